@@ -0,0 +1,102 @@
+package bot
+
+import (
+	"github.com/indes/flowerss-bot/internal/config"
+	"github.com/indes/flowerss-bot/internal/i18n"
+	"github.com/indes/flowerss-bot/internal/model"
+
+	"go.uber.org/zap"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// lookupRole 查询用户被授予的角色
+var lookupRole = model.GetRoleByUserId
+
+// userRole 用户的角色，owner 由配置指定，allowed_users 中未被授予角色的用户为 user
+func userRole(userID int64) model.Role {
+	for _, ownerID := range config.Owners {
+		if ownerID == userID {
+			return model.RoleOwner
+		}
+	}
+	if role := lookupRole(userID); role != model.RoleGuest {
+		return role
+	}
+	for _, allowUserID := range config.AllowUsers {
+		if allowUserID == userID {
+			return model.RoleUser
+		}
+	}
+	return model.RoleGuest
+}
+
+// senderRole 发送者的角色，无法确定发送者时为 guest
+func senderRole(sender *tb.User) model.Role {
+	if sender == nil {
+		return model.RoleGuest
+	}
+	return userRole(int64(sender.ID))
+}
+
+// isOwner 发送者是否为 bot 所有者
+func isOwner(sender *tb.User) bool {
+	return senderRole(sender) == model.RoleOwner
+}
+
+// roleLimit 角色的使用限制，owner 不受限制，guest 使用 user 的限制
+func roleLimit(role model.Role) config.RoleLimit {
+	if role == model.RoleOwner {
+		return config.RoleLimit{Telegraph: true, Download: true}
+	}
+	if role == model.RoleGuest {
+		role = model.RoleUser
+	}
+	if limit, ok := config.Limits[string(role)]; ok {
+		return limit
+	}
+	return config.RoleLimit{Telegraph: true, Download: true}
+}
+
+// canGrant actor 是否可以将 target 的角色修改为 role
+func canGrant(actor model.Role, target model.Role, role model.Role) bool {
+	switch actor {
+	case model.RoleOwner:
+		return target != model.RoleOwner
+	case model.RoleAdmin:
+		return target != model.RoleOwner && target != model.RoleAdmin && role != model.RoleAdmin
+	default:
+		return false
+	}
+}
+
+// subscribeLimitText 会话订阅数达到发送者角色上限时的提示，未达到时返回空字符串
+func subscribeLimitText(lang string, sender *tb.User, chatID int64) string {
+	limit := roleLimit(senderRole(sender))
+	if limit.MaxSubscriptions <= 0 {
+		return ""
+	}
+	count, err := model.CountSubsByUserID(chatID)
+	if err != nil {
+		zap.S().Warnw("count subscriptions failed", "chat id", chatID, "error", err)
+		return ""
+	}
+	if count >= int64(limit.MaxSubscriptions) {
+		return i18n.T(lang, "limit.subscriptions", limit.MaxSubscriptions)
+	}
+	return ""
+}
+
+// applyRoleLimit 按发送者角色的限制调整新订阅的设置
+func applyRoleLimit(sender *tb.User, sub *model.Subscribe) {
+	limit := roleLimit(senderRole(sender))
+	if sub.Interval < limit.MinInterval {
+		sub.Interval = limit.MinInterval
+	}
+	if !limit.Telegraph {
+		sub.EnableTelegraph = 0
+	}
+	if !limit.Download {
+		sub.EnableDownload = 0
+	}
+	sub.Save()
+}
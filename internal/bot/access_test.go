@@ -0,0 +1,57 @@
+package bot
+
+import (
+	"testing"
+
+	"github.com/indes/flowerss-bot/internal/config"
+	"github.com/indes/flowerss-bot/internal/model"
+)
+
+func Test_canGrant(t *testing.T) {
+	tests := []struct {
+		name   string
+		actor  model.Role
+		target model.Role
+		role   model.Role
+		want   bool
+	}{
+		{"owner grants admin", model.RoleOwner, model.RoleGuest, model.RoleAdmin, true},
+		{"owner revokes admin", model.RoleOwner, model.RoleAdmin, model.RoleGuest, true},
+		{"owner changes owner", model.RoleOwner, model.RoleOwner, model.RoleBanned, false},
+		{"admin bans user", model.RoleAdmin, model.RoleUser, model.RoleBanned, true},
+		{"admin grants admin", model.RoleAdmin, model.RoleUser, model.RoleAdmin, false},
+		{"admin bans admin", model.RoleAdmin, model.RoleAdmin, model.RoleBanned, false},
+		{"user grants user", model.RoleUser, model.RoleGuest, model.RoleUser, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := canGrant(tt.actor, tt.target, tt.role); got != tt.want {
+				t.Errorf("canGrant() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_roleLimit(t *testing.T) {
+	userLimit := config.RoleLimit{MaxSubscriptions: 10, MinInterval: 30}
+	config.Limits = map[string]config.RoleLimit{"user": userLimit}
+	defer func() { config.Limits = map[string]config.RoleLimit{} }()
+
+	unlimited := config.RoleLimit{Telegraph: true, Download: true}
+	tests := []struct {
+		role model.Role
+		want config.RoleLimit
+	}{
+		{model.RoleOwner, unlimited},
+		{model.RoleAdmin, unlimited},
+		{model.RoleUser, userLimit},
+		{model.RoleGuest, userLimit},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.role), func(t *testing.T) {
+			if got := roleLimit(tt.role); got != tt.want {
+				t.Errorf("roleLimit() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,23 +1,27 @@
 package bot
 
 import (
+	"encoding/json"
 	"strings"
 	"time"
 
 	"github.com/indes/flowerss-bot/internal/bot/fsm"
 	"github.com/indes/flowerss-bot/internal/config"
-	"github.com/indes/flowerss-bot/internal/util"
+	"github.com/indes/flowerss-bot/internal/i18n"
 
 	"go.uber.org/zap"
 	tb "gopkg.in/tucnak/telebot.v2"
 )
 
 var (
-	// UserState 用户状态，用于标示当前用户操作所在状态
-	UserState = make(map[int64]fsm.UserStatus)
+	// states 会话状态机，记录会话当前所处的多步操作
+	states = newStateMachine()
 
 	// B telebot
 	B *tb.Bot
+
+	// sender 限流发送器，所有推送消息都应经由它发送
+	sender = NewSender()
 )
 
 func init() {
@@ -26,16 +30,21 @@ func init() {
 	}
 	var poller tb.Poller
 	if config.TelegramWebhookEndpoint == "" {
+		// getUpdates 的结果由 newBotClient 的 transport 记录论坛话题
 		poller = &tb.LongPoller{Timeout: 10 * time.Second}
 	} else {
-		poller = &tb.Webhook{
+		poller = newTopicWebhook(&tb.Webhook{
 			Listen: ":5000",
 			Endpoint: &tb.WebhookEndpoint{
 				PublicURL: strings.TrimSuffix(config.TelegramWebhookEndpoint, "/") + "/" + config.BotToken,
 			},
-		}
+		})
 	}
 	spamProtected := tb.NewMiddlewarePoller(poller, func(upd *tb.Update) bool {
+		if isChatEvent(upd) {
+			return true
+		}
+
 		if !isUserAllowed(upd) {
 			// 检查用户是否可以使用bot
 			return false
@@ -58,7 +67,7 @@ func init() {
 		URL:    config.TelegramEndpoint,
 		Token:  config.BotToken,
 		Poller: spamProtected,
-		Client: util.HttpClient,
+		Client: newBotClient(0),
 	})
 
 	if err != nil {
@@ -67,12 +76,13 @@ func init() {
 	}
 }
 
-//Start bot
+// Start bot
 func Start() {
 	if config.RunMode != config.TestMode {
 		zap.S().Infof("bot start %s", config.AppVersionInfo())
 		setCommands()
 		setHandle()
+		go resolveChats()
 		B.Start()
 	}
 }
@@ -84,74 +94,115 @@ func Stop() {
 }
 
 func setCommands() {
-	// 设置bot命令提示信息
-	commands := []tb.Command{
-		{Text: "start", Description: "开始使用"},
-		{Text: "list", Description: "查看当前订阅的RSS源"},
-		{Text: "sub", Description: "[url] 订阅RSS源 (url 为可选)"},
-		{Text: "unsub", Description: "[url] 退订RSS源 (url 为可选)"},
-		{Text: "unsub_all", Description: "退订所有rss源"},
-
-		{Text: "set", Description: "对RSS订阅进行设置"},
-		{Text: "set_feed_tag", Description: "[sub id] [tag1] [tag2] 设置RSS订阅的标签 (最多设置三个tag，以空格分隔)"},
-		{Text: "set_interval", Description: "[interval] [sub id] 设置RSS订阅的抓取间隔 (可同时对多个sub id进行设置，以空格分隔)"},
-		{Text: "set_token", Description: "[token] 设置Put.io的token"},
-
-		{Text: "add_keyword", Description: "[keyword] 添加下载过滤关键词"},
-		{Text: "remove_keyword", Description: "移除下载过滤关键词"},
-		{Text: "download", Description: "回复推送的消息以开始下载"},
-
-		{Text: "export", Description: "导出订阅为OPML文件"},
-		{Text: "import", Description: "从OPML文件导入订阅"},
-
-		{Text: "check", Description: "检查RSS订阅的当前状态"},
-		{Text: "pause_all", Description: "停止抓取订阅更新"},
-		{Text: "active_all", Description: "开启抓取订阅更新"},
-
-		{Text: "cancel", Description: "取消当前操作"},
-		{Text: "help", Description: "使用帮助"},
-		{Text: "version", Description: "bot版本"},
+	// 设置bot命令提示信息，描述为消息目录中的 cmd.<命令>
+	names := []string{
+		"start", "list", "sub", "unsub", "unsub_all", "share", "chats",
+
+		"set", "set_feed_tag", "set_interval", "set_token", "set_template", "reset_template",
+		"set_timezone", "set_telegraph", "reset_telegraph", "language",
+
+		"add_keyword", "remove_keyword", "download",
+
+		"export", "import",
+
+		"check", "pause_all", "active_all",
+
+		"cancel", "help", "version",
 	}
 
-	zap.S().Debugf("set bot command %+v", commands)
+	// 未设置 language_code 的命令用于其他语言的用户
+	for _, code := range append([]string{""}, i18n.Languages()...) {
+		lang := code
+		if lang == "" {
+			lang = i18n.Default()
+		}
+		commands := make([]tb.Command, 0, len(names))
+		for _, name := range names {
+			commands = append(commands, tb.Command{Text: name, Description: i18n.T(lang, "cmd."+name)})
+		}
+
+		zap.S().Debugf("set bot command %s %+v", code, commands)
 
-	if err := B.SetCommands(commands); err != nil {
-		zap.S().Errorw("set bot commands failed", "error", err.Error())
+		if err := setMyCommands(commands, code); err != nil {
+			zap.S().Errorw("set bot commands failed", "language", code, "error", err.Error())
+		}
 	}
 }
 
+// setMyCommands 设置指定语言用户的命令列表，languageCode 为空时设置默认命令列表
+func setMyCommands(commands []tb.Command, languageCode string) error {
+	data, err := json.Marshal(commands)
+	if err != nil {
+		return err
+	}
+	params := map[string]string{
+		"commands": string(data),
+	}
+	if languageCode != "" {
+		params["language_code"] = languageCode
+	}
+	_, err = B.Raw("setMyCommands", params)
+	return err
+}
+
 func setHandle() {
-	B.Handle(&tb.InlineButton{Unique: "set_feed_item_btn"}, setFeedItemBtnCtr)
+	handleCallback("set_feed_item_btn", setFeedItemBtnCtr)
 
-	B.Handle(&tb.InlineButton{Unique: "set_feed_item_page"}, setFeedItemPageCtr)
+	handleCallback("set_feed_item_page", setFeedItemPageCtr)
 
-	B.Handle(&tb.InlineButton{Unique: "set_toggle_notice_btn"}, setToggleNoticeBtnCtr)
+	handleCallback("set_toggle_notice_btn", setToggleNoticeBtnCtr)
 
-	B.Handle(&tb.InlineButton{Unique: "set_toggle_telegraph_btn"}, setToggleTelegraphBtnCtr)
+	handleCallback("set_toggle_telegraph_btn", setToggleTelegraphBtnCtr)
 
-	B.Handle(&tb.InlineButton{Unique: "set_toggle_download_btn"}, setToggleDownloadBtnCtr)
+	handleCallback("set_toggle_download_btn", setToggleDownloadBtnCtr)
 
-	B.Handle(&tb.InlineButton{Unique: "set_toggle_filter_btn"}, setToggleFilterBtnCtr)
+	handleCallback("set_toggle_filter_btn", setToggleFilterBtnCtr)
 
-	B.Handle(&tb.InlineButton{Unique: "set_toggle_update_btn"}, setToggleUpdateBtnCtr)
+	handleCallback("set_toggle_update_btn", setToggleUpdateBtnCtr)
+
+	handleCallback("set_toggle_media_btn", setToggleMediaBtnCtr)
+
+	handleCallback("set_toggle_fulltext_btn", setToggleFullTextBtnCtr)
+
+	handleCallback("set_toggle_thread_btn", setToggleThreadBtnCtr)
+
+	handleCallback("set_default_btn", setDefaultBtnCtr)
 
 	// Deprecated: 此回调已不再使用，保留代码回应历史消息
-	B.Handle(&tb.InlineButton{Unique: "set_set_sub_tag_btn"}, setSubTagBtnCtr)
+	handleCallback("set_set_sub_tag_btn", setSubTagBtnCtr)
 
-	B.Handle(&tb.InlineButton{Unique: "unsub_all_confirm_btn"}, unsubAllConfirmBtnCtr)
+	handleCallback("unsub_all_confirm_btn", unsubAllConfirmBtnCtr)
 
 	// Deprecated: 此回调已不再使用，保留代码回应历史消息
-	B.Handle(&tb.InlineButton{Unique: "unsub_all_cancel_btn"}, cancelBtnCtr)
+	handleCallback("unsub_all_cancel_btn", cancelBtnCtr)
+
+	handleCallback("unsub_feed_item_btn", unsubFeedItemBtnCtr)
+
+	handleCallback("unsub_feed_item_page", unsubFeedItemPageCtr)
 
-	B.Handle(&tb.InlineButton{Unique: "unsub_feed_item_btn"}, unsubFeedItemBtnCtr)
+	handleCallback("remove_keyword_btn", removeKeywordBtnCtr)
 
-	B.Handle(&tb.InlineButton{Unique: "unsub_feed_item_page"}, unsubFeedItemPageCtr)
+	handleCallback("remove_keyword_page", removeKeywordPageCtr)
 
-	B.Handle(&tb.InlineButton{Unique: "remove_keyword_btn"}, removeKeywordBtnCtr)
+	handleCallback("cancel_btn", cancelBtnCtr)
 
-	B.Handle(&tb.InlineButton{Unique: "remove_keyword_page"}, removeKeywordPageCtr)
+	handleCallback("news_download_btn", newsDownloadBtnCtr)
 
-	B.Handle(&tb.InlineButton{Unique: "cancel_btn"}, cancelBtnCtr)
+	handleCallback("news_mute_btn", newsMuteBtnCtr)
+
+	handleCallback("news_unsub_btn", newsUnsubBtnCtr)
+
+	handleCallback("news_save_btn", newsSaveBtnCtr)
+
+	handleCallback("news_done_btn", newsDoneBtnCtr)
+
+	handleCallback("set_language_btn", setLanguageBtnCtr)
+
+	handleCallback("admin_sources_page", adminSourcesPageCtr)
+
+	handleCallback("admin_users_page", adminUsersPageCtr)
+
+	handleCallback("deeplink_sub_btn", deeplinkSubBtnCtr)
 
 	B.Handle("/start", startCmdCtr)
 
@@ -161,6 +212,14 @@ func setHandle() {
 
 	B.Handle("/list", listCmdCtr)
 
+	B.Handle("/share", shareCmdCtr)
+
+	B.Handle("/chats", chatsCmdCtr)
+
+	B.Handle(tb.OnMigration, migrationCtr)
+
+	B.Handle(tb.OnMyChatMember, myChatMemberCtr)
+
 	B.Handle("/set", setCmdCtr)
 
 	B.Handle("/unsub", unsubCmdCtr)
@@ -179,6 +238,32 @@ func setHandle() {
 
 	B.Handle("/set_interval", setIntervalCmdCtr)
 
+	B.Handle("/set_template", setTemplateCmdCtr)
+
+	B.Handle("/reset_template", resetTemplateCmdCtr)
+
+	B.Handle("/set_timezone", setTimezoneCmdCtr)
+
+	B.Handle("/set_telegraph", setTelegraphCmdCtr)
+
+	B.Handle("/reset_telegraph", resetTelegraphCmdCtr)
+
+	B.Handle("/language", languageCmdCtr)
+
+	B.Handle("/grant", grantCmdCtr)
+
+	B.Handle("/revoke", revokeCmdCtr)
+
+	B.Handle("/stats", statsCmdCtr)
+
+	B.Handle("/sources", sourcesCmdCtr)
+
+	B.Handle("/users", usersCmdCtr)
+
+	B.Handle("/announce", announceCmdCtr)
+
+	B.Handle("/invite", inviteCmdCtr)
+
 	B.Handle("/add_keyword", addKeywordCmdCtr)
 
 	B.Handle("/remove_keyword", removeKeywordCmdCtr)
@@ -195,6 +280,10 @@ func setHandle() {
 
 	B.Handle("/version", versionCmdCtr)
 
+	states.Handle(fsm.Sub, subStateCtr)
+	states.Handle(fsm.UnSub, unsubStateCtr)
+	states.Handle(fsm.Set, setStateCtr)
+
 	B.Handle(tb.OnText, textCtr)
 
 	B.Handle(tb.OnDocument, docCtr)
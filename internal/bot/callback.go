@@ -0,0 +1,113 @@
+package bot
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"strconv"
+	"strings"
+
+	"github.com/indes/flowerss-bot/internal/config"
+	"github.com/indes/flowerss-bot/internal/i18n"
+
+	"go.uber.org/zap"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// callbackSignatureSize 回调数据签名截取的字节数，签名编码后为 11 个字符，
+// 保证按钮数据不超过 telegram 64 字节的限制
+const callbackSignatureSize = 8
+
+// maxCallbackData telegram 回调数据的最大字节数
+const maxCallbackData = 64
+
+// callbackKey 回调数据签名密钥，未配置时由 bot token 派生
+func callbackKey() []byte {
+	if config.CallbackSecret != "" {
+		return []byte(config.CallbackSecret)
+	}
+	sum := sha256.Sum256([]byte("flowerss-callback:" + config.BotToken))
+	return sum[:]
+}
+
+// callbackSignature 按钮数据的签名，与按钮所在会话绑定
+func callbackSignature(chatID int64, unique string, data string) string {
+	mac := hmac.New(sha256.New, callbackKey())
+	mac.Write([]byte(strconv.FormatInt(chatID, 10) + "\f" + unique + "|" + data))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:callbackSignatureSize])
+}
+
+// signCallbackData 为按钮数据附加签名
+func signCallbackData(chatID int64, unique string, data string) string {
+	return data + "|" + callbackSignature(chatID, unique, data)
+}
+
+// callbackDataFits 按钮数据加上 telebot 前缀与签名后是否在回调数据长度限制内
+func callbackDataFits(unique string, data string) bool {
+	return len("\f"+unique+"|"+signCallbackData(0, unique, data)) <= maxCallbackData
+}
+
+// verifyCallbackData 校验按钮数据的签名，返回去掉签名后的数据
+func verifyCallbackData(chatID int64, unique string, signed string) (string, bool) {
+	idx := strings.LastIndexByte(signed, '|')
+	if idx < 0 {
+		return "", false
+	}
+	data, signature := signed[:idx], signed[idx+1:]
+	if !hmac.Equal([]byte(signature), []byte(callbackSignature(chatID, unique, data))) {
+		return "", false
+	}
+	return data, true
+}
+
+// signKeyboard 为键盘中的回调按钮签名，chatID 为消息发送到的会话
+func signKeyboard(chatID int64, keyboard [][]tb.InlineButton) [][]tb.InlineButton {
+	for i := range keyboard {
+		for j := range keyboard[i] {
+			btn := &keyboard[i][j]
+			if btn.Unique != "" {
+				btn.Data = signCallbackData(chatID, btn.Unique, btn.Data)
+			}
+		}
+	}
+	return keyboard
+}
+
+// parseButtonData 解析已发送消息中按钮的回调数据，签名不正确时返回 false
+func parseButtonData(chatID int64, btn tb.InlineButton) (unique string, data string, ok bool) {
+	if !strings.HasPrefix(btn.Data, "\f") {
+		return "", "", false
+	}
+	idx := strings.IndexByte(btn.Data, '|')
+	if idx < 0 {
+		return "", "", false
+	}
+	unique = btn.Data[1:idx]
+	data, ok = verifyCallbackData(chatID, unique, btn.Data[idx+1:])
+	return unique, data, ok
+}
+
+// handleCallback 注册按钮回调，签名校验通过后 handler 收到的 c.Data 为原始数据
+func handleCallback(unique string, handler func(*tb.Callback)) {
+	B.Handle(&tb.InlineButton{Unique: unique}, func(c *tb.Callback) {
+		if c.Message == nil {
+			return
+		}
+		data, ok := verifyCallbackData(c.Message.Chat.ID, unique, c.Data)
+		if !ok {
+			zap.S().Warnw("invalid callback signature",
+				"chat id", c.Message.Chat.ID,
+				"sender", c.Sender.ID,
+				"unique", unique,
+				"data", c.Data,
+			)
+			_ = B.Respond(c, &tb.CallbackResponse{
+				Text:      i18n.T(callbackLanguage(c), "err.callback_signature"),
+				ShowAlert: true,
+			})
+			return
+		}
+		c.Data = data
+		handler(c)
+	})
+}
@@ -0,0 +1,57 @@
+package bot
+
+import (
+	"testing"
+
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+func Test_verifyCallbackData(t *testing.T) {
+	signed := signCallbackData(100, "set_feed_item_btn", "100:2:1")
+	tests := []struct {
+		name   string
+		chatID int64
+		unique string
+		signed string
+		want   string
+		ok     bool
+	}{
+		{"valid", 100, "set_feed_item_btn", signed, "100:2:1", true},
+		{"other chat", 200, "set_feed_item_btn", signed, "", false},
+		{"other button", 100, "unsub_feed_item_btn", signed, "", false},
+		{"tampered", 100, "set_feed_item_btn", "200" + signed[3:], "", false},
+		{"unsigned", 100, "set_feed_item_btn", "100:2:1", "", false},
+		{"empty data", 100, "cancel_btn", signCallbackData(100, "cancel_btn", ""), "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := verifyCallbackData(tt.chatID, tt.unique, tt.signed)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("verifyCallbackData() = %q, %v, want %q, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func Test_signKeyboard(t *testing.T) {
+	const chatID = -1001234567890
+	keyboard := signKeyboard(chatID, [][]tb.InlineButton{{
+		{Unique: "set_toggle_telegraph_btn", Data: "-1001234567890:1234567:99"},
+		{Text: "Telegraph", URL: "https://telegra.ph"},
+	}})
+
+	btn := keyboard[0][0]
+	// telegram 限制回调数据最长 64 字节
+	if size := len("\f" + btn.Unique + "|" + btn.Data); size > 64 {
+		t.Errorf("callback data is %d bytes, want <= 64", size)
+	}
+	if keyboard[0][1].Data != "" {
+		t.Errorf("url button data = %q, want empty", keyboard[0][1].Data)
+	}
+
+	sent := tb.InlineButton{Data: "\f" + btn.Unique + "|" + btn.Data}
+	unique, data, ok := parseButtonData(chatID, sent)
+	if !ok || unique != btn.Unique || data != "-1001234567890:1234567:99" {
+		t.Errorf("parseButtonData() = %q, %q, %v", unique, data, ok)
+	}
+}
@@ -0,0 +1,92 @@
+package bot
+
+import (
+	"github.com/indes/flowerss-bot/internal/model"
+
+	"go.uber.org/zap"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// chatFromModel 由保存的会话信息生成 tb.Chat
+func chatFromModel(c *model.Chat) *tb.Chat {
+	return &tb.Chat{
+		ID:       c.ID,
+		Type:     tb.ChatType(c.Type),
+		Title:    c.Title,
+		Username: c.Username,
+	}
+}
+
+// saveChat 保存会话信息，ownerID 为管理会话的用户，私聊的所有者为用户本人
+func saveChat(chat *tb.Chat, ownerID int64) {
+	if chat == nil || chat.ID == 0 {
+		return
+	}
+	title := chat.Title
+	chatType := chat.Type
+	if chatType == tb.ChatChannel && chat.Username == "" {
+		chatType = tb.ChatChannelPrivate
+	}
+	if chatType == tb.ChatPrivate {
+		ownerID = chat.ID
+		title = chat.FirstName
+		if chat.LastName != "" {
+			title += " " + chat.LastName
+		}
+	}
+	err := model.SaveChatInfo(chat.ID, string(chatType), title, chat.Username, ownerID)
+	if err != nil {
+		zap.S().Warnw("save chat failed", "chat id", chat.ID, "error", err.Error())
+	}
+}
+
+// findChat 获取会话信息，优先使用已保存的会话，未保存时向 telegram 查询并保存
+func findChat(chatID int64) (*tb.Chat, error) {
+	if c, err := model.GetChatByID(chatID); err == nil && c.Type != "" {
+		return chatFromModel(c), nil
+	}
+	chat, err := getChatByUserId(chatID)
+	if err != nil {
+		return nil, err
+	}
+	saveChat(chat, 0)
+	return chat, nil
+}
+
+// resolveChats 补全迁移生成的会话记录，群组与频道的所有者为其创建者
+func resolveChats() {
+	chats, err := model.GetUnresolvedChats()
+	if err != nil {
+		zap.S().Warnw("get unresolved chats failed", "error", err.Error())
+		return
+	}
+	for _, c := range chats {
+		var chat *tb.Chat
+		err := sender.Do(c.ID, func() (err error) {
+			chat, err = getChatByUserId(c.ID)
+			return
+		})
+		if err != nil {
+			zap.S().Debugw("resolve chat failed", "chat id", c.ID, "error", err.Error())
+			continue
+		}
+		saveChat(chat, chatCreator(chat))
+	}
+}
+
+// chatCreator 群组或频道的创建者，无法获取时返回 0
+func chatCreator(chat *tb.Chat) int64 {
+	if !HasAdminType(chat.Type) {
+		return 0
+	}
+	admins, err := B.AdminsOf(chat)
+	if err != nil {
+		return 0
+	}
+	for _, admin := range admins {
+		if admin.Role == tb.Creator && admin.User != nil {
+			return int64(admin.User.ID)
+		}
+	}
+	return 0
+}
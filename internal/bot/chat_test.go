@@ -0,0 +1,27 @@
+package bot
+
+import (
+	"testing"
+
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+func Test_chatName(t *testing.T) {
+	tests := []struct {
+		name string
+		chat *tb.Chat
+		want string
+	}{
+		{"private", &tb.Chat{ID: 1, Type: tb.ChatPrivate, Title: "x"}, "Private chat"},
+		{"group", &tb.Chat{ID: -1, Type: tb.ChatSuperGroup, Title: "a<b"}, "Group a&lt;b"},
+		{"public channel", &tb.Chat{ID: -2, Type: tb.ChatChannel, Title: "c", Username: "ch"}, `Channel <a href="https://t.me/ch">c</a>`},
+		{"private channel", &tb.Chat{ID: -3, Type: tb.ChatChannelPrivate, Title: "c"}, "Channel c"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := chatName("en", tt.chat); got != tt.want {
+				t.Errorf("chatName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package bot
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"html"
 	"html/template"
@@ -13,7 +14,9 @@ import (
 
 	"github.com/indes/flowerss-bot/internal/bot/fsm"
 	"github.com/indes/flowerss-bot/internal/config"
+	"github.com/indes/flowerss-bot/internal/i18n"
 	"github.com/indes/flowerss-bot/internal/model"
+	"github.com/indes/flowerss-bot/internal/tgraph"
 	"github.com/indes/flowerss-bot/internal/util"
 
 	"go.uber.org/zap"
@@ -26,34 +29,47 @@ const (
 	actionToggleDownload  = "toggleDownload"
 	actionToggleFilter    = "toggleFilter"
 	actionToggleUpdate    = "toggleUpdate"
+	actionToggleMedia     = "toggleMedia"
+	actionToggleFullText  = "toggleFullText"
+	actionToggleThread    = "toggleThread"
 	limitPerPage          = 10
-)
 
-var (
-	feedSettingTmpl = `
-订阅<b>设置</b>
-[id] {{ .sub.ID }}
-[标题] <a href="{{.source.Link }}">{{ .source.Title }}</a>
-[抓取更新] {{if ge .source.ErrorCount .Count }}暂停{{else if lt .source.ErrorCount .Count }}抓取中{{end}}
-[抓取频率] {{ .sub.Interval }}分钟
-[通知] {{if eq .sub.EnableNotification 0}}关闭{{else if eq .sub.EnableNotification 1}}开启{{end}}
-[下载任务] {{if eq .sub.EnableDownload 0}}关闭{{else if eq .sub.EnableDownload 1}}开启{{end}}{{if eq .sub.EnableFilter 0}}（未过滤）{{else if eq .sub.EnableFilter 1}}（已过滤）{{end}}
-[Telegraph] {{if eq .sub.EnableTelegraph 0}}关闭{{else if eq .sub.EnableTelegraph 1}}开启{{end}}
-[Tag] {{if .sub.Tag}}{{ .sub.Tag }}{{else}}无{{end}}
-`
+	newsBtnDownload    = "download"
+	newsBtnMute        = "mute"
+	newsBtnUnsubscribe = "unsubscribe"
+	newsBtnTelegraph   = "telegraph"
+	newsBtnSave        = "save"
+	newsMuteDuration   = 24 * time.Hour
+
+	// maxAnnounceFailures 公告发送报告中最多列出的失败会话数
+	maxAnnounceFailures = 20
 )
 
+// feedSettingText 订阅设置消息
+func feedSettingText(lang string, source *model.Source, sub *model.Subscribe) string {
+	t := template.New("setting template")
+	_, _ = t.Parse(i18n.T(lang, "set.template"))
+	text := new(bytes.Buffer)
+	_ = t.Execute(text, map[string]interface{}{
+		"source": source,
+		"sub":    sub,
+		"Count":  config.ErrorThreshold,
+	})
+	return strings.TrimSpace(text.String())
+}
+
 func toggleCtrlButtons(c *tb.Callback, action string) {
+	lang := callbackLanguage(c)
 	data := strings.Split(c.Data, ":")
 	if len(data) < 2 {
-		_, _ = B.Edit(c.Message, "内部错误：回调数据不正确")
+		_, _ = B.Edit(c.Message, i18n.T(lang, "err.callback_data"))
 		return
 	}
 
 	user, err := getMentionedUser(c.Message, data[0], c.Sender)
 	if err != nil {
 		_ = B.Respond(c, &tb.CallbackResponse{
-			Text: err.Error(),
+			Text: errorText(lang, err),
 		})
 		return
 	}
@@ -67,7 +83,7 @@ func toggleCtrlButtons(c *tb.Callback, action string) {
 		return
 	}
 	sub, err := model.GetSubscribeByID(subID)
-	if sub == nil || err != nil {
+	if sub == nil || err != nil || sub.UserID != user.ID {
 		_ = B.Respond(c, &tb.CallbackResponse{
 			Text: "error",
 		})
@@ -75,21 +91,27 @@ func toggleCtrlButtons(c *tb.Callback, action string) {
 	}
 
 	source, _ := model.GetSourceById(sub.SourceID)
-	t := template.New("setting template")
-	_, _ = t.Parse(feedSettingTmpl)
 
 	switch action {
 	case actionToggleNotice:
 		err = sub.ToggleNotification()
 	case actionToggleTelegraph:
+		if sub.EnableTelegraph != 1 && !roleLimit(senderRole(c.Sender)).Telegraph {
+			_ = B.Respond(c, &tb.CallbackResponse{Text: i18n.T(lang, "limit.telegraph")})
+			return
+		}
 		err = sub.ToggleTelegraph()
 	case actionToggleDownload:
+		if sub.EnableDownload != 1 && !roleLimit(senderRole(c.Sender)).Download {
+			_ = B.Respond(c, &tb.CallbackResponse{Text: i18n.T(lang, "limit.download")})
+			return
+		}
 		err = sub.ToggleDownload()
 		if sub.EnableDownload == 1 {
 			user, err := model.FindOrCreateUserByTelegramID(sub.UserID)
 			if err != nil || user.Token == "" {
 				_ = B.Respond(c, &tb.CallbackResponse{
-					Text: "请先通过 /set_token 设置Put.io的token",
+					Text: i18n.T(lang, "set.need_token"),
 				})
 				return
 			}
@@ -98,6 +120,17 @@ func toggleCtrlButtons(c *tb.Callback, action string) {
 		err = sub.ToggleFilter()
 	case actionToggleUpdate:
 		err = source.ToggleEnabled()
+	case actionToggleMedia:
+		err = sub.ToggleMedia()
+	case actionToggleFullText:
+		err = sub.ToggleFullText()
+	case actionToggleThread:
+		threadID := settingThreadID(user, c.Message)
+		if sub.ThreadID == 0 && threadID == 0 {
+			_ = B.Respond(c, &tb.CallbackResponse{Text: i18n.T(lang, "set.thread_required")})
+			return
+		}
+		err = sub.ToggleThread(threadID)
 	}
 
 	if err != nil {
@@ -108,47 +141,235 @@ func toggleCtrlButtons(c *tb.Callback, action string) {
 	}
 	sub.Save()
 
-	text := new(bytes.Buffer)
-	_ = t.Execute(text, map[string]interface{}{
-		"source": source,
-		"sub":    sub,
-		"Count":  config.ErrorThreshold,
-	})
 	_ = B.Respond(c, &tb.CallbackResponse{
-		Text: "修改成功",
+		Text: i18n.T(lang, "set.success"),
 	})
 
-	textStr := fmt.Sprintf("%s%s", getUserHtml(user, c.Message.Chat, ""), strings.TrimSpace(text.String()))
+	textStr := getUserHtml(lang, user, c.Message.Chat, "") + feedSettingText(lang, source, sub)
 	_, _ = B.Edit(c.Message, textStr, &tb.SendOptions{
 		DisableWebPagePreview: true,
 		ParseMode:             tb.ModeHTML,
 	}, &tb.ReplyMarkup{
-		InlineKeyboard: genFeedSetBtn(c.Data, sub, source),
+		InlineKeyboard: signKeyboard(c.Message.Chat.ID, genFeedSetBtn(lang, c.Data, sub, source, settingThreadID(user, c.Message))),
 	})
 }
 
 func startCmdCtr(m *tb.Message) {
+	lang := messageLanguage(m)
+	if strings.HasPrefix(m.Payload, model.InvitePrefix) {
+		if err := redeemInvite(m, m.Payload); err != nil {
+			_, _ = B.Reply(m, errorText(lang, err))
+			return
+		}
+	}
 	user, _ := model.FindOrCreateUserByTelegramID(m.Chat.ID)
 	zap.S().Infof("/start user_id: %d telegram_id: %d", user.ID, user.TelegramID)
-	_, _ = B.Reply(m, fmt.Sprintf("你好，欢迎使用flowerss。"))
+	if m.Sender != nil {
+		saveChat(m.Chat, int64(m.Sender.ID))
+	}
+	if sourceID, url, ok := decodeFeedPayload(m.Payload); ok {
+		// 通过订阅链接打开时预览订阅源
+		previewFeedLink(lang, m, sourceID, url)
+		return
+	}
+	_, _ = B.Reply(m, i18n.T(lang, "start.welcome"))
+}
+
+// previewFeedLink 预览订阅链接对应的源，确认后订阅
+func previewFeedLink(lang string, m *tb.Message, sourceID uint, url string) {
+	msg, err := B.Reply(m, i18n.T(lang, "processing"))
+	if err != nil {
+		return
+	}
+	var source *model.Source
+	if url != "" {
+		// 确认前不保存订阅源
+		source, err = model.PreviewSourceByUrl(url)
+	} else {
+		source, err = model.GetSourceById(sourceID)
+	}
+	if err != nil {
+		_, _ = B.Edit(msg, i18n.T(lang, "deeplink.not_found"))
+		return
+	}
+	if _, err := model.GetSubscribeByUserIDAndSourceID(m.Chat.ID, source.ID); err == nil {
+		_, _ = B.Edit(msg, i18n.T(lang, "deeplink.subscribed", source.Link, html.EscapeString(source.Title)), &tb.SendOptions{
+			DisableWebPagePreview: true,
+			ParseMode:             tb.ModeHTML,
+		})
+		return
+	}
+
+	keyboard := [][]tb.InlineButton{{
+		{
+			Unique: "deeplink_sub_btn",
+			Text:   i18n.T(lang, "btn.confirm"),
+			Data:   feedButtonData("deeplink_sub_btn", source.Link),
+		},
+		{
+			Unique: "cancel_btn",
+			Text:   i18n.T(lang, "btn.cancel"),
+		},
+	}}
+	text := i18n.T(lang, "deeplink.preview", source.Link, html.EscapeString(source.Title))
+	if source.SiteLink != "" {
+		text += i18n.T(lang, "deeplink.site", source.SiteLink)
+	}
+	_, _ = B.Edit(msg, text, &tb.SendOptions{
+		DisableWebPagePreview: true,
+		ParseMode:             tb.ModeHTML,
+	}, &tb.ReplyMarkup{
+		InlineKeyboard: signKeyboard(m.Chat.ID, keyboard),
+	})
+}
+
+// deeplinkSubBtnCtr 确认订阅订阅链接对应的源
+func deeplinkSubBtnCtr(c *tb.Callback) {
+	lang := callbackLanguage(c)
+	url := c.Data
+	if url == "" {
+		url = previewFeedURL(c.Message)
+	}
+	if !CheckURL(url) {
+		_, _ = B.Edit(c.Message, i18n.T(lang, "deeplink.not_found"))
+		return
+	}
+	chat := c.Message.Chat
+	if text := subscribeLimitText(lang, c.Sender, chat.ID); text != "" {
+		_ = B.Respond(c, &tb.CallbackResponse{Text: text, ShowAlert: true})
+		return
+	}
+	_ = B.Respond(c)
+	_, _ = B.Edit(c.Message, i18n.T(lang, "processing"))
+	// 确认后才通过 RegistFeed 创建订阅源
+	subscribeFeed(lang, c.Message, c.Sender, chat, chat, url, messageThreadID(c.Message))
+}
+
+// chatsCmdCtr 列出用户管理的所有会话及其订阅数
+func chatsCmdCtr(m *tb.Message) {
+	lang := messageLanguage(m)
+	if m.Chat.Type != tb.ChatPrivate {
+		_, _ = B.Reply(m, i18n.T(lang, "chats.private_only"))
+		return
+	}
+	userID := int64(m.Sender.ID)
+	chats, err := model.GetUserChats(userID)
+	if err != nil {
+		_, _ = B.Reply(m, i18n.T(lang, "err.system"))
+		return
+	}
+
+	var text string
+	for i := range chats {
+		var chat *tb.Chat
+		if chats[i].Type != "" {
+			chat = chatFromModel(&chats[i])
+		} else if err = sender.Do(chats[i].ID, func() (err error) {
+			chat, err = findChat(chats[i].ID)
+			return
+		}); err != nil {
+			continue
+		}
+		if chat.Type == tb.ChatPrivate {
+			if chat.ID != userID {
+				continue
+			}
+		} else if HasAdminType(chat.Type) {
+			// 只列出用户仍是管理员的会话，bot 已不是频道管理员时无法查询，仍为所有者列出
+			err := isAdminOfChat(m.Sender.ID, chat)
+			if err != nil && !errors.Is(err, ErrBotNotChannelAdmin) {
+				continue
+			}
+		}
+		count, _ := model.CountSubsByUserID(chat.ID)
+		text += i18n.T(lang, "chats.item", chatName(lang, chat), chat.ID, count)
+	}
+	if text == "" {
+		_, _ = B.Reply(m, i18n.T(lang, "chats.empty"))
+		return
+	}
+	_, _ = B.Reply(m, i18n.T(lang, "chats.title")+text, &tb.SendOptions{
+		DisableWebPagePreview: true,
+		ParseMode:             tb.ModeHTML,
+	})
+}
+
+// chatName 会话的 HTML 名称，群组与频道带有类型前缀
+func chatName(lang string, chat *tb.Chat) string {
+	title := html.EscapeString(chat.Title)
+	if chat.Type == tb.ChatPrivate {
+		return i18n.T(lang, "chats.private")
+	}
+	if chat.Username != "" {
+		title = fmt.Sprintf("<a href=\"https://t.me/%s\">%s</a>", chat.Username, title)
+	}
+	if chat.Type == tb.ChatChannel || chat.Type == tb.ChatChannelPrivate {
+		return i18n.T(lang, "chat.channel") + title
+	}
+	return i18n.T(lang, "chat.group") + title
+}
+
+// shareCmdCtr 生成会话订阅的分享链接，可指定订阅 id
+func shareCmdCtr(m *tb.Message) {
+	lang := messageLanguage(m)
+	mention, args, _ := GetArgumentsFromMessage(m)
+	chat, err := getMentionedUser(m, mention, nil)
+	if err != nil {
+		_, _ = B.Reply(m, errorText(lang, err))
+		return
+	}
+	subs, err := model.GetSubsByUserID(chat.ID)
+	if err != nil {
+		_, _ = B.Reply(m, i18n.T(lang, "err.list_subs"))
+		return
+	}
+
+	wanted := make(map[uint]bool, len(args))
+	for _, arg := range args {
+		if id, err := strconv.ParseUint(arg, 10, 32); err == nil {
+			wanted[uint(id)] = true
+		}
+	}
+	text := getUserHtml(lang, chat, m.Chat, "")
+	var count int
+	for _, sub := range subs {
+		if len(wanted) > 0 && !wanted[sub.ID] {
+			continue
+		}
+		source, err := model.GetSourceById(sub.SourceID)
+		if err != nil {
+			continue
+		}
+		count++
+		text += i18n.T(lang, "share.item", sub.ID, source.Link, html.EscapeString(source.Title), feedShareLink(source))
+	}
+	if count == 0 {
+		_, _ = B.Reply(m, i18n.T(lang, "share.empty"))
+		return
+	}
+	_, _ = B.Reply(m, i18n.T(lang, "share.title")+text, &tb.SendOptions{
+		DisableWebPagePreview: true,
+		ParseMode:             tb.ModeHTML,
+	})
 }
 
 func subCmdCtr(m *tb.Message) {
+	lang := messageLanguage(m)
 	mention, _, urls := GetArgumentsFromMessage(m)
 	user, err := getMentionedUser(m, mention, nil)
 	if err != nil {
-		_, _ = B.Reply(m, err.Error())
+		_, _ = B.Reply(m, errorText(lang, err))
 		return
 	}
 
 	if len(urls) == 0 {
 		if user.ID == m.Chat.ID {
-			_, err := B.Reply(m, "请回复RSS URL", &tb.ReplyMarkup{ForceReply: true})
+			_, err := B.Reply(m, i18n.T(lang, "sub.reply_url"), &tb.ReplyMarkup{ForceReply: true})
 			if err == nil {
-				UserState[m.Chat.ID] = fsm.Sub
+				_ = states.Enter(m.Chat.ID, fsm.Sub, "")
 			}
 		} else {
-			_, _ = B.Reply(m, "频道订阅请使用' /sub @ChannelID URL ' 命令")
+			_, _ = B.Reply(m, i18n.T(lang, "sub.channel_usage"))
 		}
 		return
 	}
@@ -157,28 +378,35 @@ func subCmdCtr(m *tb.Message) {
 }
 
 func exportCmdCtr(m *tb.Message) {
+	lang := messageLanguage(m)
 	mention, _, _ := GetArgumentsFromMessage(m)
 	user, err := getMentionedUser(m, mention, nil)
 	if err != nil {
-		_, _ = B.Reply(m, err.Error())
+		_, _ = B.Reply(m, errorText(lang, err))
 		return
 	}
 	sourceList, _, _, err := model.GetSourcesByUserID(user.ID, 0, 0)
 	if err != nil {
 		zap.S().Errorf(err.Error())
-		_, _ = B.Reply(m, fmt.Sprintf("导出失败"))
+		_, _ = B.Reply(m, i18n.T(lang, "export.failed"))
 		return
 	}
 
 	if len(sourceList) == 0 {
-		_, _ = B.Reply(m, fmt.Sprintf("订阅列表为空"))
+		_, _ = B.Reply(m, i18n.T(lang, "list.empty"))
 		return
 	}
 
-	opmlStr, err := ToOPML(sourceList)
+	threads := make(map[uint]int)
+	if subs, err := model.GetSubsByUserID(user.ID); err == nil {
+		for _, sub := range subs {
+			threads[sub.SourceID] = sub.ThreadID
+		}
+	}
+	opmlStr, err := ToOPML(sourceList, threads)
 
 	if err != nil {
-		_, _ = B.Reply(m, fmt.Sprintf("导出失败"))
+		_, _ = B.Reply(m, i18n.T(lang, "export.failed"))
 		return
 	}
 	opmlFile := &tb.Document{File: tb.FromReader(strings.NewReader(opmlStr))}
@@ -186,35 +414,36 @@ func exportCmdCtr(m *tb.Message) {
 	_, err = B.Reply(m, opmlFile)
 
 	if err != nil {
-		_, _ = B.Reply(m, fmt.Sprintf("导出失败"))
+		_, _ = B.Reply(m, i18n.T(lang, "export.failed"))
 		zap.S().Errorf("send opml file failed, err:%+v", err)
 	}
 }
 
 func listCmdCtr(m *tb.Message) {
+	lang := messageLanguage(m)
 	mention, _, _ := GetArgumentsFromMessage(m)
 	chat, err := getMentionedUser(m, mention, nil)
 	if err != nil {
-		_, _ = B.Reply(m, err.Error())
+		_, _ = B.Reply(m, errorText(lang, err))
 		return
 	}
 
 	user, err := model.FindOrCreateUserByTelegramID(chat.ID)
 	if err != nil {
-		_, _ = B.Reply(m, "内部错误：无法找到对应的用户")
+		_, _ = B.Reply(m, i18n.T(lang, "err.user_not_found"))
 		return
 	}
 	subSourceMap, err := user.GetSubSourceMap()
 	if err != nil {
-		_, _ = B.Reply(m, "内部错误：无法查询用户订阅列表")
+		_, _ = B.Reply(m, i18n.T(lang, "err.list_subs"))
 		return
 	}
 
-	rspMessage := getUserHtml(chat, m.Chat, "当前")
+	rspMessage := getUserHtml(lang, chat, m.Chat, i18n.T(lang, "list.current"))
 	if len(subSourceMap) == 0 {
-		rspMessage += "订阅列表为空"
+		rspMessage += i18n.T(lang, "list.empty")
 	} else {
-		rspMessage += "订阅列表：\n"
+		rspMessage += i18n.T(lang, "list.title")
 		var subs []model.Subscribe
 		for sub, _ := range subSourceMap {
 			subs = append(subs, sub)
@@ -234,21 +463,22 @@ func listCmdCtr(m *tb.Message) {
 }
 
 func checkCmdCtr(m *tb.Message) {
+	lang := messageLanguage(m)
 	mention, _, _ := GetArgumentsFromMessage(m)
 	user, err := getMentionedUser(m, mention, nil)
 	if err != nil {
-		_, _ = B.Reply(m, err.Error())
+		_, _ = B.Reply(m, errorText(lang, err))
 		return
 	}
 	sources, _ := model.GetErrorSourcesByUserID(user.ID)
-	message := getUserHtml(user, m.Chat, "")
+	message := getUserHtml(lang, user, m.Chat, "")
 	if len(sources) > 0 {
-		message += "失效订阅的列表：\n"
+		message += i18n.T(lang, "check.error_list")
 		for _, source := range sources {
 			message += fmt.Sprintf("[%d] <a href=\"%s\">%s</a>\n", source.ID, source.Link, html.EscapeString(source.Title))
 		}
 	} else {
-		message += "所有订阅正常"
+		message += i18n.T(lang, "check.all_ok")
 	}
 	_, _ = B.Reply(m, message, &tb.SendOptions{
 		DisableWebPagePreview: true,
@@ -257,37 +487,39 @@ func checkCmdCtr(m *tb.Message) {
 }
 
 func setCmdCtr(m *tb.Message) {
+	lang := messageLanguage(m)
 	mention, _, _ := GetArgumentsFromMessage(m)
 	user, err := getMentionedUser(m, mention, nil)
 	if err != nil {
-		_, _ = B.Reply(m, err.Error())
+		_, _ = B.Reply(m, errorText(lang, err))
 		return
 	}
 
-	msg, _ := B.Reply(m, "处理中...")
-	setFeedItemsCurrentPage(msg, user, 1)
+	msg, _ := B.Reply(m, i18n.T(lang, "processing"))
+	setFeedItemsCurrentPage(lang, msg, user, 1)
 }
 
 func setFeedItemPageCtr(c *tb.Callback) {
+	lang := callbackLanguage(c)
 	data := strings.Split(c.Data, ":")
 	if len(data) < 2 {
-		_, _ = B.Edit(c.Message, "内部错误：回调数据不正确")
+		_, _ = B.Edit(c.Message, i18n.T(lang, "err.callback_data"))
 		return
 	}
 
 	user, err := getMentionedUser(c.Message, data[0], c.Sender)
 	if err != nil {
 		_ = B.Respond(c, &tb.CallbackResponse{
-			Text: err.Error(),
+			Text: errorText(lang, err),
 		})
 		return
 	}
 
 	page, _ := strconv.Atoi(data[1])
-	setFeedItemsCurrentPage(c.Message, user, page)
+	setFeedItemsCurrentPage(lang, c.Message, user, page)
 }
 
-func setFeedItemsCurrentPage(m *tb.Message, user *tb.Chat, page int) {
+func setFeedItemsCurrentPage(lang string, m *tb.Message, user *tb.Chat, page int) {
 	sources, hasPrev, hasNext, _ := model.GetSourcesByUserID(user.ID, page, limitPerPage)
 
 	var setFeedItemBtns [][]tb.InlineButton
@@ -305,39 +537,40 @@ func setFeedItemsCurrentPage(m *tb.Message, user *tb.Chat, page int) {
 	if hasPrev {
 		lastRow = append(lastRow, tb.InlineButton{
 			Unique: "set_feed_item_page",
-			Text:   "上一页",
+			Text:   i18n.T(lang, "btn.prev"),
 			Data:   fmt.Sprintf("%d:%d", user.ID, page-1),
 		})
 	}
 	lastRow = append(lastRow, tb.InlineButton{
 		Unique: "cancel_btn",
-		Text:   "取消",
+		Text:   i18n.T(lang, "btn.cancel"),
 	})
 	if hasNext {
 		lastRow = append(lastRow, tb.InlineButton{
 			Unique: "set_feed_item_page",
-			Text:   "下一页",
+			Text:   i18n.T(lang, "btn.next"),
 			Data:   fmt.Sprintf("%d:%d", user.ID, page+1),
 		})
 	}
 	setFeedItemBtns = append(setFeedItemBtns, lastRow)
 
-	_, _ = B.Edit(m, "请选择你要设置的源", &tb.ReplyMarkup{
-		InlineKeyboard: setFeedItemBtns,
+	_, _ = B.Edit(m, i18n.T(lang, "set.choose"), &tb.ReplyMarkup{
+		InlineKeyboard: signKeyboard(m.Chat.ID, setFeedItemBtns),
 	})
 }
 
 func setFeedItemBtnCtr(c *tb.Callback) {
+	lang := callbackLanguage(c)
 	data := strings.Split(c.Data, ":")
 	if len(data) < 2 {
-		_, _ = B.Edit(c.Message, "内部错误：回调数据不正确")
+		_, _ = B.Edit(c.Message, i18n.T(lang, "err.callback_data"))
 		return
 	}
 
 	user, err := getMentionedUser(c.Message, data[0], c.Sender)
 	if err != nil {
 		_ = B.Respond(c, &tb.CallbackResponse{
-			Text: err.Error(),
+			Text: errorText(lang, err),
 		})
 		return
 	}
@@ -345,45 +578,37 @@ func setFeedItemBtnCtr(c *tb.Callback) {
 	sourceID, _ := strconv.Atoi(data[1])
 	source, err := model.GetSourceById(uint(sourceID))
 	if err != nil {
-		_, _ = B.Edit(c.Message, "找不到该订阅源，错误代码01。")
+		_, _ = B.Edit(c.Message, i18n.T(lang, "err.source_not_found"))
 		return
 	}
 
 	sub, err := model.GetSubscribeByUserIDAndSourceID(user.ID, source.ID)
 	if err != nil {
-		_, _ = B.Edit(c.Message, "用户未订阅该rss，错误代码02。")
+		_, _ = B.Edit(c.Message, i18n.T(lang, "err.user_not_subscribed"))
 		return
 	}
 
-	t := template.New("setting template")
-	_, _ = t.Parse(feedSettingTmpl)
-	text := new(bytes.Buffer)
-	_ = t.Execute(text, map[string]interface{}{
-		"source": source,
-		"sub":    sub,
-		"Count":  config.ErrorThreshold,
-	})
-
-	textStr := fmt.Sprintf("%s%s", getUserHtml(user, c.Message.Chat, ""), strings.TrimSpace(text.String()))
+	textStr := getUserHtml(lang, user, c.Message.Chat, "") + feedSettingText(lang, source, sub)
 	_, _ = B.Edit(c.Message, textStr, &tb.SendOptions{
 		DisableWebPagePreview: true,
 		ParseMode:             tb.ModeHTML,
 	}, &tb.ReplyMarkup{
-		InlineKeyboard: genFeedSetBtn(c.Data, sub, source),
+		InlineKeyboard: signKeyboard(c.Message.Chat.ID, genFeedSetBtn(lang, c.Data, sub, source, settingThreadID(user, c.Message))),
 	})
 }
 
 func setSubTagBtnCtr(c *tb.Callback) {
+	lang := callbackLanguage(c)
 	data := strings.Split(c.Data, ":")
 	if len(data) < 2 {
-		_ = B.Respond(c, &tb.CallbackResponse{Text: "内部错误：回调数据不正确"})
+		_ = B.Respond(c, &tb.CallbackResponse{Text: i18n.T(lang, "err.callback_data")})
 		return
 	}
 
 	user, err := getMentionedUser(c.Message, data[0], c.Sender)
 	if err != nil {
 		_ = B.Respond(c, &tb.CallbackResponse{
-			Text: err.Error(),
+			Text: errorText(lang, err),
 		})
 		return
 	}
@@ -391,61 +616,85 @@ func setSubTagBtnCtr(c *tb.Callback) {
 	sourceID, _ := strconv.Atoi(data[1])
 	sub, err := model.GetSubscribeByUserIDAndSourceID(user.ID, uint(sourceID))
 	if err != nil {
-		_ = B.Respond(c, &tb.CallbackResponse{Text: "系统错误，代码04"})
+		_ = B.Respond(c, &tb.CallbackResponse{Text: i18n.T(lang, "err.system")})
 		return
 	}
 
-	msg := fmt.Sprintf(
-		"请使用`/set_feed_tag %d tags`命令为该订阅设置标签，tags为需要设置的标签，以空格分隔。（最多设置三个标签） \n"+
-			"例如：`/set_feed_tag %d 科技 苹果`",
-		sub.ID, sub.ID)
+	msg := i18n.T(lang, "set.tag_usage", sub.ID, sub.ID)
 	_, _ = B.Edit(c.Message, msg, &tb.SendOptions{ParseMode: tb.ModeMarkdown})
 }
 
-func genFeedSetBtn(data string, sub *model.Subscribe, source *model.Source) [][]tb.InlineButton {
+// settingThreadID 设置消息所在的论坛话题，设置其他会话的订阅时返回 0
+func settingThreadID(user *tb.Chat, m *tb.Message) int {
+	if user.ID != m.Chat.ID {
+		return 0
+	}
+	return messageThreadID(m)
+}
+
+// genFeedSetBtn 订阅设置按钮，threadID 为设置消息所在的论坛话题
+func genFeedSetBtn(lang string, data string, sub *model.Subscribe, source *model.Source, threadID int) [][]tb.InlineButton {
 	toggleDownloadKey := tb.InlineButton{
 		Unique: "set_toggle_download_btn",
-		Text:   "开启下载",
+		Text:   i18n.T(lang, "set.enable_dl"),
 		Data:   data,
 	}
 	if sub.EnableDownload == 1 {
-		toggleDownloadKey.Text = "关闭下载"
+		toggleDownloadKey.Text = i18n.T(lang, "set.disable_dl")
 	}
 
 	toggleNoticeKey := tb.InlineButton{
 		Unique: "set_toggle_notice_btn",
-		Text:   "开启通知",
+		Text:   i18n.T(lang, "set.enable_notice"),
 		Data:   data,
 	}
 	if sub.EnableNotification == 1 {
-		toggleNoticeKey.Text = "关闭通知"
+		toggleNoticeKey.Text = i18n.T(lang, "set.disable_notice")
 	}
 
 	toggleTelegraphKey := tb.InlineButton{
 		Unique: "set_toggle_telegraph_btn",
-		Text:   "开启 Telegraph 转码",
+		Text:   i18n.T(lang, "set.enable_tg"),
 		Data:   data,
 	}
 	if sub.EnableTelegraph == 1 {
-		toggleTelegraphKey.Text = "关闭 Telegraph 转码"
+		toggleTelegraphKey.Text = i18n.T(lang, "set.disable_tg")
 	}
 
 	toggleEnabledKey := tb.InlineButton{
 		Unique: "set_toggle_update_btn",
-		Text:   "暂停更新",
+		Text:   i18n.T(lang, "set.pause_update"),
 		Data:   data,
 	}
 	if source.ErrorCount >= config.ErrorThreshold {
-		toggleEnabledKey.Text = "重启更新"
+		toggleEnabledKey.Text = i18n.T(lang, "set.resume_update")
 	}
 
 	toggleFilterKey := tb.InlineButton{
 		Unique: "set_toggle_filter_btn",
-		Text:   "开启下载过滤",
+		Text:   i18n.T(lang, "set.enable_filter"),
 		Data:   data,
 	}
 	if sub.EnableFilter == 1 {
-		toggleFilterKey.Text = "关闭下载过滤"
+		toggleFilterKey.Text = i18n.T(lang, "set.disable_filter")
+	}
+
+	toggleMediaKey := tb.InlineButton{
+		Unique: "set_toggle_media_btn",
+		Text:   i18n.T(lang, "set.enable_media"),
+		Data:   data,
+	}
+	if sub.EnableMedia == 1 {
+		toggleMediaKey.Text = i18n.T(lang, "set.disable_media")
+	}
+
+	toggleFullTextKey := tb.InlineButton{
+		Unique: "set_toggle_fulltext_btn",
+		Text:   i18n.T(lang, "set.enable_full"),
+		Data:   data,
+	}
+	if sub.EnableFullText == 1 {
+		toggleFullTextKey.Text = i18n.T(lang, "set.disable_full")
 	}
 
 	parts := strings.Split(data, ":")
@@ -454,10 +703,16 @@ func genFeedSetBtn(data string, sub *model.Subscribe, source *model.Source) [][]
 	}
 	backKey := tb.InlineButton{
 		Unique: "set_feed_item_page",
-		Text:   "返回",
+		Text:   i18n.T(lang, "btn.back"),
 		Data:   fmt.Sprintf("%s:%s", parts[0], parts[2]),
 	}
 
+	saveDefaultKey := tb.InlineButton{
+		Unique: "set_default_btn",
+		Text:   i18n.T(lang, "set.save_default"),
+		Data:   data,
+	}
+
 	feedSettingKeys := [][]tb.InlineButton{
 		{
 			toggleEnabledKey,
@@ -469,10 +724,27 @@ func genFeedSetBtn(data string, sub *model.Subscribe, source *model.Source) [][]
 		},
 		{
 			toggleTelegraphKey,
-			backKey,
+			toggleMediaKey,
+		},
+		{
+			toggleFullTextKey,
+			saveDefaultKey,
 		},
 	}
-	return feedSettingKeys
+	if sub.ThreadID == 0 && threadID == 0 {
+		return append(feedSettingKeys, []tb.InlineButton{backKey})
+	}
+
+	// 在论坛话题中设置或已指定话题时可切换推送的话题
+	toggleThreadKey := tb.InlineButton{
+		Unique: "set_toggle_thread_btn",
+		Text:   i18n.T(lang, "set.enable_thread"),
+		Data:   data,
+	}
+	if sub.ThreadID != 0 {
+		toggleThreadKey.Text = i18n.T(lang, "set.disable_thread")
+	}
+	return append(feedSettingKeys, []tb.InlineButton{toggleThreadKey, backKey})
 }
 
 func setToggleNoticeBtnCtr(c *tb.Callback) {
@@ -495,58 +767,102 @@ func setToggleUpdateBtnCtr(c *tb.Callback) {
 	toggleCtrlButtons(c, actionToggleUpdate)
 }
 
+func setToggleMediaBtnCtr(c *tb.Callback) {
+	toggleCtrlButtons(c, actionToggleMedia)
+}
+
+func setToggleFullTextBtnCtr(c *tb.Callback) {
+	toggleCtrlButtons(c, actionToggleFullText)
+}
+
+func setToggleThreadBtnCtr(c *tb.Callback) {
+	toggleCtrlButtons(c, actionToggleThread)
+}
+
+// setDefaultBtnCtr 将订阅的设置保存为会话新订阅的默认设置
+func setDefaultBtnCtr(c *tb.Callback) {
+	lang := callbackLanguage(c)
+	data := strings.Split(c.Data, ":")
+	if len(data) < 2 {
+		_ = B.Respond(c, &tb.CallbackResponse{Text: i18n.T(lang, "err.callback_data")})
+		return
+	}
+
+	user, err := getMentionedUser(c.Message, data[0], c.Sender)
+	if err != nil {
+		_ = B.Respond(c, &tb.CallbackResponse{
+			Text: errorText(lang, err),
+		})
+		return
+	}
+
+	sourceID, _ := strconv.Atoi(data[1])
+	sub, err := model.GetSubscribeByUserIDAndSourceID(user.ID, uint(sourceID))
+	if err != nil {
+		_ = B.Respond(c, &tb.CallbackResponse{Text: i18n.T(lang, "err.user_not_subscribed")})
+		return
+	}
+	if err := model.SaveChatDefaults(user.ID, sub); err != nil {
+		_ = B.Respond(c, &tb.CallbackResponse{Text: i18n.T(lang, "err.system")})
+		return
+	}
+	_ = B.Respond(c, &tb.CallbackResponse{Text: i18n.T(lang, "set.default_saved")})
+}
+
 func unsubCmdCtr(m *tb.Message) {
+	lang := messageLanguage(m)
 	mention, _, urls := GetArgumentsFromMessage(m)
 	user, err := getMentionedUser(m, mention, nil)
 	if err != nil {
-		_, _ = B.Reply(m, err.Error())
+		_, _ = B.Reply(m, errorText(lang, err))
 		return
 	}
 
 	if len(urls) > 0 {
 		source, err := model.GetSourceByUrl(urls[0])
 		if err != nil {
-			_, _ = B.Reply(m, "未订阅该RSS源")
+			_, _ = B.Reply(m, i18n.T(lang, "err.not_subscribed"))
 			return
 		}
 		err = model.UnsubByUserIDAndSource(user.ID, source)
 		if err != nil {
-			_, _ = B.Reply(m, fmt.Sprintf("退订失败：%s", err.Error()))
+			_, _ = B.Reply(m, i18n.T(lang, "unsub.failed", errorText(lang, err)))
 			return
 		}
-		text := getUserHtml(user, m.Chat, "")
-		text += fmt.Sprintf("退订 <a href=\"%s\">%s</a> 成功！", source.Link, html.EscapeString(source.Title))
+		text := getUserHtml(lang, user, m.Chat, "")
+		text += i18n.T(lang, "unsub.success", source.Link, html.EscapeString(source.Title))
 		_, _ = B.Reply(m, text, &tb.SendOptions{
 			DisableWebPagePreview: true,
 			ParseMode:             tb.ModeHTML,
 		})
 		zap.S().Infof("%d unsubscribe [%d]%s %s", user.ID, source.ID, source.Title, source.Link)
 	} else {
-		msg, _ := B.Reply(m, "处理中...")
-		unsubFeedItemsCurrentPage(msg, user, 1)
+		msg, _ := B.Reply(m, i18n.T(lang, "processing"))
+		unsubFeedItemsCurrentPage(lang, msg, user, 1)
 	}
 }
 
 func unsubFeedItemPageCtr(c *tb.Callback) {
+	lang := callbackLanguage(c)
 	data := strings.Split(c.Data, ":")
 	if len(data) != 2 {
-		_, _ = B.Edit(c.Message, "内部错误：回调数据不正确")
+		_, _ = B.Edit(c.Message, i18n.T(lang, "err.callback_data"))
 		return
 	}
 
 	user, err := getMentionedUser(c.Message, data[0], c.Sender)
 	if err != nil {
 		_ = B.Respond(c, &tb.CallbackResponse{
-			Text: err.Error(),
+			Text: errorText(lang, err),
 		})
 		return
 	}
 
 	page, _ := strconv.Atoi(data[1])
-	unsubFeedItemsCurrentPage(c.Message, user, page)
+	unsubFeedItemsCurrentPage(lang, c.Message, user, page)
 }
 
-func unsubFeedItemsCurrentPage(m *tb.Message, user *tb.Chat, page int) {
+func unsubFeedItemsCurrentPage(lang string, m *tb.Message, user *tb.Chat, page int) {
 	subs, hasPrev, hasNext, _ := model.GetSubsByUserIdByPage(user.ID, page, limitPerPage)
 
 	var unsubFeedItemBtns [][]tb.InlineButton
@@ -567,55 +883,56 @@ func unsubFeedItemsCurrentPage(m *tb.Message, user *tb.Chat, page int) {
 	if hasPrev {
 		lastRow = append(lastRow, tb.InlineButton{
 			Unique: "unsub_feed_item_page",
-			Text:   "上一页",
+			Text:   i18n.T(lang, "btn.prev"),
 			Data:   fmt.Sprintf("%d:%d", user.ID, page-1),
 		})
 	}
 	lastRow = append(lastRow, tb.InlineButton{
 		Unique: "cancel_btn",
-		Text:   "取消",
+		Text:   i18n.T(lang, "btn.cancel"),
 	})
 	if hasNext {
 		lastRow = append(lastRow, tb.InlineButton{
 			Unique: "unsub_feed_item_page",
-			Text:   "下一页",
+			Text:   i18n.T(lang, "btn.next"),
 			Data:   fmt.Sprintf("%d:%d", user.ID, page+1),
 		})
 	}
 	unsubFeedItemBtns = append(unsubFeedItemBtns, lastRow)
 
-	_, _ = B.Edit(m, "请选择你要退订的源", &tb.ReplyMarkup{
-		InlineKeyboard: unsubFeedItemBtns,
+	_, _ = B.Edit(m, i18n.T(lang, "unsub.choose"), &tb.ReplyMarkup{
+		InlineKeyboard: signKeyboard(m.Chat.ID, unsubFeedItemBtns),
 	})
 }
 
 func unsubFeedItemBtnCtr(c *tb.Callback) {
+	lang := callbackLanguage(c)
 	data := strings.Split(c.Data, ":")
 	if len(data) != 3 {
-		_, _ = B.Edit(c.Message, "内部错误：回调数据不正确")
+		_, _ = B.Edit(c.Message, i18n.T(lang, "err.callback_data"))
 		return
 	}
 	user, err := getMentionedUser(c.Message, data[0], c.Sender)
 	if err != nil {
 		_ = B.Respond(c, &tb.CallbackResponse{
-			Text: err.Error(),
+			Text: errorText(lang, err),
 		})
 		return
 	}
 	sourceID, _ := strconv.Atoi(data[2])
 	source, err := model.GetSourceById(uint(sourceID))
 	if err != nil {
-		_, _ = B.Edit(c.Message, "未订阅该RSS源")
+		_, _ = B.Edit(c.Message, i18n.T(lang, "err.not_subscribed"))
 		return
 	}
 	subID, _ := strconv.Atoi(data[1])
 	err = model.UnsubByUserIDAndSubID(user.ID, uint(subID))
 	if err != nil {
-		_, _ = B.Edit(c.Message, fmt.Sprintf("退订失败：%s", err.Error()))
+		_, _ = B.Edit(c.Message, i18n.T(lang, "unsub.failed", errorText(lang, err)))
 		return
 	}
-	text := getUserHtml(user, c.Message.Chat, "")
-	text += fmt.Sprintf("退订 <a href=\"%s\">%s</a> 成功！", source.Link, html.EscapeString(source.Title))
+	text := getUserHtml(lang, user, c.Message.Chat, "")
+	text += i18n.T(lang, "unsub.success", source.Link, html.EscapeString(source.Title))
 	_, _ = B.Edit(c.Message, text, &tb.SendOptions{
 		DisableWebPagePreview: true,
 		ParseMode:             tb.ModeHTML,
@@ -624,10 +941,11 @@ func unsubFeedItemBtnCtr(c *tb.Callback) {
 }
 
 func unsubAllCmdCtr(m *tb.Message) {
+	lang := messageLanguage(m)
 	mention, _, _ := GetArgumentsFromMessage(m)
 	user, err := getMentionedUser(m, mention, nil)
 	if err != nil {
-		_, _ = B.Reply(m, err.Error())
+		_, _ = B.Reply(m, errorText(lang, err))
 		return
 	}
 
@@ -635,45 +953,48 @@ func unsubAllCmdCtr(m *tb.Message) {
 	confirmKeys = append(confirmKeys, []tb.InlineButton{
 		{
 			Unique: "unsub_all_confirm_btn",
-			Text:   "确认",
+			Text:   i18n.T(lang, "btn.confirm"),
 			Data:   strconv.FormatInt(user.ID, 10),
 		},
 		{
 			Unique: "unsub_all_cancel_btn",
-			Text:   "取消",
+			Text:   i18n.T(lang, "btn.cancel"),
 		},
 	})
 
-	msg := fmt.Sprintf("是否退订%s的所有订阅？", getUserHtml(user, m.Chat, "当前用户"))
+	msg := i18n.T(lang, "unsub_all.confirm", getUserHtml(lang, user, m.Chat, i18n.T(lang, "unsub_all.current")))
 	_, _ = B.Reply(m, msg, &tb.SendOptions{
 		DisableWebPagePreview: true,
 		ParseMode:             tb.ModeHTML,
 	}, &tb.ReplyMarkup{
-		InlineKeyboard: confirmKeys,
+		InlineKeyboard: signKeyboard(m.Chat.ID, confirmKeys),
 	})
 }
 
 func cancelBtnCtr(c *tb.Callback) {
-	_, _ = B.Edit(c.Message, "操作已取消。")
-	UserState[c.Message.Chat.ID] = fsm.None
+	lang := callbackLanguage(c)
+	_, _ = B.Edit(c.Message, i18n.T(lang, "cancel.btn_done"))
+	_ = states.Reset(c.Message.Chat.ID)
 }
 
 func cancelCmdCtr(m *tb.Message) {
-	_, _ = B.Reply(m, "当前操作已取消。", &tb.ReplyMarkup{
+	lang := messageLanguage(m)
+	_, _ = B.Reply(m, i18n.T(lang, "cancel.done"), &tb.ReplyMarkup{
 		ReplyKeyboardRemove: true,
 	})
-	UserState[m.Chat.ID] = fsm.None
+	_ = states.Reset(m.Chat.ID)
 }
 
 func unsubAllConfirmBtnCtr(c *tb.Callback) {
+	lang := callbackLanguage(c)
 	if c.Data == "" {
-		_, _ = B.Edit(c.Message, "内部错误：回调内容不正确")
+		_, _ = B.Edit(c.Message, i18n.T(lang, "err.callback_data"))
 		return
 	}
 	user, err := getMentionedUser(c.Message, c.Data, c.Sender)
 	if err != nil {
 		_ = B.Respond(c, &tb.CallbackResponse{
-			Text: err.Error(),
+			Text: errorText(lang, err),
 		})
 		return
 	}
@@ -681,9 +1002,9 @@ func unsubAllConfirmBtnCtr(c *tb.Callback) {
 	var msg string
 	success, fail, err := model.UnsubAllByUserID(user.ID)
 	if err != nil {
-		msg = "退订失败"
+		msg = i18n.T(lang, "unsub_all.failed")
 	} else {
-		msg = fmt.Sprintf("退订成功：%d\n退订失败：%d", success, fail)
+		msg = i18n.T(lang, "unsub_all.result", success, fail)
 	}
 	_, _ = B.Edit(c.Message, msg)
 }
@@ -697,27 +1018,8 @@ func pingCmdCtr(m *tb.Message) {
 }
 
 func helpCmdCtr(m *tb.Message) {
-	message := `
-命令：
-/sub 订阅源
-/unsub  取消订阅
-/list 查看当前订阅源
-/set 设置订阅
-/check 检查当前订阅
-/set_feed_tag 设置订阅标签
-/set_interval 设置订阅刷新频率
-/set_token 设置Put.io的token
-/add_keyword 添加需下载的关键词
-/remove_keyword 移除需下载的关键词
-/download 开启特定下载任务
-/active_all 开启所有订阅
-/pause_all 暂停所有订阅
-/help 帮助
-/import 导入 OPML 文件
-/export 导出 OPML 文件
-/unsub_all 取消所有订阅
-详细使用方法请看：https://flowerss-bot.vercel.app/#/usage
-`
+	lang := messageLanguage(m)
+	message := i18n.T(lang, "help")
 
 	_, _ = B.Reply(m, message, &tb.SendOptions{DisableWebPagePreview: true})
 }
@@ -727,85 +1029,90 @@ func versionCmdCtr(m *tb.Message) {
 }
 
 func importCmdCtr(m *tb.Message) {
-	message := `请直接发送OPML文件，
-如果需要为channel导入OPML，请在发送文件的时候附上channel id，例如@telegram
-`
+	lang := messageLanguage(m)
+	message := i18n.T(lang, "import.usage")
 	_, _ = B.Reply(m, message)
 }
 
 func setFeedTagCmdCtr(m *tb.Message) {
+	lang := messageLanguage(m)
 	_, args, _ := GetArgumentsFromMessage(m)
 	if len(args) < 1 {
-		_, _ = B.Reply(m, "/set_feed_tag [sub id] [tag1] [tag2] 设置订阅标签（最多设置三个Tag，以空格分割）")
+		_, _ = B.Reply(m, i18n.T(lang, "tag.usage"))
 		return
 	}
 
 	subID, err := strconv.Atoi(args[0])
 	if err != nil {
-		_, _ = B.Reply(m, "请输入正确的订阅ID！")
-		return
-	}
-	sub, err := model.GetSubscribeByID(subID)
-	if err != nil {
-		_, _ = B.Reply(m, "请输入正确的订阅ID！")
+		_, _ = B.Reply(m, i18n.T(lang, "err.invalid_sub_id"))
 		return
 	}
-	_, err = getMentionedUser(m, strconv.FormatInt(sub.UserID, 10), nil)
+	_, sub, err := getOwnedSubscribe(lang, m, subID)
 	if err != nil {
-		_, _ = B.Reply(m, err.Error())
+		_, _ = B.Reply(m, errorText(lang, err))
 		return
 	}
 
 	err = sub.SetTag(args[1:])
 	if err != nil {
-		_, _ = B.Reply(m, "订阅标签设置失败！")
+		_, _ = B.Reply(m, i18n.T(lang, "tag.failed"))
 		return
 	}
-	_, _ = B.Reply(m, "订阅标签设置成功！")
+	_, _ = B.Reply(m, i18n.T(lang, "tag.success"))
 }
 
 func setTokenCmdCtr(m *tb.Message) {
+	lang := messageLanguage(m)
 	mention, args, _ := GetArgumentsFromMessage(m)
 	if len(args) < 1 {
-		_, _ = B.Reply(m, "/set_token [token] 设置Put.io的token")
+		_, _ = B.Reply(m, i18n.T(lang, "token.usage"))
 		return
 	}
 	token := args[0]
+	if !roleLimit(senderRole(m.Sender)).Download {
+		_, _ = B.Reply(m, i18n.T(lang, "limit.download"))
+		return
+	}
 
 	user, err := getMentionedUser(m, mention, nil)
 	if err != nil {
-		_, _ = B.Reply(m, err.Error())
+		_, _ = B.Reply(m, errorText(lang, err))
 		return
 	}
 
 	client := NewPutIoClient(token)
 	info, err := client.Account.Info(context.Background())
 	if err != nil {
-		_, _ = B.Reply(m, "无效的token")
+		_, _ = B.Reply(m, i18n.T(lang, "token.invalid"))
 		return
 	}
-	text := getUserHtml(user, m.Chat, "")
+	text := getUserHtml(lang, user, m.Chat, "")
 	err = model.SaveTokenByUserId(user.ID, token)
 	if err != nil {
-		_, _ = B.Reply(m, fmt.Sprintf("%s保存token失败", text))
+		_, _ = B.Reply(m, i18n.T(lang, "token.save_failed", text))
 		return
 	}
-	_, _ = B.Reply(m, fmt.Sprintf("%s成功保存了 %s 的 token", text, info.Username), &tb.SendOptions{
+	_, _ = B.Reply(m, i18n.T(lang, "token.success", text, info.Username), &tb.SendOptions{
 		DisableWebPagePreview: true,
 		ParseMode:             tb.ModeHTML,
 	})
 }
 
 func setIntervalCmdCtr(m *tb.Message) {
+	lang := messageLanguage(m)
 	args := strings.Split(m.Payload, " ")
 	if len(args) < 1 {
-		_, _ = B.Reply(m, "/set_interval [interval] [sub id] 设置订阅刷新频率（可设置多个sub id，以空格分割）")
+		_, _ = B.Reply(m, i18n.T(lang, "interval.usage"))
 		return
 	}
 
 	interval, err := strconv.Atoi(args[0])
 	if interval <= 0 || err != nil {
-		_, _ = B.Reply(m, "请输入正确的抓取频率")
+		_, _ = B.Reply(m, i18n.T(lang, "interval.invalid"))
+		return
+	}
+	if limit := roleLimit(senderRole(m.Sender)); interval < limit.MinInterval {
+		_, _ = B.Reply(m, i18n.T(lang, "limit.interval", limit.MinInterval))
 		return
 	}
 
@@ -816,12 +1123,7 @@ func setIntervalCmdCtr(m *tb.Message) {
 			wrong++
 			continue
 		}
-		sub, err := model.GetSubscribeByID(subID)
-		if err != nil || sub == nil {
-			wrong++
-			continue
-		}
-		_, err = getMentionedUser(m, strconv.FormatInt(sub.UserID, 10), nil)
+		_, sub, err := getOwnedSubscribe(lang, m, subID)
 		if err != nil {
 			wrong++
 			continue
@@ -833,30 +1135,109 @@ func setIntervalCmdCtr(m *tb.Message) {
 			success++
 		}
 	}
-	_, _ = B.Reply(m, fmt.Sprintf("抓取频率设置成功%d个，失败%d个，错误%d个！", success, failed, wrong))
+	_, _ = B.Reply(m, i18n.T(lang, "interval.result", success, failed, wrong))
 }
 
-func addKeywordCmdCtr(m *tb.Message) {
-	mention, args, _ := GetArgumentsFromMessage(m)
-	user, err := getMentionedUser(m, mention, nil)
+// parseTemplateCmd 解析模版命令，首行为参数，其余行为模版内容
+func parseTemplateCmd(m *tb.Message) (mention string, subID int, mode string, tplText string) {
+	lines := strings.SplitN(m.Text, "\n", 2)
+	if len(lines) == 2 {
+		tplText = strings.TrimSpace(lines[1])
+	}
+	fields := strings.Fields(lines[0])
+	for _, field := range fields[1:] {
+		switch {
+		case strings.HasPrefix(field, "@"):
+			mention = field
+		case isMessageModeName(field):
+			mode = field
+		default:
+			if id, err := strconv.Atoi(field); err == nil {
+				subID = id
+			}
+		}
+	}
+	return
+}
+
+// getTemplateTarget 获取模版命令的目标会话与订阅，subID 为 0 时仅返回会话
+func getTemplateTarget(lang string, m *tb.Message, mention string, subID int) (*tb.Chat, *model.Subscribe, error) {
+	if subID == 0 {
+		user, err := getMentionedUser(m, mention, nil)
+		return user, nil, err
+	}
+	return getOwnedSubscribe(lang, m, subID)
+}
+
+// getOwnedSubscribe 获取当前会话有权管理的订阅，订阅不属于该会话时按不存在处理
+func getOwnedSubscribe(lang string, m *tb.Message, subID int) (*tb.Chat, *model.Subscribe, error) {
+	sub, err := model.GetSubscribeByID(subID)
+	if err != nil || sub == nil {
+		return nil, nil, errors.New(i18n.T(lang, "err.invalid_sub_id"))
+	}
+	user, err := getMentionedUser(m, strconv.FormatInt(sub.UserID, 10), nil)
 	if err != nil {
-		_, _ = B.Reply(m, err.Error())
+		return nil, nil, err
+	}
+	if user.ID != sub.UserID {
+		return nil, nil, errors.New(i18n.T(lang, "err.invalid_sub_id"))
+	}
+	return user, sub, nil
+}
+
+func setTemplateCmdCtr(m *tb.Message) {
+	lang := messageLanguage(m)
+	mention, subID, modeName, tplText := parseTemplateCmd(m)
+	if tplText == "" {
+		_, _ = B.Reply(m, i18n.T(lang, "tpl.usage"))
 		return
-	} else if len(args) == 0 {
-		_, _ = B.Reply(m, "关键词不能为空")
+	}
+
+	user, sub, err := getTemplateTarget(lang, m, mention, subID)
+	if err != nil {
+		_, _ = B.Reply(m, errorText(lang, err))
 		return
 	}
 
-	parts := strings.Split(m.Payload, " ")
-	parts = findAndDelete(parts, mention)
-	keyword := strings.Join(parts, " ")
+	mode := config.MessageMode
+	if modeName != "" {
+		mode = config.ParseMessageMode(modeName)
+	}
+	tpl, err := config.ParseMessageTpl(tplText)
+	if err != nil {
+		_, _ = B.Reply(m, i18n.T(lang, "tpl.parse_failed", err.Error()))
+		return
+	}
+	samples, err := config.ValidateTemplate(tpl, mode)
+	if err != nil {
+		_, _ = B.Reply(m, i18n.T(lang, "tpl.render_failed", err.Error()))
+		return
+	}
+	for _, sample := range samples {
+		_, err := B.Reply(m, sample, &tb.SendOptions{
+			DisableWebPagePreview: true,
+			ParseMode:             mode,
+		})
+		if err != nil {
+			_, _ = B.Reply(m, i18n.T(lang, "tpl.preview_failed", err.Error()))
+			return
+		}
+	}
 
-	text := getUserHtml(user, m.Chat, "")
-	err = model.SaveKeyword(user.ID, keyword)
-	if err == nil {
-		text += fmt.Sprintf("添加关键词 <code>%s</code> 成功", keyword)
+	if sub != nil {
+		err = sub.SetMessageTpl(tplText, mode)
+	} else {
+		err = model.SaveMessageTplByUserId(user.ID, tplText, mode)
+	}
+	if err != nil {
+		_, _ = B.Reply(m, i18n.T(lang, "tpl.save_failed"))
+		return
+	}
+	text := getUserHtml(lang, user, m.Chat, "")
+	if sub != nil {
+		text += i18n.T(lang, "tpl.sub_success", sub.ID)
 	} else {
-		text += fmt.Sprintf("添加关键词 <code>%s</code> 失败", keyword)
+		text += i18n.T(lang, "tpl.success")
 	}
 	_, _ = B.Reply(m, text, &tb.SendOptions{
 		DisableWebPagePreview: true,
@@ -864,41 +1245,652 @@ func addKeywordCmdCtr(m *tb.Message) {
 	})
 }
 
-func removeKeywordCmdCtr(m *tb.Message) {
-	mention, _, _ := GetArgumentsFromMessage(m)
-	user, err := getMentionedUser(m, mention, nil)
+func resetTemplateCmdCtr(m *tb.Message) {
+	lang := messageLanguage(m)
+	mention, subID, _, _ := parseTemplateCmd(m)
+	user, sub, err := getTemplateTarget(lang, m, mention, subID)
 	if err != nil {
-		_, _ = B.Reply(m, err.Error())
+		_, _ = B.Reply(m, errorText(lang, err))
 		return
 	}
 
-	msg, _ := B.Reply(m, "处理中...")
-	removeKeywordsCurrentPage(msg, user, 1)
+	if sub != nil {
+		err = sub.SetMessageTpl("", "")
+	} else {
+		err = model.SaveMessageTplByUserId(user.ID, "", "")
+	}
+	if err != nil {
+		_, _ = B.Reply(m, i18n.T(lang, "tpl.reset_failed"))
+		return
+	}
+	_, _ = B.Reply(m, i18n.T(lang, "tpl.reset_success", getUserHtml(lang, user, m.Chat, "")), &tb.SendOptions{
+		DisableWebPagePreview: true,
+		ParseMode:             tb.ModeHTML,
+	})
 }
 
-func removeKeywordPageCtr(c *tb.Callback) {
-	data := strings.Split(c.Data, ":")
-	if len(data) != 2 {
-		_, _ = B.Edit(c.Message, "内部错误：回调数据不正确")
-		return
+func setTimezoneCmdCtr(m *tb.Message) {
+	lang := messageLanguage(m)
+	mention, args, _ := GetArgumentsFromMessage(m)
+	var tz string
+	if len(args) > 0 {
+		tz = args[0]
 	}
 
-	user, err := getMentionedUser(c.Message, data[0], c.Sender)
+	user, err := getMentionedUser(m, mention, nil)
 	if err != nil {
-		_ = B.Respond(c, &tb.CallbackResponse{
-			Text: err.Error(),
-		})
+		_, _ = B.Reply(m, errorText(lang, err))
 		return
 	}
 
-	page, _ := strconv.Atoi(data[1])
-	removeKeywordsCurrentPage(c.Message, user, page)
-}
-
-func removeKeywordsCurrentPage(m *tb.Message, user *tb.Chat, page int) {
-	keywords, hasPrev, hasNext, _ := model.GetUserKeywordsByPage(user.ID, page, limitPerPage)
+	if tz == "" {
+		current := i18n.T(lang, "tz.default")
+		if c, err := model.GetChatByID(user.ID); err == nil && c.Timezone != "" {
+			current = c.Timezone
+		}
+		_, _ = B.Reply(m, i18n.T(lang, "tz.usage", current))
+		return
+	}
 
-	var inlineKeyboard [][]tb.InlineButton
+	if strings.ToLower(tz) == "default" {
+		tz = ""
+	} else if _, err := loadLocation(tz); err != nil {
+		_, _ = B.Reply(m, i18n.T(lang, "tz.invalid"))
+		return
+	}
+	if err := model.SaveTimezoneByUserId(user.ID, tz); err != nil {
+		_, _ = B.Reply(m, i18n.T(lang, "tz.failed"))
+		return
+	}
+	_, _ = B.Reply(m, i18n.T(lang, "tz.success", getUserHtml(lang, user, m.Chat, "")), &tb.SendOptions{
+		DisableWebPagePreview: true,
+		ParseMode:             tb.ModeHTML,
+	})
+}
+
+// setTelegraphCmdCtr 为会话创建 telegraph 账号，该会话订阅的内容以会话署名发布
+func setTelegraphCmdCtr(m *tb.Message) {
+	lang := messageLanguage(m)
+	if !tgraph.Enabled() {
+		_, _ = B.Reply(m, i18n.T(lang, "tg.disabled"))
+		return
+	}
+	if !roleLimit(senderRole(m.Sender)).Telegraph {
+		_, _ = B.Reply(m, i18n.T(lang, "limit.telegraph"))
+		return
+	}
+	mention, args, urls := GetArgumentsFromMessage(m)
+	user, err := getMentionedUser(m, mention, nil)
+	if err != nil {
+		_, _ = B.Reply(m, errorText(lang, err))
+		return
+	}
+
+	account, _ := model.GetTelegraphAccountByUserID(user.ID)
+	authorName := strings.Join(args, " ")
+	var authorURL string
+	if len(urls) > 0 {
+		authorURL = urls[0]
+	}
+	if authorName == "" && authorURL == "" {
+		text := i18n.T(lang, "tg.global")
+		if account != nil {
+			text = i18n.T(lang, "tg.current", account.AuthorName, account.AuthorURL)
+		}
+		_, _ = B.Reply(m, text+i18n.T(lang, "tg.usage"), &tb.SendOptions{DisableWebPagePreview: true})
+		return
+	}
+	if authorName == "" {
+		authorName = user.Title
+	}
+
+	if account == nil {
+		token, err := tgraph.CreateAccount(user.Title, authorName, authorURL)
+		if err != nil {
+			zap.S().Warnw("create telegraph account failed", "user id", user.ID, "error", err)
+			_, _ = B.Reply(m, i18n.T(lang, "tg.create_failed", err))
+			return
+		}
+		account = &model.TelegraphAccount{UserID: user.ID, AccessToken: token}
+	} else if err := tgraph.EditAccount(account.AccessToken, user.Title, authorName, authorURL); err != nil {
+		zap.S().Warnw("edit telegraph account failed", "user id", user.ID, "error", err)
+		_, _ = B.Reply(m, i18n.T(lang, "tg.edit_failed", err))
+		return
+	}
+	account.AuthorName = authorName
+	account.AuthorURL = authorURL
+	if err := account.Save(); err != nil {
+		_, _ = B.Reply(m, i18n.T(lang, "tg.save_failed"))
+		return
+	}
+	_, _ = B.Reply(m, i18n.T(lang, "tg.success", getUserHtml(lang, user, m.Chat, ""), html.EscapeString(authorName)), &tb.SendOptions{
+		DisableWebPagePreview: true,
+		ParseMode:             tb.ModeHTML,
+	})
+}
+
+// resetTelegraphCmdCtr 删除会话的 telegraph 账号，恢复使用全局账号
+func resetTelegraphCmdCtr(m *tb.Message) {
+	lang := messageLanguage(m)
+	mention, _, _ := GetArgumentsFromMessage(m)
+	user, err := getMentionedUser(m, mention, nil)
+	if err != nil {
+		_, _ = B.Reply(m, errorText(lang, err))
+		return
+	}
+	if err := model.DeleteTelegraphAccountByUserID(user.ID); err != nil {
+		_, _ = B.Reply(m, i18n.T(lang, "tg.delete_failed"))
+		return
+	}
+	_, _ = B.Reply(m, i18n.T(lang, "tg.reset_success", getUserHtml(lang, user, m.Chat, "")), &tb.SendOptions{
+		DisableWebPagePreview: true,
+		ParseMode:             tb.ModeHTML,
+	})
+}
+
+// languageCmdCtr 设置会话使用的语言，设置为 default 时跟随 telegram 客户端的语言
+func languageCmdCtr(m *tb.Message) {
+	lang := messageLanguage(m)
+	mention, args, _ := GetArgumentsFromMessage(m)
+	user, err := getMentionedUser(m, mention, nil)
+	if err != nil {
+		_, _ = B.Reply(m, errorText(lang, err))
+		return
+	}
+
+	if len(args) == 0 {
+		current := i18n.T(lang, "language.auto")
+		if setting := i18n.Parse(model.GetLanguageByUserId(user.ID)); setting != "" {
+			current = i18n.T(setting, "language.name")
+		}
+		var row []tb.InlineButton
+		for _, l := range i18n.Languages() {
+			row = append(row, tb.InlineButton{
+				Unique: "set_language_btn",
+				Text:   i18n.T(l, "language.name"),
+				Data:   fmt.Sprintf("%d:%s", user.ID, l),
+			})
+		}
+		row = append(row, tb.InlineButton{
+			Unique: "set_language_btn",
+			Text:   i18n.T(lang, "language.auto"),
+			Data:   fmt.Sprintf("%d:default", user.ID),
+		})
+		_, _ = B.Reply(m, i18n.T(lang, "language.current", getUserHtml(lang, user, m.Chat, ""), current), &tb.SendOptions{
+			DisableWebPagePreview: true,
+			ParseMode:             tb.ModeHTML,
+		}, &tb.ReplyMarkup{
+			InlineKeyboard: signKeyboard(m.Chat.ID, [][]tb.InlineButton{row}),
+		})
+		return
+	}
+
+	setting, ok := parseLanguageArg(args[0])
+	if !ok {
+		_, _ = B.Reply(m, i18n.T(lang, "language.invalid", strings.Join(i18n.Languages(), ", ")))
+		return
+	}
+	if err := model.SaveLanguageByUserId(user.ID, setting); err != nil {
+		_, _ = B.Reply(m, i18n.T(lang, "language.failed"))
+		return
+	}
+	_, _ = B.Reply(m, languageSetText(messageLanguage(m), user, m.Chat, setting), &tb.SendOptions{
+		DisableWebPagePreview: true,
+		ParseMode:             tb.ModeHTML,
+	})
+}
+
+func setLanguageBtnCtr(c *tb.Callback) {
+	lang := callbackLanguage(c)
+	data := strings.Split(c.Data, ":")
+	if len(data) != 2 {
+		_, _ = B.Edit(c.Message, i18n.T(lang, "err.callback_data"))
+		return
+	}
+	user, err := getMentionedUser(c.Message, data[0], c.Sender)
+	if err != nil {
+		_ = B.Respond(c, &tb.CallbackResponse{
+			Text: errorText(lang, err),
+		})
+		return
+	}
+	setting, ok := parseLanguageArg(data[1])
+	if !ok {
+		_ = B.Respond(c, &tb.CallbackResponse{Text: i18n.T(lang, "err.callback_data")})
+		return
+	}
+	if err := model.SaveLanguageByUserId(user.ID, setting); err != nil {
+		_ = B.Respond(c, &tb.CallbackResponse{Text: i18n.T(lang, "language.failed")})
+		return
+	}
+	_ = B.Respond(c)
+	_, _ = B.Edit(c.Message, languageSetText(callbackLanguage(c), user, c.Message.Chat, setting), &tb.SendOptions{
+		DisableWebPagePreview: true,
+		ParseMode:             tb.ModeHTML,
+	})
+}
+
+// parseLanguageArg 解析语言参数，default 返回空字符串
+func parseLanguageArg(arg string) (string, bool) {
+	if strings.ToLower(arg) == "default" {
+		return "", true
+	}
+	setting := i18n.Parse(arg)
+	return setting, setting != ""
+}
+
+// languageSetText 语言设置成功的提示，lang 为设置后当前会话使用的语言
+func languageSetText(lang string, user, chat *tb.Chat, setting string) string {
+	if setting == "" {
+		return i18n.T(lang, "language.reset", getUserHtml(lang, user, chat, ""))
+	}
+	return i18n.T(lang, "language.success", getUserHtml(lang, user, chat, ""), i18n.T(setting, "language.name"))
+}
+
+// grantCmdCtr 为用户授予角色，owner 可以授予所有角色，admin 只能授予 user 与 banned
+func grantCmdCtr(m *tb.Message) {
+	lang := messageLanguage(m)
+	actor := senderRole(m.Sender)
+	if actor != model.RoleOwner && actor != model.RoleAdmin {
+		_, _ = B.Reply(m, i18n.T(lang, "err.no_permission"))
+		return
+	}
+	target, args, ok := parseRoleTarget(m)
+	if !ok {
+		_, _ = B.Reply(m, i18n.T(lang, "role.grant_usage"))
+		return
+	}
+	role := model.RoleUser
+	if len(args) > 0 {
+		role = model.Role(strings.ToLower(args[0]))
+	}
+	if role != model.RoleAdmin && role != model.RoleUser && role != model.RoleBanned {
+		_, _ = B.Reply(m, i18n.T(lang, "role.invalid"))
+		return
+	}
+	if !canGrant(actor, userRole(target), role) {
+		_, _ = B.Reply(m, i18n.T(lang, "role.denied"))
+		return
+	}
+	if err := model.SaveRoleByUserId(target, role); err != nil {
+		zap.S().Warnw("save role failed", "user id", target, "role", role, "error", err)
+		_, _ = B.Reply(m, i18n.T(lang, "role.failed"))
+		return
+	}
+	zap.S().Infow("grant role", "operator", m.Sender.ID, "user id", target, "role", role)
+	_, _ = B.Reply(m, i18n.T(lang, "role.granted", target, role))
+}
+
+// revokeCmdCtr 撤销用户被授予的角色
+func revokeCmdCtr(m *tb.Message) {
+	lang := messageLanguage(m)
+	actor := senderRole(m.Sender)
+	if actor != model.RoleOwner && actor != model.RoleAdmin {
+		_, _ = B.Reply(m, i18n.T(lang, "err.no_permission"))
+		return
+	}
+	target, _, ok := parseRoleTarget(m)
+	if !ok {
+		_, _ = B.Reply(m, i18n.T(lang, "role.revoke_usage"))
+		return
+	}
+	if !canGrant(actor, userRole(target), model.RoleGuest) {
+		_, _ = B.Reply(m, i18n.T(lang, "role.denied"))
+		return
+	}
+	if err := model.SaveRoleByUserId(target, model.RoleGuest); err != nil {
+		zap.S().Warnw("revoke role failed", "user id", target, "error", err)
+		_, _ = B.Reply(m, i18n.T(lang, "role.failed"))
+		return
+	}
+	zap.S().Infow("revoke role", "operator", m.Sender.ID, "user id", target)
+	_, _ = B.Reply(m, i18n.T(lang, "role.revoked", target))
+}
+
+// parseRoleTarget 解析角色命令的目标用户，回复消息时为被回复的用户，否则为第一个参数
+func parseRoleTarget(m *tb.Message) (int64, []string, bool) {
+	args := strings.Fields(m.Payload)
+	if m.IsReply() && m.ReplyTo.Sender != nil {
+		return int64(m.ReplyTo.Sender.ID), args, true
+	}
+	if len(args) == 0 {
+		return 0, nil, false
+	}
+	userID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return 0, nil, false
+	}
+	return userID, args[1:], true
+}
+
+// statsCmdCtr 实例统计，仅 owner 可用
+func statsCmdCtr(m *tb.Message) {
+	lang := messageLanguage(m)
+	if !isOwner(m.Sender) {
+		_, _ = B.Reply(m, i18n.T(lang, "err.no_permission"))
+		return
+	}
+	stats, err := model.GetStats(time.Now().Add(-24 * time.Hour))
+	if err != nil {
+		zap.S().Warnw("get stats failed", "error", err)
+		_, _ = B.Reply(m, i18n.T(lang, "admin.query_failed", err))
+		return
+	}
+	sent := sender.Stats()
+	_, _ = B.Reply(m, i18n.T(lang, "admin.stats",
+		stats.Users, stats.Chats,
+		stats.Sources, stats.ErrorSources, stats.PausedSources,
+		stats.Subscriptions,
+		stats.Sent, stats.Dead,
+		sent.Sent, sent.Retried, sent.Dropped,
+	), &tb.SendOptions{ParseMode: tb.ModeHTML})
+}
+
+// sourcesCmdCtr 按订阅数列出所有源，仅 owner 可用
+func sourcesCmdCtr(m *tb.Message) {
+	lang := messageLanguage(m)
+	if !isOwner(m.Sender) {
+		_, _ = B.Reply(m, i18n.T(lang, "err.no_permission"))
+		return
+	}
+	msg, _ := B.Reply(m, i18n.T(lang, "processing"))
+	adminSourcesCurrentPage(lang, msg, 1)
+}
+
+func adminSourcesPageCtr(c *tb.Callback) {
+	lang := callbackLanguage(c)
+	if !isOwner(c.Sender) {
+		_ = B.Respond(c, &tb.CallbackResponse{Text: i18n.T(lang, "err.no_permission")})
+		return
+	}
+	page, err := strconv.Atoi(c.Data)
+	if err != nil {
+		_, _ = B.Edit(c.Message, i18n.T(lang, "err.callback_data"))
+		return
+	}
+	adminSourcesCurrentPage(lang, c.Message, page)
+}
+
+func adminSourcesCurrentPage(lang string, m *tb.Message, page int) {
+	sources, hasPrev, hasNext, err := model.GetSourceStatsByPage(page, limitPerPage)
+	if err != nil {
+		_, _ = B.Edit(m, i18n.T(lang, "admin.query_failed", err))
+		return
+	}
+	text := i18n.T(lang, "admin.sources_title", page)
+	if len(sources) == 0 {
+		text += i18n.T(lang, "admin.empty")
+	}
+	for _, source := range sources {
+		text += i18n.T(lang, "admin.source_line", source.ID, source.Link, html.EscapeString(source.Title), source.Subscribers)
+		if source.ErrorCount > 0 {
+			text += i18n.T(lang, "admin.source_error", source.ErrorCount)
+		}
+	}
+	_, _ = B.Edit(m, text, &tb.SendOptions{
+		DisableWebPagePreview: true,
+		ParseMode:             tb.ModeHTML,
+	}, &tb.ReplyMarkup{
+		InlineKeyboard: signKeyboard(m.Chat.ID, adminPageButtons(lang, "admin_sources_page", page, hasPrev, hasNext)),
+	})
+}
+
+// usersCmdCtr 按订阅数列出所有用户，仅 owner 可用
+func usersCmdCtr(m *tb.Message) {
+	lang := messageLanguage(m)
+	if !isOwner(m.Sender) {
+		_, _ = B.Reply(m, i18n.T(lang, "err.no_permission"))
+		return
+	}
+	msg, _ := B.Reply(m, i18n.T(lang, "processing"))
+	adminUsersCurrentPage(lang, msg, 1)
+}
+
+func adminUsersPageCtr(c *tb.Callback) {
+	lang := callbackLanguage(c)
+	if !isOwner(c.Sender) {
+		_ = B.Respond(c, &tb.CallbackResponse{Text: i18n.T(lang, "err.no_permission")})
+		return
+	}
+	page, err := strconv.Atoi(c.Data)
+	if err != nil {
+		_, _ = B.Edit(c.Message, i18n.T(lang, "err.callback_data"))
+		return
+	}
+	adminUsersCurrentPage(lang, c.Message, page)
+}
+
+func adminUsersCurrentPage(lang string, m *tb.Message, page int) {
+	users, hasPrev, hasNext, err := model.GetUserStatsByPage(page, limitPerPage)
+	if err != nil {
+		_, _ = B.Edit(m, i18n.T(lang, "admin.query_failed", err))
+		return
+	}
+	text := i18n.T(lang, "admin.users_title", page)
+	if len(users) == 0 {
+		text += i18n.T(lang, "admin.empty")
+	}
+	for _, user := range users {
+		role := user.Role
+		if role == model.RoleGuest {
+			role = "-"
+		}
+		text += i18n.T(lang, "admin.user_line", user.TelegramID, role, user.Subscriptions)
+	}
+	_, _ = B.Edit(m, text, &tb.SendOptions{
+		ParseMode: tb.ModeHTML,
+	}, &tb.ReplyMarkup{
+		InlineKeyboard: signKeyboard(m.Chat.ID, adminPageButtons(lang, "admin_users_page", page, hasPrev, hasNext)),
+	})
+}
+
+// adminPageButtons 管理列表的翻页按钮
+func adminPageButtons(lang string, unique string, page int, hasPrev, hasNext bool) [][]tb.InlineButton {
+	var row []tb.InlineButton
+	if hasPrev {
+		row = append(row, tb.InlineButton{
+			Unique: unique,
+			Text:   i18n.T(lang, "btn.prev"),
+			Data:   strconv.Itoa(page - 1),
+		})
+	}
+	if hasNext {
+		row = append(row, tb.InlineButton{
+			Unique: unique,
+			Text:   i18n.T(lang, "btn.next"),
+			Data:   strconv.Itoa(page + 1),
+		})
+	}
+	if len(row) == 0 {
+		return nil
+	}
+	return [][]tb.InlineButton{row}
+}
+
+// announceCmdCtr 向所有会话发送公告，仅 owner 可用
+//
+// 公告为命令后的文本（可换行），或回复一条消息发送 /announce 公告该消息的文本
+func announceCmdCtr(m *tb.Message) {
+	lang := messageLanguage(m)
+	if !isOwner(m.Sender) {
+		_, _ = B.Reply(m, i18n.T(lang, "err.no_permission"))
+		return
+	}
+	text := announceText(m)
+	if text == "" {
+		_, _ = B.Reply(m, i18n.T(lang, "admin.announce_usage"))
+		return
+	}
+	chatIDs, err := model.GetAllChatIDs()
+	if err != nil {
+		_, _ = B.Reply(m, i18n.T(lang, "admin.query_failed", err))
+		return
+	}
+	zap.S().Infow("announce", "operator", m.Sender.ID, "chats", len(chatIDs))
+	report, _ := B.Reply(m, i18n.T(lang, "admin.announce_started", len(chatIDs)))
+
+	go func() {
+		var failed []string
+		for _, chatID := range chatIDs {
+			// 经由限流发送器发送，避免触发 telegram 的频率限制
+			_, err := sender.Send(chatID, text, &tb.SendOptions{DisableWebPagePreview: true})
+			if err != nil {
+				zap.S().Warnw("send announcement failed", "chat id", chatID, "error", err)
+				failed = append(failed, fmt.Sprintf("%d: %s", chatID, err))
+			}
+		}
+
+		result := i18n.T(lang, "admin.announce_result", len(chatIDs)-len(failed), len(failed))
+		if len(failed) > maxAnnounceFailures {
+			result += "\n" + strings.Join(failed[:maxAnnounceFailures], "\n")
+			result += i18n.T(lang, "admin.announce_more", len(failed)-maxAnnounceFailures)
+		} else if len(failed) > 0 {
+			result += "\n" + strings.Join(failed, "\n")
+		}
+		if report != nil {
+			_, _ = B.Edit(report, result)
+		} else {
+			_, _ = B.Send(m.Chat, result)
+		}
+	}()
+}
+
+// announceText 公告内容，命令后的文本优先于被回复消息的文本
+func announceText(m *tb.Message) string {
+	if idx := strings.IndexAny(m.Text, " \n"); idx >= 0 {
+		if text := strings.TrimSpace(m.Text[idx:]); text != "" {
+			return text
+		}
+	}
+	if m.IsReply() {
+		return strings.TrimSpace(m.ReplyTo.Text)
+	}
+	return ""
+}
+
+// redeemInvite 使用邀请码，已被授予角色的用户无需使用
+func redeemInvite(m *tb.Message, code string) error {
+	if senderRole(m.Sender) != model.RoleGuest {
+		return nil
+	}
+	if err := model.RedeemInviteCode(code, int64(m.Sender.ID)); err != nil {
+		zap.S().Infow("redeem invite code failed", "user id", m.Sender.ID, "code", code, "error", err)
+		return err
+	}
+	zap.S().Infow("redeem invite code", "user id", m.Sender.ID, "code", code)
+	_, _ = B.Reply(m, i18n.T(messageLanguage(m), "invite.success"))
+	return nil
+}
+
+// inviteCmdCtr 创建邀请码，仅 owner 可用
+func inviteCmdCtr(m *tb.Message) {
+	lang := messageLanguage(m)
+	if !isOwner(m.Sender) {
+		_, _ = B.Reply(m, i18n.T(lang, "err.no_permission"))
+		return
+	}
+	maxUses, days := 1, 7
+	args := strings.Fields(m.Payload)
+	var err error
+	if len(args) > 0 {
+		maxUses, err = strconv.Atoi(args[0])
+	}
+	if err == nil && len(args) > 1 {
+		days, err = strconv.Atoi(args[1])
+	}
+	if err != nil || maxUses < 0 || days < 0 {
+		_, _ = B.Reply(m, i18n.T(lang, "invite.usage"))
+		return
+	}
+
+	invite, err := model.CreateInviteCode(int64(m.Sender.ID), maxUses, time.Duration(days)*24*time.Hour)
+	if err != nil {
+		zap.S().Warnw("create invite code failed", "error", err)
+		_, _ = B.Reply(m, i18n.T(lang, "invite.failed", err))
+		return
+	}
+
+	uses := i18n.T(lang, "invite.unlimited")
+	if invite.MaxUses > 0 {
+		uses = strconv.Itoa(invite.MaxUses)
+	}
+	expire := i18n.T(lang, "invite.never")
+	if invite.ExpireAt != nil {
+		expire = invite.ExpireAt.Format("2006-01-02 15:04")
+	}
+	link := fmt.Sprintf("https://t.me/%s?start=%s", B.Me.Username, invite.Code)
+	_, _ = B.Reply(m, i18n.T(lang, "invite.created", invite.Code, uses, expire, link), &tb.SendOptions{
+		DisableWebPagePreview: true,
+		ParseMode:             tb.ModeHTML,
+	})
+}
+
+func addKeywordCmdCtr(m *tb.Message) {
+	lang := messageLanguage(m)
+	mention, args, _ := GetArgumentsFromMessage(m)
+	user, err := getMentionedUser(m, mention, nil)
+	if err != nil {
+		_, _ = B.Reply(m, errorText(lang, err))
+		return
+	} else if len(args) == 0 {
+		_, _ = B.Reply(m, i18n.T(lang, "kw.empty"))
+		return
+	}
+
+	parts := strings.Split(m.Payload, " ")
+	parts = findAndDelete(parts, mention)
+	keyword := strings.Join(parts, " ")
+
+	text := getUserHtml(lang, user, m.Chat, "")
+	err = model.SaveKeyword(user.ID, keyword)
+	if err == nil {
+		text += i18n.T(lang, "kw.add_success", keyword)
+	} else {
+		text += i18n.T(lang, "kw.add_failed", keyword)
+	}
+	_, _ = B.Reply(m, text, &tb.SendOptions{
+		DisableWebPagePreview: true,
+		ParseMode:             tb.ModeHTML,
+	})
+}
+
+func removeKeywordCmdCtr(m *tb.Message) {
+	lang := messageLanguage(m)
+	mention, _, _ := GetArgumentsFromMessage(m)
+	user, err := getMentionedUser(m, mention, nil)
+	if err != nil {
+		_, _ = B.Reply(m, errorText(lang, err))
+		return
+	}
+
+	msg, _ := B.Reply(m, i18n.T(lang, "processing"))
+	removeKeywordsCurrentPage(lang, msg, user, 1)
+}
+
+func removeKeywordPageCtr(c *tb.Callback) {
+	lang := callbackLanguage(c)
+	data := strings.Split(c.Data, ":")
+	if len(data) != 2 {
+		_, _ = B.Edit(c.Message, i18n.T(lang, "err.callback_data"))
+		return
+	}
+
+	user, err := getMentionedUser(c.Message, data[0], c.Sender)
+	if err != nil {
+		_ = B.Respond(c, &tb.CallbackResponse{
+			Text: errorText(lang, err),
+		})
+		return
+	}
+
+	page, _ := strconv.Atoi(data[1])
+	removeKeywordsCurrentPage(lang, c.Message, user, page)
+}
+
+func removeKeywordsCurrentPage(lang string, m *tb.Message, user *tb.Chat, page int) {
+	keywords, hasPrev, hasNext, _ := model.GetUserKeywordsByPage(user.ID, page, limitPerPage)
+
+	var inlineKeyboard [][]tb.InlineButton
 	for _, k := range keywords {
 		inlineKeyboard = append(inlineKeyboard, []tb.InlineButton{{
 			Unique: "remove_keyword_btn",
@@ -911,39 +1903,40 @@ func removeKeywordsCurrentPage(m *tb.Message, user *tb.Chat, page int) {
 	if hasPrev {
 		lastRow = append(lastRow, tb.InlineButton{
 			Unique: "remove_keyword_page",
-			Text:   "上一页",
+			Text:   i18n.T(lang, "btn.prev"),
 			Data:   fmt.Sprintf("%d:%d", user.ID, page-1),
 		})
 	}
 	lastRow = append(lastRow, tb.InlineButton{
 		Unique: "cancel_btn",
-		Text:   "取消",
+		Text:   i18n.T(lang, "btn.cancel"),
 	})
 	if hasNext {
 		lastRow = append(lastRow, tb.InlineButton{
 			Unique: "remove_keyword_page",
-			Text:   "下一页",
+			Text:   i18n.T(lang, "btn.next"),
 			Data:   fmt.Sprintf("%d:%d", user.ID, page+1),
 		})
 	}
 	inlineKeyboard = append(inlineKeyboard, lastRow)
 
-	_, _ = B.Edit(m, "请选择你要删除的关键词", &tb.ReplyMarkup{
-		InlineKeyboard: inlineKeyboard,
+	_, _ = B.Edit(m, i18n.T(lang, "kw.choose"), &tb.ReplyMarkup{
+		InlineKeyboard: signKeyboard(m.Chat.ID, inlineKeyboard),
 	})
 }
 
 func removeKeywordBtnCtr(c *tb.Callback) {
+	lang := callbackLanguage(c)
 	data := strings.Split(c.Data, ":")
 	if len(data) != 3 {
-		_, _ = B.Edit(c.Message, "内部错误：回调数据不正确")
+		_, _ = B.Edit(c.Message, i18n.T(lang, "err.callback_data"))
 		return
 	}
 
 	user, err := getMentionedUser(c.Message, data[0], c.Sender)
 	if err != nil {
 		_ = B.Respond(c, &tb.CallbackResponse{
-			Text: err.Error(),
+			Text: errorText(lang, err),
 		})
 		return
 	}
@@ -952,61 +1945,236 @@ func removeKeywordBtnCtr(c *tb.Callback) {
 	_ = model.RemoveKeyword(uint(keywordId), user.ID)
 
 	page, _ := strconv.Atoi(data[2])
-	removeKeywordsCurrentPage(c.Message, user, page)
+	removeKeywordsCurrentPage(lang, c.Message, user, page)
+}
+
+// genNewsBtns 生成推送消息下方的操作按钮
+func genNewsBtns(lang string, sub *model.Subscribe, content *model.Content, telegraphURL string) *tb.ReplyMarkup {
+	data := fmt.Sprintf("%d:%s", sub.ID, content.HashID)
+	var row []tb.InlineButton
+	for _, name := range config.MessageButtons {
+		switch name {
+		case newsBtnDownload:
+			if content.TorrentUrl != "" {
+				row = append(row, tb.InlineButton{Unique: "news_download_btn", Text: i18n.T(lang, "news.download"), Data: data})
+			}
+		case newsBtnMute:
+			row = append(row, tb.InlineButton{Unique: "news_mute_btn", Text: i18n.T(lang, "news.mute"), Data: data})
+		case newsBtnUnsubscribe:
+			row = append(row, tb.InlineButton{Unique: "news_unsub_btn", Text: i18n.T(lang, "news.unsub"), Data: data})
+		case newsBtnTelegraph:
+			if telegraphURL != "" {
+				row = append(row, tb.InlineButton{Text: "Telegraph", URL: telegraphURL})
+			}
+		case newsBtnSave:
+			row = append(row, tb.InlineButton{Unique: "news_save_btn", Text: i18n.T(lang, "news.save"), Data: data})
+		}
+	}
+	if len(row) == 0 {
+		return nil
+	}
+	return &tb.ReplyMarkup{InlineKeyboard: signKeyboard(sub.UserID, [][]tb.InlineButton{row})}
+}
+
+// getNewsBtnTarget 解析推送消息按钮的回调数据，并确认订阅属于当前会话
+func getNewsBtnTarget(lang string, c *tb.Callback) (*model.Subscribe, *model.Content, error) {
+	data := strings.Split(c.Data, ":")
+	if len(data) != 2 {
+		return nil, nil, errors.New(i18n.T(lang, "err.callback_data"))
+	}
+	subID, err := strconv.Atoi(data[0])
+	if err != nil {
+		return nil, nil, errors.New(i18n.T(lang, "err.callback_data"))
+	}
+	sub, err := model.GetSubscribeByID(subID)
+	if err != nil || sub.UserID != c.Message.Chat.ID {
+		return nil, nil, errors.New(i18n.T(lang, "err.subscribe_not_found"))
+	}
+	content, err := model.GetContentByHashID(data[1])
+	if err != nil {
+		return nil, nil, errors.New(i18n.T(lang, "err.content_not_found"))
+	}
+	return sub, content, nil
+}
+
+// confirmNewsBtn 将被点击的按钮替换为操作结果
+func confirmNewsBtn(c *tb.Callback, unique string, text string) {
+	_ = B.Respond(c, &tb.CallbackResponse{Text: text})
+
+	chatID := c.Message.Chat.ID
+	var keyboard [][]tb.InlineButton
+	for _, row := range c.Message.ReplyMarkup.InlineKeyboard {
+		var newRow []tb.InlineButton
+		for _, btn := range row {
+			if u, data, ok := parseButtonData(chatID, btn); ok && u == unique && data == c.Data {
+				btn = tb.InlineButton{
+					Unique: "news_done_btn",
+					Text:   "✅ " + text,
+					Data:   signCallbackData(chatID, "news_done_btn", ""),
+				}
+			}
+			newRow = append(newRow, btn)
+		}
+		keyboard = append(keyboard, newRow)
+	}
+	_, _ = B.EditReplyMarkup(c.Message, &tb.ReplyMarkup{InlineKeyboard: keyboard})
+}
+
+func newsDownloadBtnCtr(c *tb.Callback) {
+	lang := callbackLanguage(c)
+	sub, content, err := getNewsBtnTarget(lang, c)
+	if err != nil {
+		_ = B.Respond(c, &tb.CallbackResponse{Text: errorText(lang, err)})
+		return
+	}
+	if content.TorrentUrl == "" {
+		_ = B.Respond(c, &tb.CallbackResponse{Text: i18n.T(lang, "news.no_download")})
+		return
+	}
+	if !roleLimit(senderRole(c.Sender)).Download {
+		_ = B.Respond(c, &tb.CallbackResponse{Text: i18n.T(lang, "limit.download")})
+		return
+	}
+	user, _ := model.FindOrCreateUserByTelegramID(sub.UserID)
+	if user.Token == "" {
+		_ = B.Respond(c, &tb.CallbackResponse{Text: i18n.T(lang, "set.need_token")})
+		return
+	}
+	count := AddPutIoTransfers(user.Token, map[string]string{content.TorrentUrl: content.GetTriggerId()})
+	if count == 0 {
+		_ = B.Respond(c, &tb.CallbackResponse{Text: i18n.T(lang, "news.duplicate")})
+		return
+	}
+	confirmNewsBtn(c, "news_download_btn", i18n.T(lang, "news.downloaded"))
+}
+
+func newsMuteBtnCtr(c *tb.Callback) {
+	lang := callbackLanguage(c)
+	sub, _, err := getNewsBtnTarget(lang, c)
+	if err != nil {
+		_ = B.Respond(c, &tb.CallbackResponse{Text: errorText(lang, err)})
+		return
+	}
+	if err := sub.Mute(newsMuteDuration); err != nil {
+		_ = B.Respond(c, &tb.CallbackResponse{Text: i18n.T(lang, "news.mute_failed")})
+		return
+	}
+	confirmNewsBtn(c, "news_mute_btn", i18n.T(lang, "news.muted"))
+}
+
+func newsUnsubBtnCtr(c *tb.Callback) {
+	lang := callbackLanguage(c)
+	sub, _, err := getNewsBtnTarget(lang, c)
+	if err != nil {
+		_ = B.Respond(c, &tb.CallbackResponse{Text: errorText(lang, err)})
+		return
+	}
+	if err := sub.Unsub(); err != nil {
+		_ = B.Respond(c, &tb.CallbackResponse{Text: i18n.T(lang, "unsub.failed", errorText(lang, err))})
+		return
+	}
+	zap.S().Infof("%d unsubscribe [%d] by news button", sub.UserID, sub.SourceID)
+	confirmNewsBtn(c, "news_unsub_btn", i18n.T(lang, "news.unsubscribed"))
+}
+
+func newsSaveBtnCtr(c *tb.Callback) {
+	lang := callbackLanguage(c)
+	if _, _, err := getNewsBtnTarget(lang, c); err != nil {
+		_ = B.Respond(c, &tb.CallbackResponse{Text: errorText(lang, err)})
+		return
+	}
+	if _, err := B.Copy(c.Sender, c.Message); err != nil {
+		_ = B.Respond(c, &tb.CallbackResponse{Text: i18n.T(lang, "news.save_failed")})
+		return
+	}
+	_ = B.Respond(c, &tb.CallbackResponse{Text: i18n.T(lang, "news.saved")})
+}
+
+func newsDoneBtnCtr(c *tb.Callback) {
+	_ = B.Respond(c)
 }
 
 func downloadCmdCtr(m *tb.Message) {
+	lang := messageLanguage(m)
+	if !roleLimit(senderRole(m.Sender)).Download {
+		_, _ = B.Reply(m, i18n.T(lang, "limit.download"))
+		return
+	}
 	user, _ := model.FindOrCreateUserByTelegramID(m.Chat.ID)
 	if user.Token == "" {
-		_, _ = B.Reply(m, "请先通过 `/set_token` 设置Put.io的token", &tb.SendOptions{
+		_, _ = B.Reply(m, i18n.T(lang, "set.need_token_md"), &tb.SendOptions{
 			ParseMode: tb.ModeMarkdown,
 		})
 		return
 	}
 
 	if !m.IsReply() || m.ReplyTo.Sender.ID != B.Me.ID {
-		_, _ = B.Reply(m, "请回复本 bot 推送的消息")
+		_, _ = B.Reply(m, i18n.T(lang, "download.reply"))
 		return
-	} else if len(m.ReplyTo.Entities) == 0 {
-		_, _ = B.Reply(m, "该消息未包含可下载的内容")
+	} else if len(m.ReplyTo.Entities) == 0 && len(m.ReplyTo.ReplyMarkup.InlineKeyboard) == 0 {
+		_, _ = B.Reply(m, i18n.T(lang, "news.no_download"))
 		return
 	}
 
-	var rawLink string
-	for i := len(m.ReplyTo.Entities) - 1; i >= 0; i-- {
-		entity := m.ReplyTo.Entities[i]
-		if entity.Type == tb.EntityTextLink {
-			rawLink = entity.URL
-			break
+	content := getContentByNewsBtns(m.ReplyTo)
+	if content == nil {
+		var rawLink string
+		for i := len(m.ReplyTo.Entities) - 1; i >= 0; i-- {
+			entity := m.ReplyTo.Entities[i]
+			if entity.Type == tb.EntityTextLink {
+				rawLink = entity.URL
+				break
+			}
 		}
+		content = model.GetContentByRawLink(rawLink)
 	}
-	content := model.GetContentByRawLink(rawLink)
 	if content == nil || content.TorrentUrl == "" {
-		_, _ = B.Reply(m, "该消息未包含可下载的内容")
+		_, _ = B.Reply(m, i18n.T(lang, "news.no_download"))
 		return
 	}
 
 	urlMap := map[string]string{content.TorrentUrl: content.GetTriggerId()}
 	count := AddPutIoTransfers(user.Token, urlMap)
 	if count == 0 {
-		_, _ = B.Reply(m, "无法重复添加同一下载任务，请前往<a href=\"https://app.put.io/\">Put.io</a>自行新建任务", &tb.SendOptions{
+		_, _ = B.Reply(m, i18n.T(lang, "download.duplicate"), &tb.SendOptions{
 			DisableWebPagePreview: true,
 			ParseMode:             tb.ModeHTML,
 		})
 		return
 	}
-	_, _ = B.Reply(m, "成功添加下载任务")
+	_, _ = B.Reply(m, i18n.T(lang, "download.success"))
+}
+
+// getContentByNewsBtns 通过推送消息的操作按钮找到对应内容
+func getContentByNewsBtns(m *tb.Message) *model.Content {
+	for _, row := range m.ReplyMarkup.InlineKeyboard {
+		for _, btn := range row {
+			unique, btnData, ok := parseButtonData(m.Chat.ID, btn)
+			if !ok || !strings.HasPrefix(unique, "news_") {
+				continue
+			}
+			data := strings.Split(btnData, ":")
+			if len(data) != 2 {
+				continue
+			}
+			if content, err := model.GetContentByHashID(data[1]); err == nil {
+				return content
+			}
+		}
+	}
+	return nil
 }
 
 func activeAllCmdCtr(m *tb.Message) {
+	lang := messageLanguage(m)
 	mention, _, _ := GetArgumentsFromMessage(m)
 	user, err := getMentionedUser(m, mention, nil)
 	if err != nil {
-		_, _ = B.Reply(m, err.Error())
+		_, _ = B.Reply(m, errorText(lang, err))
 		return
 	}
 	_ = model.ActiveSourcesByUserID(user.ID)
-	message := fmt.Sprintf("%s订阅已全部开启", getUserHtml(user, m.Chat, ""))
+	message := i18n.T(lang, "active_all.success", getUserHtml(lang, user, m.Chat, ""))
 	_, _ = B.Reply(m, message, &tb.SendOptions{
 		DisableWebPagePreview: true,
 		ParseMode:             tb.ModeHTML,
@@ -1014,155 +2182,191 @@ func activeAllCmdCtr(m *tb.Message) {
 }
 
 func pauseAllCmdCtr(m *tb.Message) {
+	lang := messageLanguage(m)
 	mention, _, _ := GetArgumentsFromMessage(m)
 	user, err := getMentionedUser(m, mention, nil)
 	if err != nil {
-		_, _ = B.Reply(m, err.Error())
+		_, _ = B.Reply(m, errorText(lang, err))
 		return
 	}
 	_ = model.PauseSourcesByUserID(user.ID)
-	message := fmt.Sprintf("%s订阅已全部暂停", getUserHtml(user, m.Chat, ""))
+	message := i18n.T(lang, "pause_all.success", getUserHtml(lang, user, m.Chat, ""))
 	_, _ = B.Reply(m, message, &tb.SendOptions{
 		DisableWebPagePreview: true,
 		ParseMode:             tb.ModeHTML,
 	})
 }
 
-func textCtr(m *tb.Message) {
-	switch UserState[m.Chat.ID] {
-	case fsm.UnSub:
-		{
-			str := strings.Split(m.Text, " ")
+// unsubStateCtr 退订流程中回复的订阅
+func unsubStateCtr(m *tb.Message, _ *fsm.State) {
+	lang := messageLanguage(m)
+	str := strings.Split(m.Text, " ")
 
-			if len(str) < 2 && (strings.HasPrefix(str[0], "[") && strings.HasSuffix(str[0], "]")) {
-				_, _ = B.Reply(m, "请选择正确的指令！")
-			} else {
+	if len(str) < 2 && (strings.HasPrefix(str[0], "[") && strings.HasSuffix(str[0], "]")) {
+		_, _ = B.Reply(m, i18n.T(lang, "err.invalid_command"))
+		return
+	}
 
-				var sourceID uint
-				if _, err := fmt.Sscanf(str[0], "[%d]", &sourceID); err != nil {
-					_, _ = B.Reply(m, "请选择正确的指令！")
-					return
-				}
+	var sourceID uint
+	if _, err := fmt.Sscanf(str[0], "[%d]", &sourceID); err != nil {
+		_, _ = B.Reply(m, i18n.T(lang, "err.invalid_command"))
+		return
+	}
 
-				source, err := model.GetSourceById(sourceID)
+	source, err := model.GetSourceById(sourceID)
 
-				if err != nil {
-					_, _ = B.Reply(m, "请选择正确的指令！")
-					return
-				}
+	if err != nil {
+		_, _ = B.Reply(m, i18n.T(lang, "err.invalid_command"))
+		return
+	}
 
-				err = model.UnsubByUserIDAndSource(m.Chat.ID, source)
+	err = model.UnsubByUserIDAndSource(m.Chat.ID, source)
 
-				if err != nil {
-					_, _ = B.Reply(m, "请选择正确的指令！")
-					return
-				}
+	if err != nil {
+		_, _ = B.Reply(m, i18n.T(lang, "err.invalid_command"))
+		return
+	}
 
-				_, _ = B.Reply(
-					m,
-					fmt.Sprintf("<a href=\"%s\">%s</a> 退订成功", source.Link, html.EscapeString(source.Title)),
-					&tb.SendOptions{
-						ParseMode: tb.ModeHTML,
-					}, &tb.ReplyMarkup{
-						ReplyKeyboardRemove: true,
-					},
-				)
-				UserState[m.Chat.ID] = fsm.None
-				return
-			}
-		}
-	case fsm.Sub:
-		{
-			url := strings.Split(m.Text, " ")
-			if !CheckURL(url[0]) {
-				_, _ = B.Reply(m, "请回复正确的URL", &tb.ReplyMarkup{ForceReply: true})
-				return
-			}
+	_, _ = B.Reply(
+		m,
+		i18n.T(lang, "unsub.success_short", source.Link, html.EscapeString(source.Title)),
+		&tb.SendOptions{
+			ParseMode: tb.ModeHTML,
+		}, &tb.ReplyMarkup{
+			ReplyKeyboardRemove: true,
+		},
+	)
+	_ = states.Reset(m.Chat.ID)
+}
 
-			registerFeed(m, m.Chat, url[0])
-			UserState[m.Chat.ID] = fsm.None
-		}
-	case fsm.SetSubTag:
-		{
-			return
-		}
-	case fsm.Set:
-		{
-			str := strings.Split(m.Text, " ")
-			url := str[len(str)-1]
-			if len(str) != 2 && !CheckURL(url) {
-				_, _ = B.Reply(m, "请选择正确的指令！")
-			} else {
-				source, err := model.GetSourceByUrl(url)
+// subStateCtr 订阅流程中回复的 url
+func subStateCtr(m *tb.Message, _ *fsm.State) {
+	lang := messageLanguage(m)
+	url := strings.Split(m.Text, " ")
+	if !CheckURL(url[0]) {
+		_, _ = B.Reply(m, i18n.T(lang, "sub.invalid_url"), &tb.ReplyMarkup{ForceReply: true})
+		return
+	}
 
-				if err != nil {
-					_, _ = B.Reply(m, "请选择正确的指令！")
-					return
-				}
-				sub, err := model.GetSubscribeByUserIDAndSourceID(m.Chat.ID, source.ID)
-				if err != nil {
-					_, _ = B.Reply(m, "请选择正确的指令！")
-					return
-				}
+	registerFeed(m, m.Chat, url[0])
+	_ = states.Reset(m.Chat.ID)
+}
 
-				t := template.New("setting template")
-				_, _ = t.Parse(feedSettingTmpl)
-				text := new(bytes.Buffer)
-				_ = t.Execute(text, map[string]interface{}{
-					"source": source,
-					"sub":    sub,
-					"Count":  config.ErrorThreshold,
-				})
+// setStateCtr 设置流程中回复的订阅
+func setStateCtr(m *tb.Message, _ *fsm.State) {
+	lang := messageLanguage(m)
+	str := strings.Split(m.Text, " ")
+	url := str[len(str)-1]
+	if len(str) != 2 && !CheckURL(url) {
+		_, _ = B.Reply(m, i18n.T(lang, "err.invalid_command"))
+		return
+	}
 
-				data := fmt.Sprintf("%d:%d", m.Chat.ID, source.ID)
-				textStr := fmt.Sprintf("%s%s", getUserHtml(m.Chat, m.Chat, ""), strings.TrimSpace(text.String()))
-				_, _ = B.Reply(m, textStr, &tb.SendOptions{
-					DisableWebPagePreview: true,
-					ParseMode:             tb.ModeHTML,
-				}, &tb.ReplyMarkup{
-					InlineKeyboard:      genFeedSetBtn(data, sub, source),
-					ReplyKeyboardRemove: true,
-				})
-				UserState[m.Chat.ID] = fsm.None
-			}
-		}
-	default:
-		mention, args, urls := GetArgumentsFromMessage(m)
-		urlMap := make(map[string]string, len(urls))
-		for _, url := range urls {
-			if IsTorrentUrl(url) {
-				urlMap[url] = ""
-			}
-		}
-		for _, part := range args {
-			if strings.HasPrefix(part, util.PrefixMagnet) && len(part) >= util.LengthMagnet {
-				urlMap[part] = ""
-			}
-		}
+	source, err := model.GetSourceByUrl(url)
+
+	if err != nil {
+		_, _ = B.Reply(m, i18n.T(lang, "err.invalid_command"))
+		return
+	}
+	sub, err := model.GetSubscribeByUserIDAndSourceID(m.Chat.ID, source.ID)
+	if err != nil {
+		_, _ = B.Reply(m, i18n.T(lang, "err.invalid_command"))
+		return
+	}
+
+	data := fmt.Sprintf("%d:%d", m.Chat.ID, source.ID)
+	textStr := getUserHtml(lang, m.Chat, m.Chat, "") + feedSettingText(lang, source, sub)
+	_, _ = B.Reply(m, textStr, &tb.SendOptions{
+		DisableWebPagePreview: true,
+		ParseMode:             tb.ModeHTML,
+	}, &tb.ReplyMarkup{
+		InlineKeyboard:      signKeyboard(m.Chat.ID, genFeedSetBtn(lang, data, sub, source, messageThreadID(m))),
+		ReplyKeyboardRemove: true,
+	})
+	_ = states.Reset(m.Chat.ID)
+}
+
+// migrationCtr 群组升级为超级群组后将订阅及设置迁移到新的会话 id
+func migrationCtr(from, to int64) {
+	if err := model.MigrateChat(from, to); err != nil {
+		zap.S().Errorw("migrate chat failed", "from", from, "to", to, "error", err.Error())
+		return
+	}
+	_ = states.Reset(from)
+	count, _ := model.CountSubsByUserID(to)
+	zap.S().Infow("chat migrated", "from", from, "to", to, "subscriptions", count)
+	if count == 0 {
+		return
+	}
+	lang := chatLanguage(to, nil)
+	_, _ = sender.Send(to, i18n.T(lang, "chat.migrated", count))
+}
 
-		total := len(urlMap)
-		if total <= 0 {
+// myChatMemberCtr bot 在会话中的成员状态变化，被移出时处理会话的订阅，重新加入时恢复
+func myChatMemberCtr(u *tb.ChatMemberUpdated) {
+	if u.NewChatMember == nil {
+		return
+	}
+	chatID := u.Chat.ID
+	switch u.NewChatMember.Role {
+	case tb.Left, tb.Kicked:
+		removeChat(chatID)
+	case tb.Member, tb.Administrator, tb.Creator:
+		if u.OldChatMember == nil || (u.OldChatMember.Role != tb.Left && u.OldChatMember.Role != tb.Kicked) {
 			return
 		}
-		tgUser, err := getMentionedUser(m, mention, nil)
-		if err != nil {
-			return
+		// 将 bot 加入会话的用户记为会话所有者
+		saveChat(&u.Chat, int64(u.From.ID))
+		if count := restoreChat(chatID); count > 0 {
+			lang := chatLanguage(chatID, &u.From)
+			_, _ = sender.Send(chatID, i18n.T(lang, "chat.restored", count))
 		}
-		user, _ := model.FindOrCreateUserByTelegramID(tgUser.ID)
-		if user.Token == "" {
-			return
+	}
+}
+
+func textCtr(m *tb.Message) {
+	// 处于多步操作中的会话交由状态机处理
+	if states.Dispatch(m) {
+		return
+	}
+
+	lang := messageLanguage(m)
+	mention, args, urls := GetArgumentsFromMessage(m)
+	urlMap := make(map[string]string, len(urls))
+	for _, url := range urls {
+		if IsTorrentUrl(url) {
+			urlMap[url] = ""
 		}
-		count := AddPutIoTransfers(user.Token, urlMap)
-		_, _ = B.Reply(m, fmt.Sprintf("发现%d条链接，成功添加%d个下载任务", total, count))
 	}
+	for _, part := range args {
+		if strings.HasPrefix(part, util.PrefixMagnet) && len(part) >= util.LengthMagnet {
+			urlMap[part] = ""
+		}
+	}
+
+	total := len(urlMap)
+	if total <= 0 || !roleLimit(senderRole(m.Sender)).Download {
+		return
+	}
+	tgUser, err := getMentionedUser(m, mention, nil)
+	if err != nil {
+		return
+	}
+	user, _ := model.FindOrCreateUserByTelegramID(tgUser.ID)
+	if user.Token == "" {
+		return
+	}
+	count := AddPutIoTransfers(user.Token, urlMap)
+	_, _ = B.Reply(m, i18n.T(lang, "download.found", total, count))
 }
 
 // docCtr Document handler
 func docCtr(m *tb.Message) {
+	lang := messageLanguage(m)
 	mention, _, _ := GetArgumentsFromMessage(m)
 	user, err := getMentionedUser(m, mention, nil)
 	if err != nil {
-		_, _ = B.Reply(m, err.Error())
+		_, _ = B.Reply(m, errorText(lang, err))
 		return
 	}
 
@@ -1176,36 +2380,52 @@ func docCtr(m *tb.Message) {
 }
 
 func importOpmlFile(m *tb.Message, userID int64, url string) {
+	lang := messageLanguage(m)
 	opml, err := GetOPMLByURL(url)
 	if err != nil {
 		var text string
 		if err.Error() == "fetch opml file error" {
-			text = "下载 OPML 文件失败，请检查 bot 服务器能否正常连接至 Telegram 服务器或稍后尝试导入。错误代码 02"
+			text = i18n.T(lang, "opml.fetch_failed")
 		} else {
-			text = fmt.Sprintf("如果需要导入订阅，请发送正确的 OPML 文件。错误代码 01，doc mimetype: %s", m.Document.MIME)
+			text = i18n.T(lang, "opml.invalid", m.Document.MIME)
 		}
 		_, _ = B.Reply(m, text)
 		return
 	}
 
-	message, _ := B.Reply(m, "处理中，请稍候...")
+	message, _ := B.Reply(m, i18n.T(lang, "processing_wait"))
 	outlines, _ := opml.GetFlattenOutlines()
 	var failImportList []Outline
 	var successImportList []Outline
+	var limitText string
 
 	for _, outline := range outlines {
+		if limitText == "" {
+			limitText = subscribeLimitText(lang, m.Sender, userID)
+		}
+		if limitText != "" {
+			failImportList = append(failImportList, outline)
+			continue
+		}
 		source, err := model.RegistFeed(userID, outline.XMLURL)
 		if err != nil {
 			failImportList = append(failImportList, outline)
 			continue
 		}
+		if sub, err := model.GetSubscribeByUserIDAndSourceID(userID, source.ID); err == nil {
+			applyRoleLimit(m.Sender, sub)
+			// 论坛话题只存在于超级群组中
+			if threadID := outline.ThreadID; threadID != 0 && userID < 0 && sub.ThreadID != threadID {
+				_ = sub.SetThread(threadID)
+			}
+		}
 		zap.S().Infof("%d subscribe [%d]%s %s", m.Chat.ID, source.ID, source.Title, source.Link)
 		successImportList = append(successImportList, outline)
 	}
 
-	importReport := fmt.Sprintf("<b>导入成功：%d，导入失败：%d</b>", len(successImportList), len(failImportList))
+	importReport := i18n.T(lang, "opml.result", len(successImportList), len(failImportList))
 	if len(successImportList) != 0 {
-		successReport := "\n\n<b>以下订阅源导入成功:</b>"
+		successReport := i18n.T(lang, "opml.success_list")
 		for i, line := range successImportList {
 			if line.Text != "" {
 				successReport += fmt.Sprintf("\n[%d] <a href=\"%s\">%s</a>", i+1, line.XMLURL, line.Text)
@@ -1217,7 +2437,7 @@ func importOpmlFile(m *tb.Message, userID int64, url string) {
 	}
 
 	if len(failImportList) != 0 {
-		failReport := "\n\n<b>以下订阅源导入失败:</b>"
+		failReport := i18n.T(lang, "opml.fail_list")
 		for i, line := range failImportList {
 			if line.Text != "" {
 				failReport += fmt.Sprintf("\n[%d] <a href=\"%s\">%s</a>", i+1, line.XMLURL, line.Text)
@@ -1227,6 +2447,9 @@ func importOpmlFile(m *tb.Message, userID int64, url string) {
 		}
 		importReport += failReport
 	}
+	if limitText != "" {
+		importReport += "\n\n" + limitText
+	}
 
 	_, _ = B.Edit(message, importReport, &tb.SendOptions{
 		DisableWebPagePreview: true,
@@ -1235,9 +2458,14 @@ func importOpmlFile(m *tb.Message, userID int64, url string) {
 }
 
 func startTorrentFileTransfer(msg *tb.Message, userId int64, url string) {
+	lang := messageLanguage(msg)
+	if !roleLimit(senderRole(msg.Sender)).Download {
+		_, _ = B.Reply(msg, i18n.T(lang, "limit.download"))
+		return
+	}
 	user, _ := model.FindOrCreateUserByTelegramID(userId)
 	if user.Token == "" {
-		_, _ = B.Reply(msg, "请先通过 `/set_token` 设置Put.io的token", &tb.SendOptions{
+		_, _ = B.Reply(msg, i18n.T(lang, "set.need_token_md"), &tb.SendOptions{
 			ParseMode: tb.ModeMarkdown,
 		})
 		return
@@ -1245,8 +2473,8 @@ func startTorrentFileTransfer(msg *tb.Message, userId int64, url string) {
 	urlMap := map[string]string{url: ""}
 	count := AddPutIoTransfers(user.Token, urlMap)
 	if count <= 0 {
-		_, _ = B.Reply(msg, "添加下载任务失败")
+		_, _ = B.Reply(msg, i18n.T(lang, "download.failed"))
 		return
 	}
-	_, _ = B.Reply(msg, "成功添加下载任务")
+	_, _ = B.Reply(msg, i18n.T(lang, "download.success"))
 }
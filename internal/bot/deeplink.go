@@ -0,0 +1,80 @@
+package bot
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/indes/flowerss-bot/internal/model"
+
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// maxStartPayload telegram /start 参数的最大长度
+const maxStartPayload = 64
+
+// encodeFeedPayload 订阅链接的 /start 参数，为 base64url 编码的 feed url，
+// 编码后超出长度限制时改为编码带签名的源 id，避免通过遍历 id 获取他人的订阅地址
+func encodeFeedPayload(source *model.Source) string {
+	if payload := base64.RawURLEncoding.EncodeToString([]byte(source.Link)); len(payload) <= maxStartPayload {
+		return payload
+	}
+	id := strconv.FormatUint(uint64(source.ID), 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(id + "|" + feedPayloadSignature(id)))
+}
+
+// feedPayloadSignature 源 id 的签名，使用回调数据的签名密钥
+func feedPayloadSignature(id string) string {
+	mac := hmac.New(sha256.New, callbackKey())
+	mac.Write([]byte("feed\f" + id))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:callbackSignatureSize])
+}
+
+// decodeFeedPayload 解析订阅链接的 /start 参数，返回源 id 或 feed url 之一，源 id 签名不符时视为无效
+func decodeFeedPayload(payload string) (sourceID uint, url string, ok bool) {
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(payload, "="))
+	if err != nil || len(data) == 0 {
+		return 0, "", false
+	}
+	text := string(data)
+	if idx := strings.LastIndexByte(text, '|'); idx > 0 {
+		id, signature := text[:idx], text[idx+1:]
+		if hmac.Equal([]byte(signature), []byte(feedPayloadSignature(id))) {
+			n, err := strconv.ParseUint(id, 10, 32)
+			return uint(n), "", err == nil && n > 0
+		}
+	}
+	if CheckURL(text) {
+		return 0, text, true
+	}
+	return 0, "", false
+}
+
+// feedShareLink 订阅源的分享链接，打开后向 bot 发送 /start 参数
+func feedShareLink(source *model.Source) string {
+	return fmt.Sprintf("https://t.me/%s?start=%s", B.Me.Username, encodeFeedPayload(source))
+}
+
+// feedButtonData 确认订阅按钮的数据为 feed url，超出回调数据长度限制时为空，由 previewFeedURL 从预览消息中获取
+func feedButtonData(unique string, url string) string {
+	if callbackDataFits(unique, url) {
+		return url
+	}
+	return ""
+}
+
+// previewFeedURL 预览消息中第一个链接为订阅源地址
+func previewFeedURL(m *tb.Message) string {
+	if m == nil {
+		return ""
+	}
+	for _, e := range m.Entities {
+		if e.Type == tb.EntityTextLink {
+			return e.URL
+		}
+	}
+	return ""
+}
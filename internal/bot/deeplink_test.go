@@ -0,0 +1,85 @@
+package bot
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/indes/flowerss-bot/internal/model"
+
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+var longLink = "https://example.com/" + strings.Repeat("feed/", 20) + "rss.xml"
+
+func Test_feedPayload(t *testing.T) {
+	tests := []struct {
+		name   string
+		source *model.Source
+		wantID uint
+		want   string
+	}{
+		{"url", &model.Source{ID: 3, Link: "https://example.com/rss"}, 0, "https://example.com/rss"},
+		{"long url", &model.Source{ID: 42, Link: longLink}, 42, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload := encodeFeedPayload(tt.source)
+			if len(payload) > maxStartPayload {
+				t.Fatalf("payload %q is longer than %d", payload, maxStartPayload)
+			}
+			id, url, ok := decodeFeedPayload(payload)
+			if !ok || id != tt.wantID || url != tt.want {
+				t.Errorf("decodeFeedPayload() = %d, %q, %v, want %d, %q", id, url, ok, tt.wantID, tt.want)
+			}
+		})
+	}
+}
+
+func Test_decodeFeedPayload(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		ok      bool
+	}{
+		{"signed source id", encodeFeedPayload(&model.Source{ID: 123, Link: longLink}), true},
+		{"unsigned source id", "MTIz", false},
+		{"forged signature", base64.RawURLEncoding.EncodeToString([]byte("124|" + feedPayloadSignature("123"))), false},
+		{"not base64", "inv_!!", false},
+		{"not url", "aGVsbG8", false},
+		{"zero id", "MA", false},
+		{"empty", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, ok := decodeFeedPayload(tt.payload); ok != tt.ok {
+				t.Errorf("decodeFeedPayload(%q) ok = %v, want %v", tt.payload, ok, tt.ok)
+			}
+		})
+	}
+}
+
+func Test_feedButtonData(t *testing.T) {
+	short := "https://a.com/rss"
+	if got := feedButtonData("deeplink_sub_btn", short); got != short {
+		t.Errorf("feedButtonData() = %q, want %q", got, short)
+	}
+	long := "https://example.com/" + strings.Repeat("feed/", 10)
+	if got := feedButtonData("deeplink_sub_btn", long); got != "" {
+		t.Errorf("feedButtonData() = %q, want empty", got)
+	}
+}
+
+func Test_previewFeedURL(t *testing.T) {
+	m := &tb.Message{Entities: []tb.MessageEntity{
+		{Type: tb.EntityBold},
+		{Type: tb.EntityTextLink, URL: "https://example.com/rss"},
+		{Type: tb.EntityURL},
+	}}
+	if got := previewFeedURL(m); got != "https://example.com/rss" {
+		t.Errorf("previewFeedURL() = %q", got)
+	}
+	if got := previewFeedURL(nil); got != "" {
+		t.Errorf("previewFeedURL(nil) = %q", got)
+	}
+}
@@ -10,4 +10,5 @@ var (
 	ErrNotGroupAdmin      = errors.New("仅群组管理员可执行此项操作")
 	ErrNotChannelAdmin    = errors.New("仅频道管理员可执行此项操作")
 	ErrBotNotChannelAdmin = errors.New("请将 bot 添加为频道的管理员")
+	ErrRenderNews         = errors.New("消息模版渲染失败")
 )
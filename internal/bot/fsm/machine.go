@@ -0,0 +1,101 @@
+package fsm
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// Handler 处理处于某一状态的会话发送的文本消息，
+// 流程结束时调用 Machine.Reset，需要进入下一步时调用 Machine.Enter
+type Handler func(m *tb.Message, state *State)
+
+// Machine 会话状态机，多步操作通过 Handle 注册各状态的处理器
+type Machine struct {
+	store Store
+	ttl   time.Duration
+
+	mu       sync.RWMutex
+	handlers map[UserStatus]Handler
+}
+
+// NewMachine new Machine，ttl 为状态无操作后的过期时间，0 为不过期
+func NewMachine(store Store, ttl time.Duration) *Machine {
+	return &Machine{
+		store:    store,
+		ttl:      ttl,
+		handlers: make(map[UserStatus]Handler),
+	}
+}
+
+// Handle 注册 status 状态下文本消息的处理器
+func (fm *Machine) Handle(status UserStatus, h Handler) {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	fm.handlers[status] = h
+}
+
+// Enter 会话进入 status 状态
+func (fm *Machine) Enter(chatID int64, status UserStatus, payload string) error {
+	if status == None {
+		return fm.Reset(chatID)
+	}
+	state := &State{ChatID: chatID, Status: status, Payload: payload}
+	if fm.ttl > 0 {
+		state.ExpireAt = time.Now().Add(fm.ttl)
+	}
+	return fm.store.Set(state)
+}
+
+// Reset 结束会话当前的操作
+func (fm *Machine) Reset(chatID int64) error {
+	return fm.store.Delete(chatID)
+}
+
+// Current 会话当前未过期的状态，没有进行中的操作时返回 nil
+func (fm *Machine) Current(chatID int64) (*State, error) {
+	state, err := fm.store.Get(chatID)
+	if err != nil || state == nil {
+		return nil, err
+	}
+	if state.Status == None || state.Expired(time.Now()) {
+		return nil, nil
+	}
+	return state, nil
+}
+
+// Dispatch 将消息交给会话当前状态的处理器，会话没有进行中的操作时返回 false
+func (fm *Machine) Dispatch(m *tb.Message) bool {
+	state, err := fm.Current(m.Chat.ID)
+	if err != nil {
+		zap.S().Warnw("get conversation state failed", "chat id", m.Chat.ID, "error", err)
+		return false
+	}
+	if state == nil {
+		return false
+	}
+
+	fm.mu.RLock()
+	h, ok := fm.handlers[state.Status]
+	fm.mu.RUnlock()
+	if !ok {
+		return true
+	}
+
+	// 收到消息后重新计算过期时间，处理器可再修改状态
+	if fm.ttl > 0 {
+		state.ExpireAt = time.Now().Add(fm.ttl)
+		if err := fm.store.Set(state); err != nil {
+			zap.S().Warnw("refresh conversation state failed", "chat id", m.Chat.ID, "error", err)
+		}
+	}
+	h(m, state)
+	return true
+}
+
+// Expire 清除已过期的状态并返回，用于通知会话操作已超时
+func (fm *Machine) Expire(now time.Time) ([]*State, error) {
+	return fm.store.PopExpired(now)
+}
@@ -0,0 +1,73 @@
+package fsm
+
+import (
+	"testing"
+	"time"
+
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+func TestMemoryStore_PopExpired(t *testing.T) {
+	now := time.Now()
+	store := NewMemoryStore()
+	_ = store.Set(&State{ChatID: 1, Status: Sub, ExpireAt: now.Add(-time.Minute)})
+	_ = store.Set(&State{ChatID: 2, Status: Sub, ExpireAt: now.Add(time.Minute)})
+	_ = store.Set(&State{ChatID: 3, Status: Sub})
+
+	expired, err := store.PopExpired(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(expired) != 1 || expired[0].ChatID != 1 {
+		t.Fatalf("PopExpired() = %v, want chat 1", expired)
+	}
+	for _, tt := range []struct {
+		chatID int64
+		exist  bool
+	}{{1, false}, {2, true}, {3, true}} {
+		state, _ := store.Get(tt.chatID)
+		if (state != nil) != tt.exist {
+			t.Errorf("Get(%d) = %v, want exist %v", tt.chatID, state, tt.exist)
+		}
+	}
+}
+
+func TestMachine_Dispatch(t *testing.T) {
+	var handled []string
+	fm := NewMachine(NewMemoryStore(), time.Minute)
+	fm.Handle(Sub, func(m *tb.Message, state *State) {
+		handled = append(handled, state.Payload+m.Text)
+		_ = fm.Reset(m.Chat.ID)
+	})
+
+	tests := []struct {
+		name    string
+		prepare func()
+		want    bool
+		handled int
+	}{
+		{"no state", func() {}, false, 0},
+		{"handled", func() { _ = fm.Enter(1, Sub, "p:") }, true, 1},
+		{"reset by handler", func() {}, false, 1},
+		{"no handler", func() { _ = fm.Enter(1, SetSubTag, "") }, true, 1},
+		{"reset", func() { _ = fm.Reset(1) }, false, 1},
+		{"expired", func() {
+			_ = fm.store.Set(&State{ChatID: 1, Status: Sub, ExpireAt: time.Now().Add(-time.Second)})
+		}, false, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.prepare()
+			m := &tb.Message{Text: "text", Chat: &tb.Chat{ID: 1}}
+			if got := fm.Dispatch(m); got != tt.want {
+				t.Errorf("Dispatch() = %v, want %v", got, tt.want)
+			}
+			if len(handled) != tt.handled {
+				t.Errorf("handled %d times, want %d", len(handled), tt.handled)
+			}
+		})
+	}
+	if handled[0] != "p:text" {
+		t.Errorf("handler got %q, want %q", handled[0], "p:text")
+	}
+}
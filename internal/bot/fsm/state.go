@@ -1,5 +1,7 @@
 package fsm
 
+import "time"
+
 type UserStatus int
 
 const (
@@ -10,3 +12,17 @@ const (
 	SetSubTag
 	UnSubAll
 )
+
+// State 会话所处的状态
+type State struct {
+	ChatID int64
+	Status UserStatus
+	// Payload 状态附带的数据，如等待设置标签的订阅 id
+	Payload  string
+	ExpireAt time.Time
+}
+
+// Expired 状态在 now 时是否已过期
+func (s *State) Expired(now time.Time) bool {
+	return !s.ExpireAt.IsZero() && now.After(s.ExpireAt)
+}
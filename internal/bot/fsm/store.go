@@ -0,0 +1,127 @@
+package fsm
+
+import (
+	"sync"
+	"time"
+
+	"github.com/indes/flowerss-bot/internal/model"
+)
+
+// Store 会话状态存储，实现需要支持并发调用
+type Store interface {
+	// Get 获取会话的状态，不存在时返回 nil
+	Get(chatID int64) (*State, error)
+	// Set 保存会话的状态
+	Set(state *State) error
+	// Delete 清除会话的状态
+	Delete(chatID int64) error
+	// PopExpired 删除并返回在 now 之前过期的状态
+	PopExpired(now time.Time) ([]*State, error)
+}
+
+// MemoryStore 内存中的状态存储，重启后状态丢失
+type MemoryStore struct {
+	mu     sync.Mutex
+	states map[int64]State
+}
+
+// NewMemoryStore new MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{states: make(map[int64]State)}
+}
+
+// Get 获取会话的状态
+func (s *MemoryStore) Get(chatID int64) (*State, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, ok := s.states[chatID]
+	if !ok {
+		return nil, nil
+	}
+	return &state, nil
+}
+
+// Set 保存会话的状态
+func (s *MemoryStore) Set(state *State) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.states[state.ChatID] = *state
+	return nil
+}
+
+// Delete 清除会话的状态
+func (s *MemoryStore) Delete(chatID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.states, chatID)
+	return nil
+}
+
+// PopExpired 删除并返回已过期的状态
+func (s *MemoryStore) PopExpired(now time.Time) ([]*State, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var expired []*State
+	for chatID, state := range s.states {
+		if state.Expired(now) {
+			state := state
+			expired = append(expired, &state)
+			delete(s.states, chatID)
+		}
+	}
+	return expired, nil
+}
+
+// DBStore 保存在数据库中的状态存储
+type DBStore struct{}
+
+// NewDBStore new DBStore
+func NewDBStore() *DBStore {
+	return &DBStore{}
+}
+
+// Get 获取会话的状态
+func (s *DBStore) Get(chatID int64) (*State, error) {
+	record, err := model.GetConversationState(chatID)
+	if err != nil || record == nil {
+		return nil, err
+	}
+	return fromRecord(record), nil
+}
+
+// Set 保存会话的状态
+func (s *DBStore) Set(state *State) error {
+	return model.SaveConversationState(&model.ConversationState{
+		ChatID:   state.ChatID,
+		Status:   int(state.Status),
+		Payload:  state.Payload,
+		ExpireAt: state.ExpireAt,
+	})
+}
+
+// Delete 清除会话的状态
+func (s *DBStore) Delete(chatID int64) error {
+	return model.DeleteConversationState(chatID)
+}
+
+// PopExpired 删除并返回已过期的状态
+func (s *DBStore) PopExpired(now time.Time) ([]*State, error) {
+	records, err := model.PopExpiredConversationStates(now)
+	if err != nil {
+		return nil, err
+	}
+	states := make([]*State, 0, len(records))
+	for _, record := range records {
+		states = append(states, fromRecord(record))
+	}
+	return states, nil
+}
+
+func fromRecord(record *model.ConversationState) *State {
+	return &State{
+		ChatID:   record.ChatID,
+		Status:   UserStatus(record.Status),
+		Payload:  record.Payload,
+		ExpireAt: record.ExpireAt,
+	}
+}
@@ -0,0 +1,68 @@
+package bot
+
+import (
+	"errors"
+
+	"github.com/indes/flowerss-bot/internal/i18n"
+	"github.com/indes/flowerss-bot/internal/model"
+
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// errorKeys 可展示给用户的错误对应的消息 key
+var errorKeys = map[error]string{
+	ErrNoPermission:            "err.no_permission",
+	ErrChatNotFound:            "err.chat_not_found",
+	ErrNotGroupAdmin:           "err.not_group_admin",
+	ErrNotChannelAdmin:         "err.not_channel_admin",
+	ErrBotNotChannelAdmin:      "err.bot_not_channel_admin",
+	ErrRenderNews:              "err.render_news",
+	model.ErrNotSubscribed:     "err.not_subscribed",
+	model.ErrSubscribeNotFound: "err.subscribe_not_found",
+	model.ErrInviteNotFound:    "invite.not_found",
+	model.ErrInviteExpired:     "invite.expired",
+	model.ErrInviteUsedUp:      "invite.used_up",
+}
+
+// chatLanguage 会话使用的语言，依次为会话设置的语言、发送者 telegram 客户端的语言与默认语言
+func chatLanguage(chatID int64, sender *tb.User) string {
+	if lang := i18n.Parse(model.GetLanguageByUserId(chatID)); lang != "" {
+		return lang
+	}
+	if sender != nil {
+		if lang := i18n.Parse(sender.LanguageCode); lang != "" {
+			return lang
+		}
+	}
+	return i18n.Default()
+}
+
+// messageLanguage 回复消息使用的语言
+func messageLanguage(m *tb.Message) string {
+	return chatLanguage(m.Chat.ID, m.Sender)
+}
+
+// callbackLanguage 回应按钮回调使用的语言
+func callbackLanguage(c *tb.Callback) string {
+	return chatLanguage(c.Message.Chat.ID, c.Sender)
+}
+
+// pushLanguage 推送消息使用的语言
+func pushLanguage(chat *model.Chat) string {
+	if chat != nil {
+		if lang := i18n.Parse(chat.Language); lang != "" {
+			return lang
+		}
+	}
+	return i18n.Default()
+}
+
+// errorText 错误的本地化文本，未知错误使用原始错误信息
+func errorText(lang string, err error) string {
+	for target, key := range errorKeys {
+		if errors.Is(err, target) {
+			return i18n.T(lang, key)
+		}
+	}
+	return err.Error()
+}
@@ -0,0 +1,31 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/indes/flowerss-bot/internal/i18n"
+	"github.com/indes/flowerss-bot/internal/model"
+)
+
+func Test_errorText(t *testing.T) {
+	tests := []struct {
+		name string
+		lang string
+		err  error
+		want string
+	}{
+		{"english", i18n.English, ErrNoPermission, "You are not allowed to do this"},
+		{"chinese", i18n.Chinese, ErrNoPermission, "无权进行此项操作"},
+		{"wrapped", i18n.English, fmt.Errorf("unsub: %w", model.ErrNotSubscribed), "Not subscribed to this feed"},
+		{"unknown", i18n.English, errors.New("timeout"), "timeout"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := errorText(tt.lang, tt.err); got != tt.want {
+				t.Errorf("errorText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,204 @@
+package bot
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+
+	"github.com/indes/flowerss-bot/internal/config"
+	"github.com/indes/flowerss-bot/internal/model"
+	"github.com/indes/flowerss-bot/internal/util"
+
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+const (
+	// maxAlbumSize telegram 相册最多包含的媒体数量
+	maxAlbumSize = 10
+	// maxCaptionLength telegram 媒体说明的最大长度
+	maxCaptionLength = 1024
+	// maxPhotoURLSize 通过 URL 发送图片的大小上限
+	maxPhotoURLSize = 5 << 20
+	// maxFileURLSize 通过 URL 发送其他文件的大小上限
+	maxFileURLSize = 20 << 20
+	// maxLocalUploadSize 本地 Bot API 服务器的上传大小上限
+	maxLocalUploadSize = 2000 << 20
+)
+
+var imgSrcRegexp = regexp.MustCompile(`(?i)<img[^>]+src\s*=\s*["']([^"']+)["']`)
+
+// newsMedia 内容中可发送的媒体
+type newsMedia struct {
+	photos []*model.Enclosure
+	audio  *model.Enclosure
+	video  *model.Enclosure
+}
+
+func (m *newsMedia) empty() bool {
+	return len(m.photos) == 0 && m.audio == nil && m.video == nil
+}
+
+// album 是否以相册发送，相册不支持按钮
+func (m *newsMedia) album() bool {
+	return m.video == nil && m.audio == nil && len(m.photos) > 1
+}
+
+// collectMedia 从 enclosure 与描述中的首张图片收集媒体
+func collectMedia(content *model.Content) *newsMedia {
+	media := &newsMedia{}
+	for i := range content.Enclosures {
+		enclosure := &content.Enclosures[i]
+		if !mediaSizeAllowed(enclosure) {
+			continue
+		}
+		switch {
+		case enclosure.IsVideo():
+			if media.video == nil {
+				media.video = enclosure
+			}
+		case enclosure.IsAudio():
+			if media.audio == nil {
+				media.audio = enclosure
+			}
+		case enclosure.IsImage():
+			if len(media.photos) < maxAlbumSize {
+				media.photos = append(media.photos, enclosure)
+			}
+		}
+	}
+	if media.empty() {
+		if match := imgSrcRegexp.FindStringSubmatch(content.Description); match != nil {
+			if src := resolveURL(content.RawLink, html.UnescapeString(match[1])); src != "" {
+				media.photos = append(media.photos, &model.Enclosure{URL: src, Type: "image/*"})
+			}
+		}
+	}
+	return media
+}
+
+// resolveURL 将相对地址解析为绝对地址
+func resolveURL(base, ref string) string {
+	r, err := url.Parse(ref)
+	if err != nil {
+		return ""
+	}
+	if r.IsAbs() {
+		return r.String()
+	}
+	b, err := url.Parse(base)
+	if err != nil || !b.IsAbs() {
+		return ""
+	}
+	return b.ResolveReference(r).String()
+}
+
+// isLocalBotAPI 是否使用了本地 Bot API 服务器
+func isLocalBotAPI() bool {
+	return config.TelegramEndpoint != "" && config.TelegramEndpoint != tb.DefaultApiURL
+}
+
+// mediaSizeAllowed 媒体大小是否在可发送范围内，未知大小时交由 telegram 判断
+func mediaSizeAllowed(e *model.Enclosure) bool {
+	if e.Length == 0 {
+		return true
+	}
+	if isLocalBotAPI() {
+		return e.Length <= maxLocalUploadSize
+	}
+	if e.IsImage() {
+		return e.Length <= maxPhotoURLSize
+	}
+	return e.Length <= maxFileURLSize
+}
+
+// mediaFile 生成待发送的文件，超出 URL 发送上限时由本地 Bot API 服务器上传
+func mediaFile(e *model.Enclosure) (tb.File, io.Closer, error) {
+	limit := uint(maxFileURLSize)
+	if e.IsImage() {
+		limit = maxPhotoURLSize
+	}
+	if e.Length <= limit || !isLocalBotAPI() {
+		return tb.FromURL(e.URL), nil, nil
+	}
+
+	req, err := http.NewRequest(http.MethodGet, e.URL, nil)
+	if err != nil {
+		return tb.File{}, nil, err
+	}
+	req.Header.Set("User-Agent", config.UserAgent)
+	resp, err := util.HttpClient.Do(req)
+	if err != nil {
+		return tb.File{}, nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		_ = resp.Body.Close()
+		return tb.File{}, nil, fmt.Errorf("download media failed, status code %d", resp.StatusCode)
+	}
+	return tb.FromReader(resp.Body), resp.Body, nil
+}
+
+// sendMedia 以媒体消息发送内容到会话的 threadID 话题，caption 为渲染后的消息模版
+func sendMedia(chatID int64, threadID int, media *newsMedia, caption string, o *tb.SendOptions) (*tb.Message, error) {
+	var what interface{}
+	var closers []io.Closer
+	defer func() {
+		for _, c := range closers {
+			if c != nil {
+				_ = c.Close()
+			}
+		}
+	}()
+
+	switch {
+	case media.video != nil:
+		file, closer, err := mediaFile(media.video)
+		if err != nil {
+			return nil, err
+		}
+		closers = append(closers, closer)
+		what = &tb.Video{File: file, Caption: caption, SupportsStreaming: true}
+	case media.audio != nil:
+		file, closer, err := mediaFile(media.audio)
+		if err != nil {
+			return nil, err
+		}
+		closers = append(closers, closer)
+		what = &tb.Audio{File: file, Caption: caption}
+	case len(media.photos) == 1:
+		file, closer, err := mediaFile(media.photos[0])
+		if err != nil {
+			return nil, err
+		}
+		closers = append(closers, closer)
+		what = &tb.Photo{File: file, Caption: caption}
+	default:
+		album := make(tb.Album, 0, len(media.photos))
+		for i, photo := range media.photos {
+			file, closer, err := mediaFile(photo)
+			if err != nil {
+				return nil, err
+			}
+			closers = append(closers, closer)
+			p := &tb.Photo{File: file}
+			if i == 0 {
+				p.Caption = caption
+			}
+			album = append(album, p)
+		}
+		var msgs []tb.Message
+		err := sender.Do(chatID, func() (err error) {
+			msgs, err = threadBot(threadID).SendAlbum(&tb.Chat{ID: chatID}, album, o)
+			return
+		})
+		if err != nil || len(msgs) == 0 {
+			return nil, err
+		}
+		// 说明在相册的第一条消息中
+		return &msgs[0], nil
+	}
+
+	return sender.SendThread(chatID, threadID, what, o)
+}
@@ -0,0 +1,69 @@
+package bot
+
+import (
+	"testing"
+
+	"github.com/indes/flowerss-bot/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_collectMedia(t *testing.T) {
+	tests := []struct {
+		name       string
+		content    *model.Content
+		wantPhotos []string
+		wantAudio  string
+		wantVideo  string
+	}{
+		{
+			"enclosures",
+			&model.Content{Enclosures: []model.Enclosure{
+				{URL: "https://a.com/1.jpg", Type: "image/jpeg"},
+				{URL: "https://a.com/2.png", Type: "image/png"},
+				{URL: "https://a.com/a.mp3", Type: "audio/mpeg"},
+				{URL: "https://a.com/v.mp4", Type: "video/mp4", Length: 1 << 30},
+				{URL: "https://a.com/a.torrent", Type: "application/x-bittorrent"},
+			}},
+			[]string{"https://a.com/1.jpg", "https://a.com/2.png"},
+			"https://a.com/a.mp3",
+			"",
+		},
+		{
+			"first img in description",
+			&model.Content{
+				RawLink:     "https://a.com/post/1",
+				Description: `<p>hi</p><img alt="x" src="/img/1.jpg?a=1&amp;b=2"><img src="/img/2.jpg">`,
+			},
+			[]string{"https://a.com/img/1.jpg?a=1&b=2"},
+			"",
+			"",
+		},
+		{
+			"no media",
+			&model.Content{Description: "<p>hello</p>"},
+			nil,
+			"",
+			"",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			media := collectMedia(tt.content)
+			var photos []string
+			for _, p := range media.photos {
+				photos = append(photos, p.URL)
+			}
+			assert.Equal(t, tt.wantPhotos, photos)
+			if tt.wantAudio == "" {
+				assert.Nil(t, media.audio)
+			} else {
+				assert.Equal(t, tt.wantAudio, media.audio.URL)
+			}
+			if tt.wantVideo == "" {
+				assert.Nil(t, media.video)
+			} else {
+				assert.Equal(t, tt.wantVideo, media.video.URL)
+			}
+		})
+	}
+}
@@ -1,10 +1,182 @@
 package bot
 
-import "gopkg.in/tucnak/telebot.v2"
+import (
+	"errors"
+	"fmt"
+	"html"
+	"regexp"
+	"text/template"
+	"unicode/utf16"
+
+	"github.com/indes/flowerss-bot/internal/config"
+	"github.com/indes/flowerss-bot/internal/model"
+
+	"go.uber.org/zap"
+	"gopkg.in/tucnak/telebot.v2"
+)
+
+const (
+	// minPreviewLength 预览缩短到该长度以下时直接移除
+	minPreviewLength = 16
+	// maxFitAttempts 缩短消息的最大尝试次数
+	maxFitAttempts = 8
+)
+
+var (
+	errMessageTooLong = errors.New("message too long")
+
+	htmlTagRegexp        = regexp.MustCompile(`<[^>]*>`)
+	markdownLinkRegexp   = regexp.MustCompile(`\]\((?:\\.|[^)\\])*\)`)
+	markdownEscapeRegexp = regexp.MustCompile(`\\(.)`)
+
+	// overflowTpl 媒体说明放不下的剩余预览另行发送
+	overflowTpl = template.Must(config.ParseMessageTpl("{{.PreviewText}}"))
+)
+
+// messageLength telegram 解析格式后的消息长度，按 UTF-16 编码单元计算，markdown 为估算值
+func messageLength(msg string, mode telebot.ParseMode) int {
+	switch mode {
+	case telebot.ModeHTML:
+		msg = html.UnescapeString(htmlTagRegexp.ReplaceAllString(msg, ""))
+	case telebot.ModeMarkdown, telebot.ModeMarkdownV2:
+		msg = markdownEscapeRegexp.ReplaceAllString(markdownLinkRegexp.ReplaceAllString(msg, "]"), "$1")
+	}
+	return len(utf16.Encode([]rune(msg)))
+}
+
+// truncateText 截取前 n 个字符，超出部分以省略号代替
+func truncateText(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	if n <= 0 {
+		return ""
+	}
+	return string(runes[:n-1]) + "…"
+}
 
 type newContentMessage struct {
+	sub     *model.Subscribe
+	content *model.Content
+	tpl     *template.Template
+	mode    telebot.ParseMode
+	data    config.TplData
+	// preview 预览的字符数，用于生成媒体说明放不下的剩余预览
+	preview int
+	options *telebot.SendOptions
 }
 
+// render 渲染消息，超出 limit 时依次缩短预览与标题，返回消息及预览中被截掉的部分
+func (c *newContentMessage) render(limit int) (msg string, overflow string, err error) {
+	data := c.data
+	msg, err = data.RenderTemplate(c.tpl, c.mode)
+	if err != nil {
+		return "", "", fmt.Errorf("%w: %v", ErrRenderNews, err)
+	}
+	length := messageLength(msg, c.mode)
+
+	// shown 缩短后的预览中保留的字符数
+	shown := -1
+	for i := 0; length > limit && data.PreviewText != "" && i < maxFitAttempts; i++ {
+		keep := messageLength(data.PreviewText, c.mode) - (length - limit)
+		if keep < minPreviewLength {
+			data.PreviewText = ""
+			shown = 0
+		} else {
+			data.PreviewText = previewText(c.content, keep, c.mode)
+			// 截断后保留 keep-1 个字符与省略号
+			shown = keep - 1
+		}
+		if msg, err = data.RenderTemplate(c.tpl, c.mode); err != nil {
+			return "", "", fmt.Errorf("%w: %v", ErrRenderNews, err)
+		}
+		length = messageLength(msg, c.mode)
+	}
+	if shown >= 0 {
+		rest := c.data
+		rest.PreviewText = previewRest(c.content, shown, c.preview, c.mode)
+		if rest.PreviewText != "" {
+			overflow, _ = rest.RenderTemplate(overflowTpl, c.mode)
+		}
+	}
+
+	title := []rune(data.ContentTitle)
+	for i := 0; length > limit && len(title) > 0 && i < maxFitAttempts; i++ {
+		if cut := length - limit; cut < len(title) {
+			title = title[:len(title)-cut]
+		} else {
+			title = nil
+		}
+		data.ContentTitle = truncateText(c.data.ContentTitle, len(title))
+		if msg, err = data.RenderTemplate(c.tpl, c.mode); err != nil {
+			return "", "", fmt.Errorf("%w: %v", ErrRenderNews, err)
+		}
+		length = messageLength(msg, c.mode)
+	}
+
+	if length > limit {
+		return "", "", errMessageTooLong
+	}
+	return msg, overflow, nil
+}
+
+// Send 发送消息，订阅开启媒体时优先以媒体形式发送，媒体说明放不下的预览另行发送
 func (c *newContentMessage) Send() (*telebot.Message, error) {
-	return nil, nil
+	if c.sub.EnableMedia == 1 {
+		if media := collectMedia(c.content); !media.empty() {
+			caption, overflow, err := c.render(maxCaptionLength)
+			var sent *telebot.Message
+			if err == nil {
+				sent, err = sendMedia(c.sub.UserID, c.sub.ThreadID, media, caption, c.options)
+			}
+			if err == nil {
+				c.sendOverflow(overflow)
+				return sent, nil
+			}
+			if isChatUnreachable(err) {
+				return nil, err
+			}
+			zap.S().Warnw("send news media failed, fallback to text",
+				"user id", c.sub.UserID,
+				"content", c.content.HashID,
+				"error", err.Error(),
+			)
+		}
+	}
+
+	msg, _, err := c.render(maxMessageLength)
+	if errors.Is(err, errMessageTooLong) {
+		return c.SendPlain()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return sender.SendThread(c.sub.UserID, c.sub.ThreadID, msg, c.options)
+}
+
+// SendPlain 以纯文本发送消息，用于 telegram 无法解析渲染结果时降级发送
+func (c *newContentMessage) SendPlain() (*telebot.Message, error) {
+	o := *c.options
+	o.ParseMode = telebot.ModeDefault
+	return sender.SendThread(c.sub.UserID, c.sub.ThreadID, truncateText(c.data.PlainText(), maxMessageLength), &o)
+}
+
+// sendOverflow 发送媒体说明放不下的预览
+func (c *newContentMessage) sendOverflow(overflow string) {
+	if overflow == "" {
+		return
+	}
+	_, err := sender.SendThread(c.sub.UserID, c.sub.ThreadID, overflow, &telebot.SendOptions{
+		DisableWebPagePreview: true,
+		DisableNotification:   true,
+		ParseMode:             c.mode,
+	})
+	if err != nil {
+		zap.S().Warnw("send news overflow failed",
+			"user id", c.sub.UserID,
+			"content", c.content.HashID,
+			"error", err.Error(),
+		)
+	}
 }
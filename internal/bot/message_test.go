@@ -0,0 +1,92 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/indes/flowerss-bot/internal/config"
+	"github.com/indes/flowerss-bot/internal/model"
+
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+func Test_messageLength(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  string
+		mode tb.ParseMode
+		want int
+	}{
+		{"text", "hello", tb.ModeDefault, 5},
+		{"html", `<b>a &amp; b</b> <a href="https://example.com/">link</a>`, tb.ModeHTML, 10},
+		{"markdownv2", `*a\.b* [link](https://example.com/a\)b)`, tb.ModeMarkdownV2, 12},
+		{"utf16", "😀中", tb.ModeDefault, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := messageLength(tt.msg, tt.mode); got != tt.want {
+				t.Errorf("messageLength() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_newContentMessage_render(t *testing.T) {
+	tpl, err := config.ParseMessageTpl(`<b>{{.SourceTitle}}</b>
+{{.PreviewText}}
+<a href="{{.RawLink}}">{{.ContentTitle}}</a>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	longText := strings.Repeat("<p>很长的段落 <b>加粗</b></p>", 200)
+	content := &model.Content{Description: longText, RawLink: "https://example.com/"}
+
+	tests := []struct {
+		name         string
+		title        string
+		preview      string
+		limit        int
+		wantOverflow bool
+		wantErr      bool
+	}{
+		{"fits", "title", "short", 100, false, false},
+		{"trim preview", "title", previewText(content, 4096, tb.ModeHTML), 1024, true, false},
+		{"trim title", strings.Repeat("长", 200), "", 100, false, false},
+		{"too long", "title", "", 5, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &newContentMessage{
+				content: content,
+				tpl:     tpl,
+				mode:    tb.ModeHTML,
+				preview: 4096,
+				data: config.TplData{
+					SourceTitle:  "source",
+					ContentTitle: tt.title,
+					RawLink:      content.RawLink,
+					PreviewText:  tt.preview,
+				},
+			}
+			msg, overflow, err := c.render(tt.limit)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("render() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if l := messageLength(msg, tb.ModeHTML); l > tt.limit {
+				t.Errorf("render() length = %d, want <= %d", l, tt.limit)
+			}
+			if strings.Count(msg, "<b>") != strings.Count(msg, "</b>") {
+				t.Errorf("render() = %q, unbalanced tags", msg)
+			}
+			if (overflow != "") != tt.wantOverflow {
+				t.Errorf("render() overflow = %q, wantOverflow %v", overflow, tt.wantOverflow)
+			}
+			if overflow != "" && (!strings.HasPrefix(overflow, "…") || messageLength(overflow, tb.ModeHTML) >= messageLength(tt.preview, tb.ModeHTML)) {
+				t.Errorf("render() overflow = %q, want the rest of the preview", overflow)
+			}
+		})
+	}
+}
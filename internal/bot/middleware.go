@@ -33,7 +33,13 @@ func getChatByUserId(userId int64) (*tb.Chat, error) {
 func isAdminOfChat(senderId int, chat *tb.Chat) error {
 	isChannel := chat.Type == tb.ChatChannel || chat.Type == tb.ChatChannelPrivate
 	isBotAdmin := false
-	if adminList, err := B.AdminsOf(chat); err == nil {
+	var adminList []tb.ChatMember
+	// 经由限流发送器查询，避免批量检查会话时触发 429
+	err := sender.Do(chat.ID, func() (err error) {
+		adminList, err = B.AdminsOf(chat)
+		return
+	})
+	if err == nil {
 		isSenderAdmin := false
 		for _, admin := range adminList {
 			if admin.User.ID == senderId {
@@ -66,9 +72,11 @@ func getMentionedUser(msg *tb.Message, mention string, sender *tb.User) (user *t
 	}
 	var chat *tb.Chat
 	if userId, err := strconv.Atoi(mention); err != nil {
-		chat, _ = B.ChatByID(mention)
+		if chat, _ = B.ChatByID(mention); chat != nil {
+			saveChat(chat, 0)
+		}
 	} else {
-		chat, _ = getChatByUserId(int64(userId))
+		chat, _ = findChat(int64(userId))
 	}
 	if chat == nil {
 		err = ErrChatNotFound
@@ -88,6 +96,8 @@ func getMentionedUser(msg *tb.Message, mention string, sender *tb.User) (user *t
 	err = isAdminOfChat(sender.ID, chat)
 	if err == nil {
 		user = chat
+		// 首次管理会话的用户记为会话所有者
+		saveChat(chat, int64(sender.ID))
 	}
 	return
 }
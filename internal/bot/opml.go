@@ -59,6 +59,7 @@ type Outline struct {
 	Title        string    `xml:"title,attr,omitempty"`
 	Version      string    `xml:"version,attr,omitempty"`
 	Description  string    `xml:"description,attr,omitempty"`
+	ThreadID     int       `xml:"threadId,attr,omitempty"` // 推送到的论坛话题
 }
 
 // NewOPML gen OPML form []byte
@@ -130,8 +131,8 @@ func (o OPML) XML() (string, error) {
 	return xml.Header + string(b), err
 }
 
-// ToOPML dump OPML to opml file
-func ToOPML(sources []model.Source) (string, error) {
+// ToOPML dump OPML to opml file, threads 为订阅源推送到的论坛话题
+func ToOPML(sources []model.Source, threads map[uint]int) (string, error) {
 	O := OPML{}
 	O.XMLName.Local = "opml"
 	O.Version = "2.0"
@@ -143,6 +144,7 @@ func ToOPML(sources []model.Source) (string, error) {
 		outline.Text = s.Title
 		outline.Type = "rss"
 		outline.XMLURL = s.Link
+		outline.ThreadID = threads[s.ID]
 		O.Body.Outlines = append(O.Body.Outlines, outline)
 	}
 	return O.XML()
@@ -0,0 +1,191 @@
+package bot
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"go.uber.org/atomic"
+	"go.uber.org/zap"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+const (
+	// globalSendInterval 全局发送间隔，约 30 条/秒
+	globalSendInterval = time.Second / 30
+	// privateSendInterval 私聊发送间隔，约 1 条/秒
+	privateSendInterval = time.Second
+	// groupSendInterval 群组及频道发送间隔，约 20 条/分钟
+	groupSendInterval = time.Minute / 20
+	// maxFloodRetries 遇到 429 时的最大重试次数
+	maxFloodRetries = 3
+	// maxIdleLimiters 超过该数量时清理空闲的会话限流器
+	maxIdleLimiters = 1024
+)
+
+// rateLimiter 按固定间隔分配发送时间片
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+func newRateLimiter(interval time.Duration) *rateLimiter {
+	return &rateLimiter{interval: interval}
+}
+
+// reserve 预留下一个时间片，返回需要等待的时长
+func (l *rateLimiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	wait := l.next.Sub(now)
+	l.next = l.next.Add(l.interval)
+	return wait
+}
+
+// wait 阻塞直到可以发送
+func (l *rateLimiter) wait() {
+	if d := l.reserve(); d > 0 {
+		time.Sleep(d)
+	}
+}
+
+// delay 将下一个时间片推迟至少 d
+func (l *rateLimiter) delay(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if until := time.Now().Add(d); l.next.Before(until) {
+		l.next = until
+	}
+}
+
+// idle 限流器是否已空闲
+func (l *rateLimiter) idle(now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.next.Before(now)
+}
+
+// SenderStats 发送统计
+type SenderStats struct {
+	Sent    int64
+	Retried int64
+	Dropped int64
+}
+
+// Sender 带限流与 429 重试的消息发送器
+type Sender struct {
+	global *rateLimiter
+
+	mu    sync.Mutex
+	chats map[int64]*rateLimiter
+
+	sent    atomic.Int64
+	retried atomic.Int64
+	dropped atomic.Int64
+}
+
+// NewSender new Sender
+func NewSender() *Sender {
+	return &Sender{
+		global: newRateLimiter(globalSendInterval),
+		chats:  make(map[int64]*rateLimiter),
+	}
+}
+
+// Stats 返回发送统计
+func (s *Sender) Stats() SenderStats {
+	return SenderStats{
+		Sent:    s.sent.Load(),
+		Retried: s.retried.Load(),
+		Dropped: s.dropped.Load(),
+	}
+}
+
+// Send 向会话发送消息，受全局及会话限流约束
+func (s *Sender) Send(chatID int64, what interface{}, options ...interface{}) (*tb.Message, error) {
+	return s.SendThread(chatID, 0, what, options...)
+}
+
+// SendThread 向会话的论坛话题发送消息，threadID 为 0 时发送到默认话题
+func (s *Sender) SendThread(chatID int64, threadID int, what interface{}, options ...interface{}) (*tb.Message, error) {
+	var msg *tb.Message
+	err := s.Do(chatID, func() (err error) {
+		msg, err = threadBot(threadID).Send(&tb.Chat{ID: chatID}, what, options...)
+		return
+	})
+	return msg, err
+}
+
+// Do 在限流约束下执行一次发往 chatID 的请求，遇到 429 时按 retry_after 重试
+func (s *Sender) Do(chatID int64, fn func() error) error {
+	limiter := s.chatLimiter(chatID)
+	for attempt := 0; ; attempt++ {
+		limiter.wait()
+		s.global.wait()
+
+		err := fn()
+		if err == nil {
+			s.sent.Inc()
+			return nil
+		}
+
+		var floodErr tb.FloodError
+		if errors.As(err, &floodErr) && attempt < maxFloodRetries {
+			s.retried.Inc()
+			retryAfter := time.Duration(floodErr.RetryAfter) * time.Second
+			if retryAfter <= 0 {
+				retryAfter = time.Second
+			}
+			zap.S().Warnw("telegram flood limit reached, retrying",
+				"chat id", chatID,
+				"retry after", retryAfter,
+				"attempt", attempt+1,
+			)
+			// 429 为 bot 级别的限流，其他会话的发送同样推迟
+			limiter.delay(retryAfter)
+			s.global.delay(retryAfter)
+			continue
+		}
+
+		dropped := s.dropped.Inc()
+		zap.S().Warnw("message dropped",
+			"chat id", chatID,
+			"error", err.Error(),
+			"dropped total", dropped,
+		)
+		return err
+	}
+}
+
+// chatLimiter 获取会话对应的限流器
+func (s *Sender) chatLimiter(chatID int64) *rateLimiter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if l, ok := s.chats[chatID]; ok {
+		return l
+	}
+	if len(s.chats) >= maxIdleLimiters {
+		now := time.Now()
+		for id, l := range s.chats {
+			if l.idle(now) {
+				delete(s.chats, id)
+			}
+		}
+	}
+
+	interval := privateSendInterval
+	if chatID < 0 {
+		interval = groupSendInterval
+	}
+	l := newRateLimiter(interval)
+	s.chats[chatID] = l
+	return l
+}
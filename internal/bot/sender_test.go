@@ -0,0 +1,51 @@
+package bot
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimiter_reserve(t *testing.T) {
+	l := newRateLimiter(time.Second)
+	if d := l.reserve(); d != 0 {
+		t.Errorf("first reserve() = %v, want 0", d)
+	}
+	if d := l.reserve(); d <= 0 || d > time.Second {
+		t.Errorf("second reserve() = %v, want (0, 1s]", d)
+	}
+}
+
+func TestRateLimiter_delay(t *testing.T) {
+	l := newRateLimiter(time.Millisecond)
+	l.delay(5 * time.Second)
+	if d := l.reserve(); d < 4*time.Second {
+		t.Errorf("reserve() after delay = %v, want >= 4s", d)
+	}
+	if l.idle(time.Now()) {
+		t.Errorf("idle() = true, want false")
+	}
+}
+
+func TestSender_chatLimiter(t *testing.T) {
+	s := NewSender()
+	tests := []struct {
+		name   string
+		chatID int64
+		want   time.Duration
+	}{
+		{"private", 123, privateSendInterval},
+		{"group", -123, groupSendInterval},
+		{"channel", -1001234567890, groupSendInterval},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := s.chatLimiter(tt.chatID)
+			if l.interval != tt.want {
+				t.Errorf("chatLimiter().interval = %v, want %v", l.interval, tt.want)
+			}
+			if s.chatLimiter(tt.chatID) != l {
+				t.Errorf("chatLimiter() returned a new limiter for the same chat")
+			}
+		})
+	}
+}
@@ -12,7 +12,9 @@ import (
 	"strings"
 
 	"github.com/indes/flowerss-bot/internal/config"
+	"github.com/indes/flowerss-bot/internal/i18n"
 	"github.com/indes/flowerss-bot/internal/model"
+	"github.com/indes/flowerss-bot/internal/reader"
 	"github.com/indes/flowerss-bot/internal/util"
 
 	"github.com/putdotio/go-putio/putio"
@@ -21,7 +23,7 @@ import (
 	tb "gopkg.in/tucnak/telebot.v2"
 )
 
-func getUserHtml(user, chat *tb.Chat, defaultText string) (text string) {
+func getUserHtml(lang string, user, chat *tb.Chat, defaultText string) (text string) {
 	if user.ID != chat.ID {
 		if user.ID > 0 {
 			text = fmt.Sprintf("<a href=\"tg://user?id=%d\">%s</a> ", user.ID, html.EscapeString(user.Title))
@@ -31,9 +33,9 @@ func getUserHtml(user, chat *tb.Chat, defaultText string) (text string) {
 	}
 	if text != "" {
 		if user.Type == tb.ChatChannel || user.Type == tb.ChatChannelPrivate {
-			text = "频道 " + text
+			text = i18n.T(lang, "chat.channel") + text
 		} else if user.Type == tb.ChatGroup || user.Type == tb.ChatSuperGroup {
-			text = "群组 " + text
+			text = i18n.T(lang, "chat.group") + text
 		}
 	}
 	if text == "" {
@@ -43,28 +45,51 @@ func getUserHtml(user, chat *tb.Chat, defaultText string) (text string) {
 }
 
 func registerFeed(msg *tb.Message, user *tb.Chat, url string) {
-	var err error
-	chat := msg.Chat
-	msg, err = B.Reply(msg, "处理中...")
+	lang := messageLanguage(msg)
+	if text := subscribeLimitText(lang, msg.Sender, user.ID); text != "" {
+		_, _ = B.Reply(msg, text)
+		return
+	}
+	reply, err := B.Reply(msg, i18n.T(lang, "processing"))
+	if err != nil {
+		return
+	}
+	var threadID int
+	if user.ID == msg.Chat.ID {
+		threadID = messageThreadID(msg)
+	}
+	subscribeFeed(lang, reply, msg.Sender, user, msg.Chat, url, threadID)
+}
 
+// subscribeFeed 为 user 订阅 url，并将 msg 编辑为订阅结果，sender 为操作者，threadID 不为 0 时推送到该论坛话题
+func subscribeFeed(lang string, msg *tb.Message, sender *tb.User, user *tb.Chat, chat *tb.Chat, url string, threadID int) {
+	if sender != nil {
+		saveChat(user, int64(sender.ID))
+	}
 	source, err := model.RegistFeed(user.ID, url)
-	zap.S().Infof("%d for %d subscribe [%d]%s %s", chat.ID, user.ID, source.ID, source.Title, source.Link)
 	if err != nil {
-		_, _ = B.Edit(msg, fmt.Sprintf("订阅失败：%s", err))
+		_, _ = B.Edit(msg, i18n.T(lang, "sub.failed", err))
 		return
 	}
+	zap.S().Infof("%d for %d subscribe [%d]%s %s", chat.ID, user.ID, source.ID, source.Title, source.Link)
+	if sub, err := model.GetSubscribeByUserIDAndSourceID(user.ID, source.ID); err == nil {
+		applyRoleLimit(sender, sub)
+		if threadID != 0 && sub.ThreadID != threadID {
+			_ = sub.SetThread(threadID)
+		}
+	}
 
 	keyboard := make([][]tb.InlineButton, 1)
 	keyboard[0] = []tb.InlineButton{
 		{
 			Unique: "set_feed_item_btn",
-			Text:   "设置",
+			Text:   i18n.T(lang, "btn.settings"),
 			Data:   fmt.Sprintf("%d:%d:1", user.ID, source.ID),
 		},
 	}
 
-	newText := getUserHtml(user, chat, "")
-	newText += fmt.Sprintf("订阅 <a href=\"%s\">%s</a> 成功", source.Link, source.Title)
+	newText := getUserHtml(lang, user, chat, "")
+	newText += i18n.T(lang, "sub.success", source.Link, source.Title)
 	_, _ = B.Edit(
 		msg,
 		newText,
@@ -73,98 +98,240 @@ func registerFeed(msg *tb.Message, user *tb.Chat, url string) {
 			ParseMode:             tb.ModeHTML,
 		},
 		&tb.ReplyMarkup{
-			InlineKeyboard: keyboard,
+			InlineKeyboard: signKeyboard(msg.Chat.ID, keyboard),
 		},
 	)
 }
 
-//BroadcastNews send new contents message to subscriber
-func BroadcastNews(source *model.Source, subs []*model.Subscribe, contents []*model.Content) {
-	zap.S().Infow("broadcast news",
-		"fetcher id", source.ID,
-		"fetcher title", source.Title,
-		"subscriber count", len(subs),
-		"new contents", len(contents),
-	)
-
-	for _, content := range contents {
-		previewText := trimDescription(content.Description, config.PreviewText)
-
-		for _, sub := range subs {
-			tpldata := &config.TplData{
-				SourceTitle:     source.Title,
-				ContentTitle:    content.Title,
-				RawLink:         content.RawLink,
-				PreviewText:     previewText,
-				TelegraphURL:    content.TelegraphURL,
-				Tags:            sub.Tag,
-				EnableTelegraph: sub.EnableTelegraph == 1 && content.TelegraphURL != "",
-			}
+// SendNews send a new content message to the subscriber, the history is saved only after telegram confirms the message.
+// It returns the id of the sent message, for media it is the message with the caption, or 0 if the news was skipped.
+func SendNews(source *model.Source, sub *model.Subscribe, content *model.Content) (int, error) {
+	history := &model.History{
+		Type:      model.HistoryTelegramMessage,
+		TriggerId: content.GetTriggerId(),
+		TargetId:  strconv.FormatInt(sub.UserID, 10),
+	}
+	if history.IsSaved() {
+		return 0, nil
+	}
+	if sub.IsMuted() || sub.Paused {
+		zap.S().Debugw("skip muted subscription", "sub id", sub.ID, "content", content.HashID)
+		return 0, nil
+	}
 
-			u := &tb.User{
-				ID: int(sub.UserID),
-			}
+	msg := newNewsMessage(source, sub, content)
+	sent, err := msg.Send()
+	if errors.Is(err, ErrRenderNews) {
+		zap.S().Errorw("send news error, tpldata.Render err",
+			"error", err.Error(),
+			"source id", source.ID,
+			"content", content.HashID,
+		)
+		return 0, err
+	}
+	if err != nil && strings.Contains(err.Error(), "parse entities") {
+		/*
+			Telegram return error if the message has incomplete format, fallback to plain text.
+			api error: Bad Request: can't parse entities: Can't find end of the entity starting at byte offset 894
+		*/
+		zap.S().Warnw("send news error, fallback to plain text",
+			"mode", msg.mode,
+			"error", err.Error(),
+		)
+		sent, err = msg.SendPlain()
+	}
+	if err != nil {
+		if isChatUnreachable(err) {
+			zap.S().Errorw("send news error, bot stopped by user",
+				"error", err.Error(),
+				"user id", sub.UserID,
+				"source id", sub.SourceID,
+				"title", source.Title,
+				"link", source.Link,
+			)
+			removeChat(sub.UserID)
+		}
+		return 0, err
+	}
+	history.Save()
+	if sent == nil {
+		return 0, nil
+	}
+	return sent.ID, nil
+}
 
-			history := &model.History{
-				Type:      model.HistoryTelegramMessage,
-				TriggerId: content.GetTriggerId(),
-				TargetId:  strconv.FormatInt(sub.UserID, 10),
-			}
-			if history.IsSaved() {
-				continue
-			}
+// UpdateNewsTelegraph 页面生成后编辑已发送的消息，补充 telegraph 链接
+func UpdateNewsTelegraph(source *model.Source, sub *model.Subscribe, content *model.Content, messageID int) error {
+	if sub.EnableTelegraph != 1 {
+		return nil
+	}
+	msg := newNewsMessage(source, sub, content)
+	text, _, err := msg.render(maxMessageLength)
+	if err != nil {
+		return err
+	}
+	stored := tb.StoredMessage{MessageID: strconv.Itoa(messageID), ChatID: sub.UserID}
+	err = sender.Do(sub.UserID, func() error {
+		_, err := B.Edit(stored, text, msg.options)
+		return err
+	})
+	if err != nil && strings.Contains(err.Error(), "no text in the message") {
+		// 以媒体发送的消息编辑说明
+		err = editNewsCaption(msg, stored)
+	}
+	if err != nil && strings.Contains(err.Error(), "message is not modified") {
+		return nil
+	}
+	return err
+}
 
-			o := &tb.SendOptions{
-				DisableWebPagePreview: config.DisableWebPagePreview,
-				ParseMode:             config.MessageMode,
-				DisableNotification:   sub.EnableNotification != 1,
-			}
-			msg, err := tpldata.Render(config.MessageMode)
-			if err != nil {
-				zap.S().Errorw("broadcast news error, tpldata.Render err",
-					"error", err.Error(),
-				)
-				return
-			}
-			if _, err := B.Send(u, msg, o); err != nil {
-
-				if strings.Contains(err.Error(), "Forbidden") {
-					zap.S().Errorw("broadcast news error, bot stopped by user",
-						"error", err.Error(),
-						"user id", sub.UserID,
-						"source id", sub.SourceID,
-						"title", source.Title,
-						"link", source.Link,
-					)
-					sub.Unsub()
-				}
+// editNewsCaption 编辑以媒体发送的消息说明，媒体说明放不下的预览已另行发送，不再补发
+func editNewsCaption(msg *newContentMessage, stored tb.StoredMessage) error {
+	caption, _, err := msg.render(maxCaptionLength)
+	if err != nil {
+		return err
+	}
+	options := *msg.options
+	if collectMedia(msg.content).album() {
+		options.ReplyMarkup = nil
+	}
+	return sender.Do(msg.sub.UserID, func() error {
+		_, err := B.EditCaption(stored, caption, &options)
+		return err
+	})
+}
 
-				/*
-					Telegram return error if markdown message has incomplete format.
-					Print the msg to warn the user
-					api error: Bad Request: can't parse entities: Can't find end of the entity starting at byte offset 894
-				*/
-				if strings.Contains(err.Error(), "parse entities") {
-					zap.S().Errorw("broadcast news error, markdown error",
-						"markdown msg", msg,
-						"error", err.Error(),
-					)
-				}
-			} else {
-				history.Save()
-			}
+// newNewsMessage 按订阅与会话设置生成推送消息
+func newNewsMessage(source *model.Source, sub *model.Subscribe, content *model.Content) *newContentMessage {
+	if sub.EnableFullText == 1 {
+		if err := content.LoadFullText(); err != nil {
+			zap.S().Warnw("save full text failed", "content", content.HashID, "error", err)
+		}
+		if content.FullText != "" {
+			// 预览与媒体使用原文正文
+			fullText := *content
+			fullText.Description = content.FullText
+			content = &fullText
 		}
 	}
+	user, _ := model.FindOrCreateUserByTelegramID(sub.UserID)
+	chat, _ := model.FindOrCreateChat(sub.UserID)
+	tpl, mode := getMessageTpl(sub, user)
+	tpldata := newTplData(source, sub, content, mode)
+	tpldata.Location = getChatLocation(chat)
+	if reader.Enabled() {
+		// 阅读页面代替 telegraph 页面
+		if content.HasArticle() {
+			tpldata.TelegraphURL = reader.URL(content.HashID)
+		}
+		tpldata.EnableTelegraph = sub.EnableTelegraph == 1 && tpldata.TelegraphURL != ""
+	} else if _, err := model.GetTelegraphAccountByUserID(sub.UserID); err == nil {
+		// 会话拥有自有 telegraph 账号时只使用以其账号发布的页面
+		tpldata.TelegraphURL = model.GetChatTelegraphURL(content.HashID, sub.UserID)
+		tpldata.EnableTelegraph = sub.EnableTelegraph == 1 && tpldata.TelegraphURL != ""
+	}
+	return &newContentMessage{
+		sub:     sub,
+		content: content,
+		tpl:     tpl,
+		mode:    mode,
+		data:    *tpldata,
+		preview: config.PreviewText,
+		options: &tb.SendOptions{
+			DisableWebPagePreview: config.DisableWebPagePreview,
+			ParseMode:             mode,
+			DisableNotification:   sub.EnableNotification != 1,
+			ReplyMarkup:           genNewsBtns(pushLanguage(chat), sub, content, tpldata.TelegraphURL),
+		},
+	}
+}
+
+// newTplData 生成消息模版数据
+func newTplData(source *model.Source, sub *model.Subscribe, content *model.Content, mode tb.ParseMode) *config.TplData {
+	tpldata := &config.TplData{
+		SourceTitle:     source.Title,
+		ContentTitle:    content.Title,
+		RawLink:         content.RawLink,
+		PreviewText:     previewText(content, config.PreviewText, mode),
+		TelegraphURL:    content.TelegraphURL,
+		Tags:            sub.Tag,
+		EnableTelegraph: sub.EnableTelegraph == 1 && content.TelegraphURL != "",
+		Author:          content.Author,
+		Categories:      content.CategoryList(),
+		CommentsURL:     content.CommentsURL,
+		SourceLink:      source.SiteLink,
+	}
+	if content.PublishedAt != nil {
+		tpldata.PublishedAt = *content.PublishedAt
+	}
+	if content.ModifiedAt != nil {
+		tpldata.UpdatedAt = *content.ModifiedAt
+	}
+	if len(content.Enclosures) > 0 {
+		enclosure := content.Enclosures[0]
+		tpldata.EnclosureURL = enclosure.URL
+		tpldata.EnclosureType = enclosure.Type
+		tpldata.EnclosureSize = enclosure.Length
+	}
+	return tpldata
+}
+
+// IsPermanentSendError 判断发送错误是否无法通过重试恢复
+func IsPermanentSendError(err error) bool {
+	if err == nil {
+		return false
+	}
+	return errors.Is(err, ErrRenderNews) ||
+		isChatUnreachable(err) ||
+		strings.Contains(err.Error(), "chat not found") ||
+		strings.Contains(err.Error(), "parse entities")
+}
+
+// isChatUnreachable bot 被移出会话或被用户停用
+func isChatUnreachable(err error) bool {
+	return strings.Contains(err.Error(), "Forbidden")
+}
+
+// removeChat bot 被移出会话或被用户停用，按配置暂停或取消会话的所有订阅
+func removeChat(chatID int64) {
+	var count int64
+	var err error
+	if config.RemovedChatAction == "unsub" {
+		var success int
+		success, _, err = model.UnsubAllByUserID(chatID)
+		count = int64(success)
+	} else {
+		count, err = model.SetSubsPausedByUserID(chatID, true)
+	}
+	if err != nil {
+		zap.S().Errorw("handle removed chat failed", "chat id", chatID, "error", err.Error())
+		return
+	}
+	zap.S().Infow("bot removed from chat",
+		"chat id", chatID,
+		"action", config.RemovedChatAction,
+		"subscriptions", count,
+	)
+}
+
+// restoreChat bot 重新加入会话，恢复被暂停的订阅
+func restoreChat(chatID int64) int64 {
+	count, err := model.SetSubsPausedByUserID(chatID, false)
+	if err != nil {
+		zap.S().Errorw("restore chat failed", "chat id", chatID, "error", err.Error())
+		return 0
+	}
+	zap.S().Infow("bot rejoined chat", "chat id", chatID, "subscriptions", count)
+	return count
 }
 
 // BroadcastSourceError send fetcher updata error message to subscribers
 func BroadcastSourceError(source *model.Source) {
 	subs := model.GetSubscriberBySource(source)
-	var u tb.User
 	for _, sub := range subs {
-		message := fmt.Sprintf("<a href=\"%s\">%s</a> 已经累计连续%d次更新失败，暂时停止更新", source.Link, html.EscapeString(source.Title), config.ErrorThreshold)
-		u.ID = int(sub.UserID)
-		_, _ = B.Send(&u, message, &tb.SendOptions{
+		lang := chatLanguage(sub.UserID, nil)
+		message := i18n.T(lang, "source.error", source.Link, html.EscapeString(source.Title), config.ErrorThreshold)
+		_, _ = sender.SendThread(sub.UserID, sub.ThreadID, message, &tb.SendOptions{
 			ParseMode: tb.ModeHTML,
 		})
 	}
@@ -249,34 +416,62 @@ func CheckAdmin(upd *tb.Update) bool {
 	return err == nil
 }
 
-// IsUserAllowed check user is allowed to use bot
-func isUserAllowed(upd *tb.Update) bool {
+// isChatEvent 是否为会话迁移或 bot 成员状态变化，这些更新不受用户权限限制
+func isChatEvent(upd *tb.Update) bool {
 	if upd == nil {
 		return false
 	}
-	if len(config.AllowUsers) == 0 {
+	if upd.MyChatMember != nil {
 		return true
 	}
+	return upd.Message != nil && (upd.Message.MigrateTo != 0 || upd.Message.MigrateFrom != 0)
+}
+
+// isUserAllowed check user is allowed to use bot
+//
+// banned 用户不能使用，设置了 allowed_users 时只有被授予角色的用户可以使用
+func isUserAllowed(upd *tb.Update) bool {
+	if upd == nil {
+		return false
+	}
 
-	var userID int64
+	var sender *tb.User
 	if upd.Message != nil {
-		userID = int64(upd.Message.Sender.ID)
+		sender = upd.Message.Sender
 	} else if upd.Callback != nil {
-		userID = int64(upd.Callback.Sender.ID)
-	} else {
+		sender = upd.Callback.Sender
+	}
+	if sender == nil {
 		return false
 	}
 
-	for _, allowUserID := range config.AllowUsers {
-		if allowUserID == userID {
+	userID := int64(sender.ID)
+	switch userRole(userID) {
+	case model.RoleBanned:
+	case model.RoleGuest:
+		if len(config.AllowUsers) == 0 || isInviteStart(upd.Message) {
 			return true
 		}
+	default:
+		return true
 	}
 
 	zap.S().Infow("user not allowed", "userID", userID)
 	return false
 }
 
+// isInviteStart 是否为使用邀请码的 /start 命令，未被授予角色的用户可以借此获得使用权限
+func isInviteStart(m *tb.Message) bool {
+	if m == nil {
+		return false
+	}
+	fields := strings.Fields(m.Text)
+	if len(fields) != 2 || (fields[0] != "/start" && !strings.HasPrefix(fields[0], "/start@")) {
+		return false
+	}
+	return strings.HasPrefix(fields[1], model.InvitePrefix)
+}
+
 // HasAdminType check if the message is sent in the group/channel environment
 func HasAdminType(t tb.ChatType) bool {
 	hasAdmin := []tb.ChatType{tb.ChatGroup, tb.ChatSuperGroup, tb.ChatChannel, tb.ChatChannelPrivate}
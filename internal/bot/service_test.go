@@ -2,6 +2,7 @@ package bot
 
 import (
 	"github.com/indes/flowerss-bot/internal/config"
+	"github.com/indes/flowerss-bot/internal/model"
 	"github.com/magiconair/properties/assert"
 	tb "gopkg.in/tucnak/telebot.v2"
 	"testing"
@@ -94,6 +95,15 @@ func TestGetArgumentsFromMessage(t *testing.T) {
 
 // Test_isUserAllowed test isUserAllowed
 func Test_isUserAllowed(t *testing.T) {
+	roles := map[int64]model.Role{456: model.RoleBanned, 789: model.RoleUser}
+	lookupRole = func(userID int64) model.Role {
+		return roles[userID]
+	}
+	defer func() {
+		lookupRole = model.GetRoleByUserId
+		config.AllowUsers = nil
+	}()
+
 	tests := []struct {
 		name string
 		upd  *tb.Update
@@ -120,6 +130,17 @@ func Test_isUserAllowed(t *testing.T) {
 			},
 			true,
 		},
+		{
+			"banned",
+			&tb.Update{
+				Callback: &tb.Callback{
+					Sender: &tb.User{
+						ID: 456,
+					},
+				},
+			},
+			false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -157,6 +178,41 @@ func Test_isUserAllowed(t *testing.T) {
 			},
 			false,
 		},
+		{
+			"邀请码",
+			&tb.Update{
+				Message: &tb.Message{
+					Text: "/start inv_0123456789ab",
+					Sender: &tb.User{
+						ID: 555,
+					},
+				},
+			},
+			true,
+		},
+		{
+			"无邀请码",
+			&tb.Update{
+				Message: &tb.Message{
+					Text: "/start",
+					Sender: &tb.User{
+						ID: 555,
+					},
+				},
+			},
+			false,
+		},
+		{
+			"已授予角色用户",
+			&tb.Update{
+				Message: &tb.Message{
+					Sender: &tb.User{
+						ID: 789,
+					},
+				},
+			},
+			true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -166,3 +222,24 @@ func Test_isUserAllowed(t *testing.T) {
 		})
 	}
 }
+
+func Test_isChatEvent(t *testing.T) {
+	tests := []struct {
+		name string
+		upd  *tb.Update
+		want bool
+	}{
+		{"nil", nil, false},
+		{"message", &tb.Update{Message: &tb.Message{Text: "/sub"}}, false},
+		{"migrate to", &tb.Update{Message: &tb.Message{MigrateTo: -1001}}, true},
+		{"migrate from", &tb.Update{Message: &tb.Message{MigrateFrom: -1}}, true},
+		{"my chat member", &tb.Update{MyChatMember: &tb.ChatMemberUpdated{}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isChatEvent(tt.upd); got != tt.want {
+				t.Errorf("isChatEvent() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
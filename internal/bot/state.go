@@ -0,0 +1,42 @@
+package bot
+
+import (
+	"time"
+
+	"github.com/indes/flowerss-bot/internal/bot/fsm"
+	"github.com/indes/flowerss-bot/internal/config"
+	"github.com/indes/flowerss-bot/internal/i18n"
+
+	"go.uber.org/zap"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// newStateMachine 按配置创建会话状态机
+func newStateMachine() *fsm.Machine {
+	var store fsm.Store
+	switch config.ConversationStore {
+	case "memory":
+		store = fsm.NewMemoryStore()
+	default:
+		store = fsm.NewDBStore()
+	}
+	return fsm.NewMachine(store, time.Duration(config.ConversationTimeout)*time.Minute)
+}
+
+// ExpireStates 取消已超时的多步操作，并通知对应会话
+func ExpireStates() {
+	expired, err := states.Expire(time.Now())
+	if err != nil {
+		zap.S().Warnw("expire conversation states failed", "error", err)
+		return
+	}
+	for _, state := range expired {
+		lang := chatLanguage(state.ChatID, nil)
+		_, err := B.Send(&tb.Chat{ID: state.ChatID}, i18n.T(lang, "cancel.timeout"), &tb.ReplyMarkup{
+			ReplyKeyboardRemove: true,
+		})
+		if err != nil {
+			zap.S().Warnw("send conversation timeout message failed", "chat id", state.ChatID, "error", err)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package bot
+
+import (
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/indes/flowerss-bot/internal/config"
+	"github.com/indes/flowerss-bot/internal/model"
+
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// messageTplCache 已解析的自定义消息模版，key 为模版文本
+var messageTplCache sync.Map
+
+// parseMessageTpl 解析并缓存自定义消息模版
+func parseMessageTpl(text string) (*template.Template, error) {
+	if tpl, ok := messageTplCache.Load(text); ok {
+		return tpl.(*template.Template), nil
+	}
+	tpl, err := config.ParseMessageTpl(text)
+	if err != nil {
+		return nil, err
+	}
+	messageTplCache.Store(text, tpl)
+	return tpl, nil
+}
+
+// getMessageTpl 获取订阅使用的消息模版及渲染模式，优先级为 订阅 > 会话 > 全局配置
+func getMessageTpl(sub *model.Subscribe, user *model.User) (*template.Template, tb.ParseMode) {
+	if sub.MessageTpl != "" {
+		if tpl, err := parseMessageTpl(sub.MessageTpl); err == nil {
+			return tpl, config.ParseMessageMode(sub.MessageMode)
+		}
+	}
+	if user != nil && user.MessageTpl != "" {
+		if tpl, err := parseMessageTpl(user.MessageTpl); err == nil {
+			return tpl, config.ParseMessageMode(user.MessageMode)
+		}
+	}
+	return config.MessageTpl, config.MessageMode
+}
+
+// locationCache 已加载的时区
+var locationCache sync.Map
+
+// loadLocation 加载并缓存时区
+func loadLocation(name string) (*time.Location, error) {
+	if loc, ok := locationCache.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	locationCache.Store(name, loc)
+	return loc, nil
+}
+
+// getChatLocation 获取会话设置的时区，未设置时使用 bot 所在时区
+func getChatLocation(chat *model.Chat) *time.Location {
+	if chat != nil && chat.Timezone != "" {
+		if loc, err := loadLocation(chat.Timezone); err == nil {
+			return loc
+		}
+	}
+	return time.Local
+}
+
+// isMessageModeName 判断参数是否为渲染模式名称
+func isMessageModeName(s string) bool {
+	switch strings.ToLower(s) {
+	case "md", "markdown", "mdv2", "markdownv2", "html", "text", "default":
+		return true
+	}
+	return false
+}
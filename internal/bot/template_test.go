@@ -0,0 +1,33 @@
+package bot
+
+import (
+	"testing"
+
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+func Test_parseTemplateCmd(t *testing.T) {
+	tests := []struct {
+		name        string
+		text        string
+		wantMention string
+		wantSubID   int
+		wantMode    string
+		wantTpl     string
+	}{
+		{"empty", "/set_template", "", 0, "", ""},
+		{"chat template", "/set_template html\n<b>{{.ContentTitle}}</b>", "", 0, "html", "<b>{{.ContentTitle}}</b>"},
+		{"sub template", "/set_template 12 md\n*{{.ContentTitle}}*\n{{.RawLink}}", "", 12, "md", "*{{.ContentTitle}}*\n{{.RawLink}}"},
+		{"channel template", "/set_template @channel\n{{.ContentTitle}}", "@channel", 0, "", "{{.ContentTitle}}"},
+		{"reset", "/reset_template 3", "", 3, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mention, subID, mode, tpl := parseTemplateCmd(&tb.Message{Text: tt.text})
+			if mention != tt.wantMention || subID != tt.wantSubID || mode != tt.wantMode || tpl != tt.wantTpl {
+				t.Errorf("parseTemplateCmd() = %q, %d, %q, %q, want %q, %d, %q, %q",
+					mention, subID, mode, tpl, tt.wantMention, tt.wantSubID, tt.wantMode, tt.wantTpl)
+			}
+		})
+	}
+}
@@ -0,0 +1,284 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/indes/flowerss-bot/internal/util"
+
+	"go.uber.org/zap"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+const (
+	// maxTopicMessages 话题缓存每一代保存的消息数量
+	maxTopicMessages = 4096
+	// pollRetryInterval 获取更新失败后的重试间隔
+	pollRetryInterval = time.Second
+)
+
+// topics 记录收到的消息所在的论坛话题，telebot 不解析 message_thread_id
+var topics = newTopicCache(maxTopicMessages)
+
+type topicKey struct {
+	chatID    int64
+	messageID int
+}
+
+// topicCache 按消息记录话题 id，超出容量时丢弃较旧的一代
+type topicCache struct {
+	mu       sync.Mutex
+	size     int
+	current  map[topicKey]int
+	previous map[topicKey]int
+}
+
+func newTopicCache(size int) *topicCache {
+	return &topicCache{
+		size:    size,
+		current: make(map[topicKey]int),
+	}
+}
+
+func (c *topicCache) set(chatID int64, messageID int, threadID int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.current) >= c.size {
+		c.previous = c.current
+		c.current = make(map[topicKey]int)
+	}
+	c.current[topicKey{chatID, messageID}] = threadID
+}
+
+func (c *topicCache) get(chatID int64, messageID int) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := topicKey{chatID, messageID}
+	if threadID, ok := c.current[key]; ok {
+		return threadID
+	}
+	return c.previous[key]
+}
+
+// messageThreadID 消息所在的论坛话题，不在话题中时返回 0
+func messageThreadID(m *tb.Message) int {
+	if m == nil || m.Chat == nil {
+		return 0
+	}
+	return topics.get(m.Chat.ID, m.ID)
+}
+
+// topicMessage 消息中 telebot 未解析的话题字段
+type topicMessage struct {
+	ID       int  `json:"message_id"`
+	ThreadID int  `json:"message_thread_id"`
+	IsTopic  bool `json:"is_topic_message"`
+	Chat     struct {
+		ID int64 `json:"id"`
+	} `json:"chat"`
+}
+
+type topicUpdate struct {
+	Message       *topicMessage `json:"message"`
+	EditedMessage *topicMessage `json:"edited_message"`
+	Callback      *struct {
+		Message *topicMessage `json:"message"`
+	} `json:"callback_query"`
+}
+
+// recordTopics 在 telebot 解析更新前记录其中消息所在的论坛话题
+func recordTopics(data []byte) {
+	var topic topicUpdate
+	if err := json.Unmarshal(data, &topic); err != nil {
+		return
+	}
+	messages := []*topicMessage{topic.Message, topic.EditedMessage}
+	if topic.Callback != nil {
+		messages = append(messages, topic.Callback.Message)
+	}
+	for _, m := range messages {
+		if m != nil && m.IsTopic && m.ThreadID != 0 {
+			topics.set(m.Chat.ID, m.ID, m.ThreadID)
+		}
+	}
+}
+
+// filterUpdates 对 getUpdates 的结果逐条调用 recordTopics，
+// telebot 无法解析的更新替换为只含 update_id 的空更新，使轮询偏移量始终前进
+func filterUpdates(data []byte) []byte {
+	var resp map[string]json.RawMessage
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return data
+	}
+	var updates []json.RawMessage
+	if err := json.Unmarshal(resp["result"], &updates); err != nil {
+		return data
+	}
+	changed := false
+	for i, raw := range updates {
+		recordTopics(raw)
+		var upd tb.Update
+		if err := json.Unmarshal(raw, &upd); err == nil {
+			continue
+		}
+		var id struct {
+			ID int `json:"update_id"`
+		}
+		if err := json.Unmarshal(raw, &id); err != nil {
+			continue
+		}
+		zap.S().Warnw("skip undecodable update", "update id", id.ID)
+		updates[i], _ = json.Marshal(id)
+		changed = true
+	}
+	if !changed {
+		return data
+	}
+	resp["result"], _ = json.Marshal(updates)
+	filtered, err := json.Marshal(resp)
+	if err != nil {
+		return data
+	}
+	return filtered
+}
+
+// topicTransport bot 请求的 http transport，threadID 不为 0 时以 message_thread_id 参数发送到该论坛话题，
+// 否则在 tb.LongPoller 解析 getUpdates 的结果前经 filterUpdates 处理
+type topicTransport struct {
+	next     http.RoundTripper
+	threadID int
+}
+
+func (t *topicTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.threadID != 0 {
+		r := new(http.Request)
+		*r = *req
+		u := *req.URL
+		query := u.Query()
+		query.Set("message_thread_id", strconv.Itoa(t.threadID))
+		u.RawQuery = query.Encode()
+		r.URL = &u
+		return t.next.RoundTrip(r)
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if !strings.HasSuffix(req.URL.Path, "/getUpdates") {
+		return resp, err
+	}
+	if err != nil {
+		// tb.LongPoller 出错后立即重试，等待片刻以免空转
+		time.Sleep(pollRetryInterval)
+		return nil, err
+	}
+	data, err := ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		time.Sleep(pollRetryInterval)
+		return nil, err
+	}
+	data = filterUpdates(data)
+	resp.Body = ioutil.NopCloser(bytes.NewReader(data))
+	resp.ContentLength = int64(len(data))
+	resp.Header.Del("Content-Length")
+	return resp, nil
+}
+
+// newBotClient bot 使用的 http client，threadID 不为 0 时消息发送到该论坛话题
+func newBotClient(threadID int) *http.Client {
+	next := util.HttpClient.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &http.Client{
+		Transport: &topicTransport{next: next, threadID: threadID},
+		Timeout:   util.HttpClient.Timeout,
+	}
+}
+
+// threadBots 按论坛话题缓存的 bot，与 B 使用同一 token
+var threadBots sync.Map
+
+// threadBot 发送到 threadID 话题的 bot，threadID 为 0 时返回 B
+func threadBot(threadID int) *tb.Bot {
+	if threadID == 0 {
+		return B
+	}
+	if b, ok := threadBots.Load(threadID); ok {
+		return b.(*tb.Bot)
+	}
+	b, err := tb.NewBot(tb.Settings{
+		URL:     B.URL,
+		Token:   B.Token,
+		Updates: 1,
+		Client:  newBotClient(threadID),
+		Offline: true,
+	})
+	if err != nil {
+		return B
+	}
+	actual, _ := threadBots.LoadOrStore(threadID, b)
+	return actual.(*tb.Bot)
+}
+
+// topicWebhook 由 tb.Webhook 设置 webhook，接收的更新经 recordTopics 记录论坛话题后再解析，
+// 未配置监听地址时由外部 http 服务调用 ServeHTTP 转发更新
+type topicWebhook struct {
+	*tb.Webhook
+	listen string
+	dest   chan tb.Update
+}
+
+// newTopicWebhook 由 topicWebhook 代替 tb.Webhook 监听 w.Listen
+func newTopicWebhook(w *tb.Webhook) *topicWebhook {
+	h := &topicWebhook{Webhook: w, listen: w.Listen}
+	w.Listen = ""
+	return h
+}
+
+func (h *topicWebhook) Poll(b *tb.Bot, dest chan tb.Update, stop chan struct{}) {
+	h.dest = dest
+	if h.listen != "" {
+		s := &http.Server{Addr: h.listen, Handler: h}
+		go func() {
+			<-stop
+			_ = s.Shutdown(context.Background())
+		}()
+		go func() {
+			var err error
+			if h.TLS != nil {
+				err = s.ListenAndServeTLS(h.TLS.Cert, h.TLS.Key)
+			} else {
+				err = s.ListenAndServe()
+			}
+			if err != nil && err != http.ErrServerClosed {
+				zap.S().Errorw("webhook server stopped", "error", err.Error())
+			}
+		}()
+	}
+	// 监听地址已清空，tb.Webhook 只设置 webhook 并等待停止
+	h.Webhook.Poll(b, dest, stop)
+}
+
+// ServeHTTP 接收 telegram 推送的更新
+func (h *topicWebhook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return
+	}
+	recordTopics(data)
+	var upd tb.Update
+	if err := json.Unmarshal(data, &upd); err != nil {
+		zap.S().Warnw("decode update failed", "error", err.Error())
+		return
+	}
+	h.dest <- upd
+}
@@ -0,0 +1,120 @@
+package bot
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+func Test_recordTopics(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      string
+		chatID    int64
+		messageID int
+		want      int
+	}{
+		{
+			"topic message",
+			`{"update_id":1,"message":{"message_id":10,"message_thread_id":3,"is_topic_message":true,"chat":{"id":-1001,"type":"supergroup"},"text":"/sub"}}`,
+			-1001, 10, 3,
+		},
+		{
+			"reply thread",
+			`{"update_id":2,"message":{"message_id":11,"message_thread_id":5,"chat":{"id":-1001,"type":"supergroup"},"text":"/sub"}}`,
+			-1001, 11, 0,
+		},
+		{
+			"callback",
+			`{"update_id":3,"callback_query":{"id":"1","data":"x","message":{"message_id":12,"message_thread_id":7,"is_topic_message":true,"chat":{"id":-1002,"type":"supergroup"}}}}`,
+			-1002, 12, 7,
+		},
+		{
+			"general",
+			`{"update_id":4,"message":{"message_id":13,"chat":{"id":-1001,"type":"supergroup"},"text":"/sub"}}`,
+			-1001, 13, 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recordTopics([]byte(tt.data))
+			m := &tb.Message{ID: tt.messageID, Chat: &tb.Chat{ID: tt.chatID}}
+			if got := messageThreadID(m); got != tt.want {
+				t.Errorf("messageThreadID() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_topicCache(t *testing.T) {
+	c := newTopicCache(2)
+	c.set(1, 1, 11)
+	c.set(1, 2, 12)
+	c.set(1, 3, 13)
+	if got := c.get(1, 1); got != 11 {
+		t.Errorf("get() previous generation = %d, want 11", got)
+	}
+	c.set(1, 4, 14)
+	c.set(1, 5, 15)
+	if got := c.get(1, 1); got != 0 {
+		t.Errorf("get() evicted = %d, want 0", got)
+	}
+	if got := c.get(1, 5); got != 15 {
+		t.Errorf("get() = %d, want 15", got)
+	}
+}
+
+func Test_filterUpdates(t *testing.T) {
+	data := `{"ok":true,"result":[` +
+		`{"update_id":20,"message":{"message_id":"bad","chat":{"id":-1001}}},` +
+		`{"update_id":21,"message":{"message_id":30,"message_thread_id":9,"is_topic_message":true,"chat":{"id":-1001,"type":"supergroup"}}}]}`
+	var resp struct {
+		OK     bool
+		Result []tb.Update
+	}
+	if err := json.Unmarshal(filterUpdates([]byte(data)), &resp); err != nil {
+		t.Fatalf("filterUpdates() result can not be decoded: %v", err)
+	}
+	if !resp.OK || len(resp.Result) != 2 || resp.Result[0].ID != 20 || resp.Result[1].ID != 21 {
+		t.Errorf("filterUpdates() = %+v", resp)
+	}
+	if got := messageThreadID(&tb.Message{ID: 30, Chat: &tb.Chat{ID: -1001}}); got != 9 {
+		t.Errorf("messageThreadID() = %d, want 9", got)
+	}
+
+	unchanged := `{"ok":false,"error_code":409,"description":"Conflict"}`
+	if got := string(filterUpdates([]byte(unchanged))); got != unchanged {
+		t.Errorf("filterUpdates() = %s, want %s", got, unchanged)
+	}
+}
+
+func Test_topicTransport(t *testing.T) {
+	var query url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	defer srv.Close()
+
+	for _, threadID := range []int{0, 5} {
+		client := &http.Client{Transport: &topicTransport{next: http.DefaultTransport, threadID: threadID}}
+		resp, err := client.Post(srv.URL+"/botTOKEN/sendMessage", "application/json", strings.NewReader("{}"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+		want := ""
+		if threadID != 0 {
+			want = strconv.Itoa(threadID)
+		}
+		if got := query.Get("message_thread_id"); got != want {
+			t.Errorf("message_thread_id = %q, want %q", got, want)
+		}
+	}
+}
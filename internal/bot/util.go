@@ -1,13 +1,15 @@
 package bot
 
 import (
-	"html"
-	"regexp"
-	"strings"
+	"github.com/indes/flowerss-bot/internal/model"
+	"github.com/indes/flowerss-bot/internal/tghtml"
 
-	strip "github.com/grokify/html-strip-tags-go"
+	tb "gopkg.in/tucnak/telebot.v2"
 )
 
+// maxMessageLength telegram 文本消息的最大长度
+const maxMessageLength = 4096
+
 func findAndDelete(s []string, item string) []string {
 	idx := 0
 	for _, i := range s {
@@ -19,24 +21,30 @@ func findAndDelete(s []string, item string) []string {
 	return s[:idx]
 }
 
-func trimDescription(desc string, limit int) string {
-	if limit == 0 {
+// previewText 生成消息预览，HTML 模式下保留 telegram 支持的格式，其他模式为纯文本
+func previewText(content *model.Content, limit int, mode tb.ParseMode) string {
+	if limit <= 0 {
 		return ""
 	}
-	desc = strings.Trim(
-		strip.StripTags(
-			regexp.MustCompile("\n+").ReplaceAllLiteralString(
-				strings.ReplaceAll(
-					regexp.MustCompile(`<br(| /)>`).ReplaceAllString(
-						html.UnescapeString(desc), "<br>"),
-					"<br>", "\n"),
-				"\n")),
-		"\n")
-
-	contentDescRune := []rune(desc)
-	if len(contentDescRune) > limit {
-		desc = string(contentDescRune[:limit])
+	if limit > maxMessageLength {
+		limit = maxMessageLength
 	}
+	if mode == tb.ModeHTML {
+		return tghtml.Convert(content.Description, content.RawLink, limit)
+	}
+	return tghtml.Text(content.Description, limit)
+}
 
-	return desc
+// previewRest 生成预览中前 shown 个字符之后的部分，limit 为完整预览的字符数，截断规则同 previewText
+func previewRest(content *model.Content, shown, limit int, mode tb.ParseMode) string {
+	if limit <= 0 || shown >= limit {
+		return ""
+	}
+	if limit > maxMessageLength {
+		limit = maxMessageLength
+	}
+	if mode == tb.ModeHTML {
+		return tghtml.ConvertRest(content.Description, content.RawLink, shown, limit)
+	}
+	return tghtml.TextRest(content.Description, shown, limit)
 }
@@ -8,11 +8,11 @@ import (
 	"os"
 	"path"
 	"path/filepath"
-	"regexp"
 	"strconv"
 	"strings"
 	"testing"
 	"text/template"
+	"time"
 
 	"github.com/spf13/viper"
 	tb "gopkg.in/tucnak/telebot.v2"
@@ -49,7 +49,7 @@ func init() {
 		viper.SetConfigFile(filepath.Join(workDir, "config.yml"))
 	}
 
-	fmt.Println(logo)
+	fmt.Print(logo)
 	err := viper.ReadInConfig() // Find and read the config file
 	if err != nil {             // Handle errors reading the config file
 		panic(fmt.Errorf("Fatal error config file: %s", err))
@@ -62,6 +62,7 @@ func init() {
 	}
 
 	BotToken = viper.GetString("bot_token")
+	CallbackSecret = viper.GetString("callback_secret")
 	Socks5 = viper.GetString("socks5")
 	UserAgent = viper.GetString("user_agent")
 	if UserAgent == "" {
@@ -86,6 +87,22 @@ func init() {
 		}
 	}
 
+	if footer := viper.GetString("telegraph_footer"); footer != "" {
+		TelegraphFooter = footer
+	}
+
+	if viper.IsSet("language") {
+		Language = viper.GetString("language")
+	}
+
+	if baseURL := viper.GetString("reader.base_url"); baseURL != "" {
+		EnableReader = true
+		ReaderBaseURL = strings.TrimRight(baseURL, "/")
+		if viper.IsSet("reader.listen") {
+			ReaderListen = viper.GetString("reader.listen")
+		}
+	}
+
 	if viper.IsSet("preview_text") {
 		PreviewText = viper.GetInt("preview_text")
 	}
@@ -101,6 +118,37 @@ func init() {
 		}
 	}
 
+	if viper.IsSet("owners") {
+		for _, ownerIDStr := range viper.GetStringSlice("owners") {
+			ownerID, err := strconv.ParseInt(ownerIDStr, 10, 64)
+			if err != nil {
+				panic(fmt.Errorf("Fatal error config file: %s", err))
+			}
+			Owners = append(Owners, ownerID)
+		}
+	}
+
+	for _, role := range []string{"admin", "user"} {
+		key := "limits." + role
+		if !viper.IsSet(key) {
+			continue
+		}
+		limit := RoleLimit{Telegraph: true, Download: true}
+		limit.MaxSubscriptions = viper.GetInt(key + ".max_subscriptions")
+		limit.MinInterval = viper.GetInt(key + ".min_interval")
+		if viper.IsSet(key + ".telegraph") {
+			limit.Telegraph = viper.GetBool(key + ".telegraph")
+		}
+		if viper.IsSet(key + ".download") {
+			limit.Download = viper.GetBool(key + ".download")
+		}
+		Limits[role] = limit
+	}
+
+	if viper.IsSet("message_buttons") {
+		MessageButtons = viper.GetStringSlice("message_buttons")
+	}
+
 	if viper.IsSet("disable_web_page_preview") {
 		DisableWebPagePreview = viper.GetBool("disable_web_page_preview")
 	}
@@ -150,33 +198,69 @@ func init() {
 		}
 	}
 
+	if viper.IsSet("content.compress") {
+		CompressContent = viper.GetBool("content.compress")
+	}
+
+	if viper.IsSet("content.max_size") {
+		MaxContentSize = viper.GetInt("content.max_size")
+	}
+
+	if viper.IsSet("conversation.store") {
+		ConversationStore = viper.GetString("conversation.store")
+	}
+
+	if viper.IsSet("conversation.timeout") {
+		ConversationTimeout = viper.GetInt("conversation.timeout")
+	}
+
+	if viper.IsSet("removed_chat_action") {
+		RemovedChatAction = viper.GetString("removed_chat_action")
+	}
+
 	if viper.IsSet("log.db_log") {
 		DBLogMode = viper.GetBool("log.db_log")
 	}
 }
 
 func (t TplData) Render(mode tb.ParseMode) (string, error) {
+	return t.RenderTemplate(MessageTpl, mode)
+}
+
+// RenderTemplate 使用指定模版渲染消息
+func (t TplData) RenderTemplate(tpl *template.Template, mode tb.ParseMode) (string, error) {
 	var buf []byte
 	wb := bytes.NewBuffer(buf)
 
-	if mode == tb.ModeMarkdown {
-		mkd := regexp.MustCompile("(\\[|\\*|\\`|\\_)")
-		t.SourceTitle = mkd.ReplaceAllString(t.SourceTitle, "\\$1")
-		t.ContentTitle = mkd.ReplaceAllString(t.ContentTitle, "\\$1")
-		t.PreviewText = mkd.ReplaceAllString(t.PreviewText, "\\$1")
-	} else if mode == tb.ModeHTML {
-		t.SourceTitle = t.replaceHTMLTags(t.SourceTitle)
-		t.ContentTitle = t.replaceHTMLTags(t.ContentTitle)
-		t.PreviewText = t.replaceHTMLTags(t.PreviewText)
+	tpl, err := tpl.Clone()
+	if err != nil {
+		return "", err
 	}
-
-	if err := MessageTpl.Execute(wb, t); err != nil {
+	if err := tpl.Funcs(tplFuncs(mode, t.Location)).Execute(wb, t); err != nil {
 		return "", err
 	}
 
 	return strings.TrimSpace(string(wb.Bytes())), nil
 }
 
+// PlainText 不带格式的消息，用于 telegram 无法解析模版渲染结果时降级发送
+func (t TplData) PlainText() string {
+	lines := []string{t.SourceTitle, t.ContentTitle, t.RawLink}
+	if t.EnableTelegraph {
+		lines = append(lines, t.TelegraphURL)
+	}
+	if t.Tags != "" {
+		lines = append(lines, t.Tags)
+	}
+	var text []string
+	for _, line := range lines {
+		if line = strings.TrimSpace(line); line != "" {
+			text = append(text, line)
+		}
+	}
+	return strings.Join(text, "\n")
+}
+
 func (t TplData) replaceHTMLTags(s string) string {
 
 	rStr := strings.ReplaceAll(s, "&", "&amp;")
@@ -187,44 +271,93 @@ func (t TplData) replaceHTMLTags(s string) string {
 	return rStr
 }
 
-func validateTPL() {
-	testData := []TplData{
-		TplData{
-			"RSS 源标识 - 无预览无telegraph的消息",
-			"这是标题",
-			"https://www.github.com/",
-			"",
-			"",
-			"",
-			false,
+// TplSamples 用于校验消息模版的示例数据
+func TplSamples() []TplData {
+	publishedAt := time.Date(2021, 7, 7, 8, 0, 0, 0, time.UTC)
+	return []TplData{
+		{
+			SourceTitle:  "RSS 源标识 - 无预览无telegraph的消息",
+			ContentTitle: "这是标题",
+			RawLink:      "https://www.github.com/",
+			SourceLink:   "https://www.github.com/",
 		},
-		TplData{
-			"RSS源标识 - 有预览无telegraph的消息",
-			"这是标题",
-			"https://www.github.com/",
-			"这里是很长很长很长的消息预览字数补丁紫薯补丁紫薯补丁紫薯补丁紫薯补丁[1](123)",
-			"",
-			"#标签",
-			false,
+		{
+			SourceTitle:  "RSS源标识 - 有预览无telegraph的消息",
+			ContentTitle: "这是标题",
+			RawLink:      "https://www.github.com/",
+			PreviewText:  "这里是很长很长很长的消息预览字数补丁紫薯补丁紫薯补丁紫薯补丁紫薯补丁[1](123)",
+			Tags:         "#标签",
+			Author:       "作者",
+			PublishedAt:  publishedAt,
+			Categories:   []string{"分类1", "分类 2"},
+			SourceLink:   "https://www.github.com/",
 		},
-		TplData{
-			"RSS源标识 - 有预览有telegraph的消息",
-			"这是标题",
-			"https://www.github.com/",
-			"这里是很长很长很长的消息预览字数补丁紫薯补丁紫薯补丁紫薯补丁紫薯补丁",
-			"https://telegra.ph/markdown-07-07",
-			"#标签1 #标签2",
-			true,
+		{
+			SourceTitle:     "RSS源标识 - 有预览有telegraph的消息",
+			ContentTitle:    "这是标题",
+			RawLink:         "https://www.github.com/",
+			PreviewText:     "这里是很长很长很长的消息预览字数补丁紫薯补丁紫薯补丁紫薯补丁紫薯补丁",
+			TelegraphURL:    "https://telegra.ph/markdown-07-07",
+			Tags:            "#标签1 #标签2",
+			EnableTelegraph: true,
+			Author:          "作者",
+			PublishedAt:     publishedAt,
+			UpdatedAt:       publishedAt.Add(time.Hour),
+			Categories:      []string{"分类1", "分类 2"},
+			CommentsURL:     "https://www.github.com/#comments",
+			EnclosureURL:    "https://www.github.com/podcast.mp3",
+			EnclosureType:   "audio/mpeg",
+			EnclosureSize:   1 << 20,
+			SourceLink:      "https://www.github.com/",
 		},
 	}
+}
 
-	for _, d := range testData {
+// ValidateTemplate 使用示例数据渲染模版，返回渲染结果
+func ValidateTemplate(tpl *template.Template, mode tb.ParseMode) ([]string, error) {
+	var results []string
+	for _, d := range TplSamples() {
+		msg, err := d.RenderTemplate(tpl, mode)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, msg)
+	}
+	return results, nil
+}
+
+func validateTPL() {
+	for _, d := range TplSamples() {
 		fmt.Println("\n////////////////////////////////////////////")
 		fmt.Println(d.Render(MessageMode))
 	}
 	fmt.Println("\n////////////////////////////////////////////")
 }
 
+// ParseMessageTpl 解析消息模版
+func ParseMessageTpl(tplMsg string) (*template.Template, error) {
+	tpl, err := template.New("message").Funcs(tplFuncs(tb.ModeDefault, nil)).Parse(tplMsg)
+	if err != nil {
+		return nil, err
+	}
+	escapeOutput(tpl)
+	return tpl, nil
+}
+
+// ParseMessageMode 解析消息渲染模式
+func ParseMessageMode(mode string) tb.ParseMode {
+	switch strings.ToLower(mode) {
+	case "md", "markdown":
+		return tb.ModeMarkdown
+	case "mdv2", "markdownv2":
+		return tb.ModeMarkdownV2
+	case "html":
+		return tb.ModeHTML
+	default:
+		return tb.ModeDefault
+	}
+}
+
 func initTPL() {
 	var tplMsg string
 	if viper.IsSet("message_tpl") {
@@ -232,17 +365,10 @@ func initTPL() {
 	} else {
 		tplMsg = defaultMessageTpl
 	}
-	MessageTpl = template.Must(template.New("message").Parse(tplMsg))
+	MessageTpl = template.Must(ParseMessageTpl(tplMsg))
 
 	if viper.IsSet("message_mode") {
-		switch strings.ToLower(viper.GetString("message_mode")) {
-		case "md", "markdown":
-			MessageMode = tb.ModeMarkdown
-		case "html":
-			MessageMode = tb.ModeHTML
-		default:
-			MessageMode = tb.ModeDefault
-		}
+		MessageMode = ParseMessageMode(viper.GetString("message_mode"))
 	} else {
 		MessageMode = defaultMessageTplMode
 	}
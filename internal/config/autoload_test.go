@@ -79,3 +79,86 @@ func TestTplData_replaceHTMLTags(t1 *testing.T) {
 		})
 	}
 }
+
+func TestTplData_RenderMarkdownV2(t1 *testing.T) {
+	tpl, err := ParseMessageTpl(`*{{.SourceTitle}}*
+[{{.ContentTitle}}]({{link .RawLink}})
+{{.Tags}}`)
+	if err != nil {
+		t1.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		data TplData
+		want string
+	}{
+		{
+			"plain",
+			TplData{SourceTitle: "Blog", ContentTitle: "Hello", RawLink: "https://example.com/"},
+			"*Blog*\n[Hello](https://example.com/)",
+		},
+		{
+			"punctuation",
+			TplData{SourceTitle: "v1.5 released!", ContentTitle: "1 + 1 = 2 - 0", RawLink: "https://example.com/a.b"},
+			"*v1\\.5 released\\!*\n[1 \\+ 1 \\= 2 \\- 0](https://example.com/a.b)",
+		},
+		{
+			"brackets and markup",
+			TplData{SourceTitle: "C++ (beta) [draft]", ContentTitle: "a_b*c~d`e>f|g{h}", RawLink: "https://example.com/"},
+			"*C\\+\\+ \\(beta\\) \\[draft\\]*\n[a\\_b\\*c\\~d\\`e\\>f\\|g\\{h\\}](https://example.com/)",
+		},
+		{
+			"backslash",
+			TplData{SourceTitle: `back\slash`, ContentTitle: "#1", RawLink: "https://example.com/"},
+			"*back\\\\slash*\n[\\#1](https://example.com/)",
+		},
+		{
+			"url",
+			TplData{SourceTitle: "Wiki", ContentTitle: "Go", RawLink: `https://en.wikipedia.org/wiki/Go_(game)\x`},
+			"*Wiki*\n[Go](https://en.wikipedia.org/wiki/Go_(game\\)\\\\x)",
+		},
+		{
+			"tags",
+			TplData{SourceTitle: "Blog", ContentTitle: "Hello", RawLink: "https://example.com/", Tags: "#标签1 #标签_2"},
+			"*Blog*\n[Hello](https://example.com/)\n\\#标签1 \\#标签\\_2",
+		},
+	}
+	for _, tt := range tests {
+		t1.Run(tt.name, func(t1 *testing.T) {
+			got, err := tt.data.RenderTemplate(tpl, telebot.ModeMarkdownV2)
+			assert.Nil(t1, err)
+			assert.Equal(t1, tt.want, got)
+		})
+	}
+}
+
+func TestTplData_PlainText(t1 *testing.T) {
+	t := TplData{
+		SourceTitle:     "<b>Blog</b>",
+		ContentTitle:    "Hello *world*",
+		RawLink:         "https://example.com/",
+		TelegraphURL:    "https://telegra.ph/hello",
+		EnableTelegraph: true,
+		Tags:            "#tag",
+	}
+	want := "<b>Blog</b>\nHello *world*\nhttps://example.com/\nhttps://telegra.ph/hello\n#tag"
+	assert.Equal(t1, want, t.PlainText())
+}
+
+func TestParseMessageMode(t1 *testing.T) {
+	tests := []struct {
+		mode string
+		want telebot.ParseMode
+	}{
+		{"html", telebot.ModeHTML},
+		{"Markdown", telebot.ModeMarkdown},
+		{"MarkdownV2", telebot.ModeMarkdownV2},
+		{"mdv2", telebot.ModeMarkdownV2},
+		{"text", telebot.ModeDefault},
+	}
+	for _, tt := range tests {
+		t1.Run(tt.mode, func(t1 *testing.T) {
+			assert.Equal(t1, tt.want, ParseMessageMode(tt.mode))
+		})
+	}
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"text/template"
+	"time"
 
 	"github.com/spf13/viper"
 	tb "gopkg.in/tucnak/telebot.v2"
@@ -23,6 +24,12 @@ var (
 	TelegraphAuthorName  string = "flowerss-bot"
 	TelegraphAuthorURL   string
 
+	// CallbackSecret 按钮回调数据的签名密钥，为空时由 bot token 派生
+	CallbackSecret string
+
+	// TelegraphFooter telegraph 页面页脚模版，为空时按语言使用默认页脚
+	TelegraphFooter string
+
 	// EnableTelegraph 是否启用telegraph
 	EnableTelegraph       bool = false
 	PreviewText           int  = 0
@@ -31,6 +38,13 @@ var (
 	SQLitePath            string
 	EnableMysql           bool = false
 
+	// EnableReader 是否启用内置阅读页面，启用后代替 telegraph 页面
+	EnableReader bool = false
+	// ReaderListen 阅读页面 HTTP 服务监听地址
+	ReaderListen string = "127.0.0.1:8080"
+	// ReaderBaseURL 阅读页面的公开访问地址
+	ReaderBaseURL string
+
 	// UpdateInterval rss抓取间隔
 	UpdateInterval int = 10
 
@@ -43,6 +57,9 @@ var (
 	// MessageMode telegram消息渲染模式
 	MessageMode tb.ParseMode
 
+	// MessageButtons 推送消息下方的操作按钮
+	MessageButtons []string
+
 	// TelegramEndpoint telegram bot 服务器地址，默认为空
 	TelegramEndpoint string = tb.DefaultApiURL
 
@@ -55,9 +72,31 @@ var (
 	// RunMode 运行模式 Release / Debug
 	RunMode RunType = ReleaseMode
 
-	// AllowUsers 允许使用bot的用户
+	// Language bot 的默认语言，无法确定会话语言时使用
+	Language string = "zh"
+
+	// ConversationStore 会话状态的存储方式，db 或 memory
+	ConversationStore string = "db"
+	// ConversationTimeout 多步操作无响应后超时取消的时间，单位分钟，0 为不超时
+	ConversationTimeout int = 10
+
+	// RemovedChatAction bot 被移出会话或被用户停用后对会话订阅的处理，pause 或 unsub
+	RemovedChatAction string = "pause"
+
+	// AllowUsers 允许使用bot的用户，为空时未被禁止的用户都可以使用
 	AllowUsers []int64
 
+	// Owners bot 所有者的 telegram id
+	Owners []int64
+
+	// Limits 各角色的使用限制，未配置的角色不受限制
+	Limits = map[string]RoleLimit{}
+
+	// CompressContent 是否压缩保存文章内容
+	CompressContent bool = false
+	// MaxContentSize 保存的文章内容的最大字节数，0 为不限制
+	MaxContentSize int = 256 << 10
+
 	// DBLogMode 是否打印数据库日志
 	DBLogMode bool = false
 )
@@ -94,10 +133,23 @@ const (
 {{- end }}
 {{.Tags}}
 `
+
 	TestMode    RunType = "Test"
 	ReleaseMode RunType = "Release"
 )
 
+// RoleLimit 角色的使用限制
+type RoleLimit struct {
+	// MaxSubscriptions 每个会话的最大订阅数，0 为不限制
+	MaxSubscriptions int
+	// MinInterval 订阅的最小抓取间隔，单位分钟
+	MinInterval int
+	// Telegraph 是否可以使用 telegraph
+	Telegraph bool
+	// Download 是否可以使用 put.io 下载
+	Download bool
+}
+
 // MysqlConfig mysql 配置
 type MysqlConfig struct {
 	Host     string
@@ -108,13 +160,26 @@ type MysqlConfig struct {
 }
 
 type TplData struct {
-	SourceTitle     string
-	ContentTitle    string
-	RawLink         string
+	SourceTitle  string
+	ContentTitle string
+	RawLink      string
+	// PreviewText 文章预览，HTML 模式下为 telegram 支持的 HTML，渲染时不再转义
 	PreviewText     string
 	TelegraphURL    string
 	Tags            string
 	EnableTelegraph bool
+	Author          string
+	PublishedAt     time.Time
+	UpdatedAt       time.Time
+	// Categories 条目分类，可配合 hashtag 使用
+	Categories    []string
+	CommentsURL   string
+	EnclosureURL  string
+	EnclosureType string
+	EnclosureSize uint
+	SourceLink    string
+	// Location date 函数使用的时区，为空时使用本地时区
+	Location *time.Location
 }
 
 func AppVersionInfo() (s string) {
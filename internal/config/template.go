@@ -0,0 +1,211 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"time"
+	"unicode"
+
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+var (
+	markdownEscapeRegexp = regexp.MustCompile("(\\[|\\*|\\`|\\_)")
+
+	// markdownV2Escaper MarkdownV2 文本中需要转义的字符
+	markdownV2Escaper = strings.NewReplacer(
+		"\\", "\\\\", "_", "\\_", "*", "\\*", "[", "\\[", "]", "\\]", "(", "\\(", ")", "\\)",
+		"~", "\\~", "`", "\\`", ">", "\\>", "#", "\\#", "+", "\\+", "-", "\\-", "=", "\\=",
+		"|", "\\|", "{", "\\{", "}", "\\}", ".", "\\.", "!", "\\!",
+	)
+	// markdownV2URLEscaper MarkdownV2 链接地址中需要转义的字符
+	markdownV2URLEscaper = strings.NewReplacer("\\", "\\\\", ")", "\\)")
+)
+
+// 模版输出时追加的转义函数
+const (
+	outputText    = "_outputText"
+	outputURL     = "_outputURL"
+	outputPreview = "_outputPreview"
+)
+
+// fieldOutputs 直接输出字段时使用的转义函数，其余输出使用 outputText
+var fieldOutputs = map[string]string{
+	"RawLink":      outputURL,
+	"TelegraphURL": outputURL,
+	"CommentsURL":  outputURL,
+	"EnclosureURL": outputURL,
+	"SourceLink":   outputURL,
+	"PreviewText":  outputPreview,
+}
+
+// safeText 已按渲染模式转义的文本，输出时不再转义
+type safeText string
+
+// tplFuncs 消息模版可用的函数，escape 与 date 依赖渲染模式与时区，渲染时重新绑定
+func tplFuncs(mode tb.ParseMode, loc *time.Location) template.FuncMap {
+	if loc == nil {
+		loc = time.Local
+	}
+	return template.FuncMap{
+		"truncate": truncate,
+		"date": func(layout string, t time.Time) string {
+			if t.IsZero() {
+				return ""
+			}
+			return t.In(loc).Format(layout)
+		},
+		"escape": func(s string) safeText {
+			return safeText(escapeText(s, mode))
+		},
+		"hashtag":  hashtag,
+		"hostname": hostname,
+		"link": func(s string) safeText {
+			return safeText(escapeURL(s, mode))
+		},
+		outputText: func(v interface{}) string {
+			if s, ok := v.(safeText); ok {
+				return string(s)
+			}
+			return escapeText(fmt.Sprint(v), mode)
+		},
+		outputURL: func(s string) string {
+			if mode == tb.ModeMarkdown {
+				// Markdown 模式下链接地址不支持转义，保持原样
+				return s
+			}
+			return escapeText(s, mode)
+		},
+		outputPreview: func(s string) string {
+			if mode == tb.ModeHTML {
+				// HTML 模式下预览为已转换的 telegram HTML
+				return s
+			}
+			return escapeText(s, mode)
+		},
+	}
+}
+
+// escapeOutput 在模版的每个输出末尾追加转义函数，模版中的字段与函数均使用原文，输出时再按渲染模式转义
+func escapeOutput(tpl *template.Template) {
+	for _, t := range tpl.Templates() {
+		if t.Tree != nil {
+			escapeNode(t.Tree, t.Tree.Root)
+		}
+	}
+}
+
+func escapeNode(tree *parse.Tree, node parse.Node) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, c := range n.Nodes {
+			escapeNode(tree, c)
+		}
+	case *parse.IfNode:
+		escapeNode(tree, n.List)
+		escapeNode(tree, n.ElseList)
+	case *parse.RangeNode:
+		escapeNode(tree, n.List)
+		escapeNode(tree, n.ElseList)
+	case *parse.WithNode:
+		escapeNode(tree, n.List)
+		escapeNode(tree, n.ElseList)
+	case *parse.ActionNode:
+		if len(n.Pipe.Decl) > 0 {
+			// 变量声明与赋值不产生输出
+			return
+		}
+		fn := parse.NewIdentifier(outputFunc(n.Pipe)).SetTree(tree).SetPos(n.Pos)
+		n.Pipe.Cmds = append(n.Pipe.Cmds, &parse.CommandNode{
+			NodeType: parse.NodeCommand,
+			Pos:      n.Pos,
+			Args:     []parse.Node{fn},
+		})
+	}
+}
+
+// outputFunc 输出使用的转义函数，链接与预览字段直接输出时单独处理，链接按文本转义，作为链接地址时需使用 link
+func outputFunc(pipe *parse.PipeNode) string {
+	if len(pipe.Cmds) == 1 && len(pipe.Cmds[0].Args) == 1 {
+		if field, ok := pipe.Cmds[0].Args[0].(*parse.FieldNode); ok && len(field.Ident) == 1 {
+			if fn, ok := fieldOutputs[field.Ident[0]]; ok {
+				return fn
+			}
+		}
+	}
+	return outputText
+}
+
+// escapeText 按消息渲染模式转义文本
+func escapeText(s string, mode tb.ParseMode) string {
+	switch mode {
+	case tb.ModeMarkdown:
+		return markdownEscapeRegexp.ReplaceAllString(s, "\\$1")
+	case tb.ModeMarkdownV2:
+		return markdownV2Escaper.Replace(s)
+	case tb.ModeHTML:
+		return TplData{}.replaceHTMLTags(s)
+	}
+	return s
+}
+
+// escapeURL 按消息渲染模式转义链接地址，用于 MarkdownV2 的 (...) 与 HTML 的 href
+func escapeURL(s string, mode tb.ParseMode) string {
+	switch mode {
+	case tb.ModeMarkdownV2:
+		return markdownV2URLEscaper.Replace(s)
+	case tb.ModeHTML:
+		return TplData{}.replaceHTMLTags(s)
+	}
+	return s
+}
+
+// truncate 截取前 n 个字符，超出部分以省略号代替
+func truncate(n int, s string) string {
+	runes := []rune(s)
+	if n < 0 || len(runes) <= n {
+		return s
+	}
+	if n == 0 {
+		return ""
+	}
+	return string(runes[:n-1]) + "…"
+}
+
+// hashtag 将分类转换为 telegram 话题标签，非字母数字的字符替换为下划线
+func hashtag(tags []string) string {
+	var result []string
+	for _, tag := range tags {
+		var b strings.Builder
+		underscore := false
+		for _, r := range strings.TrimSpace(tag) {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				b.WriteRune(r)
+				underscore = false
+			} else if !underscore && b.Len() > 0 {
+				b.WriteRune('_')
+				underscore = true
+			}
+		}
+		if s := strings.TrimRight(b.String(), "_"); s != "" {
+			result = append(result, "#"+s)
+		}
+	}
+	return strings.Join(result, " ")
+}
+
+// hostname 获取链接的域名
+func hostname(link string) string {
+	u, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
@@ -0,0 +1,145 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+func Test_truncate(t *testing.T) {
+	tests := []struct {
+		name string
+		n    int
+		s    string
+		want string
+	}{
+		{"short", 10, "hello", "hello"},
+		{"exact", 5, "hello", "hello"},
+		{"long", 3, "hello", "he…"},
+		{"unicode", 3, "这是很长的标题", "这是…"},
+		{"zero", 0, "hello", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, truncate(tt.n, tt.s))
+		})
+	}
+}
+
+func Test_hashtag(t *testing.T) {
+	tests := []struct {
+		name string
+		tags []string
+		want string
+	}{
+		{"empty", nil, ""},
+		{"words", []string{"Go", "开源"}, "#Go #开源"},
+		{"symbols", []string{"C++ / Rust", " web-dev ", "!!!"}, "#C_Rust #web_dev"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, hashtag(tt.tags))
+		})
+	}
+}
+
+func Test_hostname(t *testing.T) {
+	assert.Equal(t, "www.github.com", hostname("https://www.github.com/indes"))
+	assert.Equal(t, "", hostname("://bad"))
+}
+
+func TestTplData_RenderTemplateFuncs(t *testing.T) {
+	shanghai := time.FixedZone("CST", 8*3600)
+	data := TplData{
+		ContentTitle: "<Title>",
+		Author:       "A&B",
+		PublishedAt:  time.Date(2021, 7, 7, 20, 0, 0, 0, time.UTC),
+		Categories:   []string{"Go", "open source"},
+		SourceLink:   "https://blog.example.com/",
+		Location:     shanghai,
+	}
+	tests := []struct {
+		name string
+		tpl  string
+		mode tb.ParseMode
+		want string
+	}{
+		{"date in location", `{{ .PublishedAt | date "2006-01-02 15:04" }}`, tb.ModeHTML, "2021-07-08 04:00"},
+		{"zero date", `{{ .UpdatedAt | date "2006-01-02" }}`, tb.ModeHTML, ""},
+		{"escaped fields", `{{ .ContentTitle }} by {{ .Author }}`, tb.ModeHTML, "&lt;Title&gt; by A&amp;B"},
+		{"escape func", `{{ escape "<b>" }}`, tb.ModeHTML, "&lt;b&gt;"},
+		{"escape markdown", `{{ escape "a_b" }}`, tb.ModeMarkdown, "a\\_b"},
+		{"hashtag", `{{ hashtag .Categories }}`, tb.ModeHTML, "#Go #open_source"},
+		{"hashtag markdownv2", `{{ hashtag .Categories }}`, tb.ModeMarkdownV2, "\\#Go \\#open\\_source"},
+		{"hostname", `{{ hostname .SourceLink }}`, tb.ModeHTML, "blog.example.com"},
+		{"truncate", `{{ truncate 4 .Author }}`, tb.ModeDefault, "A&B"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tpl, err := ParseMessageTpl(tt.tpl)
+			if err != nil {
+				t.Fatalf("ParseMessageTpl() error = %v", err)
+			}
+			got, err := data.RenderTemplate(tpl, tt.mode)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestTplData_RenderTemplateRawText(t *testing.T) {
+	data := TplData{ContentTitle: "a & b.c.d"}
+	tests := []struct {
+		name string
+		tpl  string
+		mode tb.ParseMode
+		want string
+	}{
+		{"truncate html", `{{ truncate 6 .ContentTitle }}`, tb.ModeHTML, "a &amp; b…"},
+		{"truncate markdownv2", `{{ truncate 8 .ContentTitle }}`, tb.ModeMarkdownV2, "a & b\\.c…"},
+		{"escape html", `{{ escape .ContentTitle }}`, tb.ModeHTML, "a &amp; b.c.d"},
+		{"escape markdownv2", `{{ escape .ContentTitle }}`, tb.ModeMarkdownV2, "a & b\\.c\\.d"},
+		{"pipeline markdownv2", `{{ .ContentTitle | truncate 6 }}`, tb.ModeMarkdownV2, "a & b…"},
+		{"variable html", `{{ $t := .ContentTitle }}{{ $t }}`, tb.ModeHTML, "a &amp; b.c.d"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tpl, err := ParseMessageTpl(tt.tpl)
+			if err != nil {
+				t.Fatalf("ParseMessageTpl() error = %v", err)
+			}
+			got, err := data.RenderTemplate(tpl, tt.mode)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestTplData_RenderTemplateURL(t *testing.T) {
+	data := TplData{ContentTitle: "Title", RawLink: "https://example.com/a-b_(1)?x=1&y=2"}
+	tests := []struct {
+		name string
+		tpl  string
+		mode tb.ParseMode
+		want string
+	}{
+		{"bare url markdownv2", `{{ .RawLink }}`, tb.ModeMarkdownV2, "https://example\\.com/a\\-b\\_\\(1\\)?x\\=1&y\\=2"},
+		{"link markdownv2", `[{{ .ContentTitle }}]({{ link .RawLink }})`, tb.ModeMarkdownV2, "[Title](https://example.com/a-b_(1\\)?x=1&y=2)"},
+		{"bare url html", `{{ .RawLink }}`, tb.ModeHTML, "https://example.com/a-b_(1)?x=1&amp;y=2"},
+		{"link html", `<a href="{{ link .RawLink }}">{{ .ContentTitle }}</a>`, tb.ModeHTML, `<a href="https://example.com/a-b_(1)?x=1&amp;y=2">Title</a>`},
+		{"link markdown", `[{{ .ContentTitle }}]({{ .RawLink }})`, tb.ModeMarkdown, "[Title](https://example.com/a-b_(1)?x=1&y=2)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tpl, err := ParseMessageTpl(tt.tpl)
+			if err != nil {
+				t.Fatalf("ParseMessageTpl() error = %v", err)
+			}
+			got, err := data.RenderTemplate(tpl, tt.mode)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,305 @@
+package i18n
+
+var en = map[string]string{
+	"language.name": "English",
+
+	"cmd.start":           "Get started",
+	"cmd.list":            "List subscribed feeds",
+	"cmd.share":           "Share subscription links",
+	"cmd.chats":           "List chats you manage",
+	"cmd.sub":             "[url] Subscribe to a feed (url is optional)",
+	"cmd.unsub":           "[url] Unsubscribe from a feed (url is optional)",
+	"cmd.unsub_all":       "Unsubscribe from all feeds",
+	"cmd.set":             "Configure a subscription",
+	"cmd.set_feed_tag":    "[sub id] [tag1] [tag2] Set subscription tags (up to three, separated by spaces)",
+	"cmd.set_interval":    "[interval] [sub id] Set the fetch interval (several sub ids can be given, separated by spaces)",
+	"cmd.set_token":       "[token] Set the Put.io token",
+	"cmd.set_template":    "[sub id] [mode] Set the message template of the chat or a subscription (template on the following lines)",
+	"cmd.reset_template":  "[sub id] Restore the default message template",
+	"cmd.set_timezone":    "[timezone] Set the timezone used for dates in message templates",
+	"cmd.set_telegraph":   "[author name] [author url] Set the author of Telegraph pages",
+	"cmd.reset_telegraph": "Use the global Telegraph account again",
+	"cmd.language":        "[zh|en] Set the bot language",
+	"cmd.add_keyword":     "[keyword] Add a download filter keyword",
+	"cmd.remove_keyword":  "Remove a download filter keyword",
+	"cmd.download":        "Reply to a pushed message to start downloading",
+	"cmd.export":          "Export subscriptions as an OPML file",
+	"cmd.import":          "Import subscriptions from an OPML file",
+	"cmd.check":           "Check the status of subscriptions",
+	"cmd.pause_all":       "Pause fetching all subscriptions",
+	"cmd.active_all":      "Resume fetching all subscriptions",
+	"cmd.cancel":          "Cancel the current operation",
+	"cmd.help":            "Help",
+	"cmd.version":         "Bot version",
+
+	"help": `
+Commands:
+/sub Subscribe to a feed
+/unsub Unsubscribe from a feed
+/list List subscriptions
+/set Configure a subscription
+/check Check subscriptions
+/set_feed_tag Set subscription tags
+/set_interval Set the fetch interval
+/set_token Set the Put.io token
+/set_template Set the message template
+/reset_template Restore the default message template
+/set_timezone Set the timezone used for dates in message templates
+/set_telegraph Set the author of Telegraph pages
+/reset_telegraph Use the global Telegraph account again
+/language Set the bot language
+/add_keyword Add a download keyword
+/remove_keyword Remove a download keyword
+/download Start a download task
+/active_all Resume all subscriptions
+/pause_all Pause all subscriptions
+/help Help
+/import Import an OPML file
+/export Export an OPML file
+/unsub_all Unsubscribe from all feeds
+Documentation: https://flowerss-bot.vercel.app/#/usage
+`,
+
+	"btn.prev":     "Previous",
+	"btn.next":     "Next",
+	"btn.cancel":   "Cancel",
+	"btn.confirm":  "Confirm",
+	"btn.back":     "Back",
+	"btn.settings": "Settings",
+
+	"chat.channel": "Channel ",
+	"chat.group":   "Group ",
+
+	"processing":      "Processing...",
+	"processing_wait": "Processing, please wait...",
+
+	"err.no_permission":         "You are not allowed to do this",
+	"err.chat_not_found":        "The chat does not exist",
+	"err.not_group_admin":       "Only group admins can do this",
+	"err.not_channel_admin":     "Only channel admins can do this",
+	"err.bot_not_channel_admin": "Please add the bot as a channel admin",
+	"err.render_news":           "Failed to render the message template",
+	"err.not_subscribed":        "Not subscribed to this feed",
+	"err.subscribe_not_found":   "Subscription not found",
+	"err.content_not_found":     "Item not found",
+	"err.callback_data":         "Internal error: invalid callback data",
+	"err.callback_signature":    "This button is no longer valid, please send the command again",
+	"err.user_not_found":        "Internal error: user not found",
+	"err.list_subs":             "Internal error: failed to query subscriptions",
+	"err.source_not_found":      "Feed not found, error code 01.",
+	"err.user_not_subscribed":   "Not subscribed to this feed, error code 02.",
+	"err.system":                "System error, code 04",
+	"err.invalid_sub_id":        "Please enter a valid subscription ID!",
+	"err.invalid_command":       "Please choose a valid command!",
+
+	"start.welcome": "Hello, welcome to flowerss.",
+
+	"sub.reply_url":     "Please reply with the RSS URL",
+	"sub.channel_usage": "To subscribe for a channel use ' /sub @ChannelID URL '",
+	"sub.invalid_url":   "Please reply with a valid URL",
+	"sub.failed":        "Subscription failed: %s",
+	"sub.success":       "Subscribed to <a href=\"%s\">%s</a>",
+
+	"export.failed": "Export failed",
+
+	"list.current": "",
+	"list.empty":   "No subscriptions",
+	"list.title":   "Subscriptions:\n",
+
+	"check.error_list": "Failed subscriptions:\n",
+	"check.all_ok":     "All subscriptions are working",
+
+	"set.choose":          "Choose the feed to configure",
+	"set.tag_usage":       "Use `/set_feed_tag %d tags` to set tags for this subscription, separated by spaces (up to three tags)\nFor example: `/set_feed_tag %d tech apple`",
+	"set.success":         "Updated",
+	"set.need_token":      "Please set the Put.io token with /set_token first",
+	"set.need_token_md":   "Please set the Put.io token with `/set_token` first",
+	"set.enable_dl":       "Enable download",
+	"set.disable_dl":      "Disable download",
+	"set.enable_notice":   "Enable notification",
+	"set.disable_notice":  "Disable notification",
+	"set.enable_tg":       "Enable Telegraph",
+	"set.disable_tg":      "Disable Telegraph",
+	"set.pause_update":    "Pause updates",
+	"set.resume_update":   "Resume updates",
+	"set.enable_filter":   "Enable download filter",
+	"set.disable_filter":  "Disable download filter",
+	"set.enable_media":    "Enable media",
+	"set.disable_media":   "Disable media",
+	"set.enable_full":     "Enable full text",
+	"set.disable_full":    "Disable full text",
+	"set.enable_thread":   "Push to this topic",
+	"set.disable_thread":  "Push to General",
+	"set.thread_required": "Run /set inside a forum topic to choose the topic",
+	"set.save_default":    "Save as default",
+	"set.template": `
+Subscription <b>settings</b>
+[id] {{ .sub.ID }}
+[Title] <a href="{{.source.Link }}">{{ .source.Title }}</a>
+[Updates] {{if ge .source.ErrorCount .Count }}Paused{{else if lt .source.ErrorCount .Count }}Active{{end}}
+[Interval] {{ .sub.Interval }} min
+[Notification] {{if eq .sub.EnableNotification 0}}Off{{else if eq .sub.EnableNotification 1}}On{{end}}
+[Download] {{if eq .sub.EnableDownload 0}}Off{{else if eq .sub.EnableDownload 1}}On{{end}}{{if eq .sub.EnableFilter 0}} (unfiltered){{else if eq .sub.EnableFilter 1}} (filtered){{end}}
+[Telegraph] {{if eq .sub.EnableTelegraph 0}}Off{{else if eq .sub.EnableTelegraph 1}}On{{end}}
+[Media] {{if eq .sub.EnableMedia 0}}Off{{else if eq .sub.EnableMedia 1}}On{{end}}
+[Full text] {{if eq .sub.EnableFullText 0}}Off{{else if eq .sub.EnableFullText 1}}On{{end}}
+[Tag] {{if .sub.Tag}}{{ .sub.Tag }}{{else}}None{{end}}
+[Template] {{if .sub.MessageTpl}}Custom{{else}}Default{{end}}
+[Topic] {{if .sub.ThreadID}}{{ .sub.ThreadID }}{{else}}General{{end}}
+`,
+
+	"unsub.failed":        "Unsubscribe failed: %s",
+	"unsub.success":       "Unsubscribed from <a href=\"%s\">%s</a>!",
+	"unsub.success_short": "Unsubscribed from <a href=\"%s\">%s</a>",
+	"unsub.choose":        "Choose the feed to unsubscribe from",
+
+	"unsub_all.current":  "the current chat",
+	"unsub_all.confirm":  "Unsubscribe %s from all feeds?",
+	"unsub_all.failed":   "Unsubscribe failed",
+	"unsub_all.result":   "Unsubscribed: %d\nFailed: %d",
+	"cancel.btn_done":    "Operation cancelled.",
+	"cancel.done":        "The current operation has been cancelled.",
+	"cancel.timeout":     "The operation timed out and has been cancelled.",
+	"import.usage":       "Please send the OPML file directly.\nTo import OPML for a channel, add the channel id as the caption, e.g. @telegram\n",
+	"active_all.success": "%sAll subscriptions resumed",
+	"pause_all.success":  "%sAll subscriptions paused",
+
+	"tag.usage":   "/set_feed_tag [sub id] [tag1] [tag2] Set subscription tags (up to three, separated by spaces)",
+	"tag.failed":  "Failed to set subscription tags!",
+	"tag.success": "Subscription tags updated!",
+
+	"token.usage":       "/set_token [token] Set the Put.io token",
+	"token.invalid":     "Invalid token",
+	"token.save_failed": "%sFailed to save the token",
+	"token.success":     "%sSaved the token of %s",
+
+	"interval.usage":   "/set_interval [interval] [sub id] Set the fetch interval (several sub ids can be given, separated by spaces)",
+	"interval.invalid": "Please enter a valid fetch interval",
+	"interval.result":  "Fetch interval updated: %d succeeded, %d failed, %d invalid!",
+
+	"tpl.usage": "/set_template [sub id] [html|markdown|markdownv2|text] with the template on the following lines, sets the message template of the chat or a subscription\n" +
+		"Without sub id the template applies to the whole chat, /reset_template [sub id] restores the default template",
+	"tpl.parse_failed":   "Failed to parse the template: %s",
+	"tpl.render_failed":  "Failed to render the template: %s",
+	"tpl.preview_failed": "Failed to preview the template: %s",
+	"tpl.save_failed":    "Failed to save the message template!",
+	"tpl.sub_success":    "Message template of subscription %d updated! The preview is above",
+	"tpl.success":        "Message template updated! The preview is above",
+	"tpl.reset_failed":   "Failed to reset the message template!",
+	"tpl.reset_success":  "%sMessage template restored to default",
+
+	"tz.default": "default",
+	"tz.usage": "Current timezone: %s\n/set_timezone [timezone] sets the timezone used for dates in message templates, e.g. Asia/Shanghai, " +
+		"use default to restore the default timezone",
+	"tz.invalid": "Please enter a valid timezone, e.g. Asia/Shanghai or UTC",
+	"tz.failed":  "Failed to set the timezone!",
+	"tz.success": "%sTimezone updated!",
+
+	"tg.disabled":      "Telegraph is not enabled",
+	"tg.global":        "Using the global Telegraph account",
+	"tg.current":       "Current Telegraph author: %s %s",
+	"tg.usage":         "\n/set_telegraph [author name] [author url] sets the author of Telegraph pages, /reset_telegraph uses the global account again",
+	"tg.create_failed": "Failed to create the Telegraph account: %s",
+	"tg.edit_failed":   "Failed to update the Telegraph account: %s",
+	"tg.save_failed":   "Failed to save the Telegraph account!",
+	"tg.success":       "%sTelegraph author updated, new pages will be signed as %s",
+	"tg.delete_failed": "Failed to delete the Telegraph account!",
+	"tg.reset_success": "%sUsing the global Telegraph account again",
+	"telegraph.next":   "Next page »",
+	"telegraph.raw":    "Original: ",
+	"telegraph.footer": `<hr><p>This article was fetched from RSS by <a href="https://github.com/indes/flowerss-bot">flowerss</a>, all rights reserved by {{if .SourceLink}}<a href="{{.SourceLink}}">{{.SourceTitle}}</a>{{else}}{{.SourceTitle}}{{end}}.</p>
+<p>Original: <a href="{{.RawLink}}">{{.ContentTitle}} - {{.SourceTitle}}</a></p>`,
+
+	"language.current": "%sCurrent language: %s\n/language [zh|en] sets the bot language, use default to follow the language of the Telegram client",
+	"language.auto":    "follow the client",
+	"language.invalid": "Unsupported language, available: %s",
+	"language.success": "%sLanguage set to %s",
+	"language.reset":   "%sLanguage now follows the Telegram client",
+	"language.failed":  "Failed to set the language!",
+
+	"kw.empty":       "The keyword cannot be empty",
+	"kw.add_success": "Added keyword <code>%s</code>",
+	"kw.add_failed":  "Failed to add keyword <code>%s</code>",
+	"kw.choose":      "Choose the keyword to remove",
+
+	"news.download":     "Download to Put.io",
+	"news.mute":         "Mute for 24 hours",
+	"news.unsub":        "Unsubscribe",
+	"news.save":         "Save",
+	"news.no_download":  "This message has nothing to download",
+	"news.duplicate":    "The download task already exists",
+	"news.downloaded":   "Download added",
+	"news.mute_failed":  "Failed to mute",
+	"news.muted":        "Muted for 24 hours",
+	"news.unsubscribed": "Unsubscribed",
+	"news.save_failed":  "Failed to save, please start a private chat with the bot and send /start first",
+	"news.saved":        "Saved to the private chat with the bot",
+
+	"download.reply":     "Please reply to a message pushed by this bot",
+	"download.duplicate": "The download task already exists, please create it on <a href=\"https://app.put.io/\">Put.io</a> yourself",
+	"download.success":   "Download task added",
+	"download.failed":    "Failed to add the download task",
+	"download.found":     "Found %d links, added %d download tasks",
+
+	"opml.fetch_failed": "Failed to download the OPML file, please check that the bot server can reach the Telegram server or try again later. Error code 02",
+	"opml.invalid":      "To import subscriptions, please send a valid OPML file. Error code 01, doc mimetype: %s",
+	"opml.result":       "<b>Imported: %d, failed: %d</b>",
+	"opml.success_list": "\n\n<b>Imported feeds:</b>",
+	"opml.fail_list":    "\n\n<b>Failed feeds:</b>",
+
+	"source.error": "<a href=\"%s\">%s</a> failed to update %d times in a row, updates are paused",
+
+	"role.grant_usage":  "Usage: /grant <user id> [admin|user|banned], or reply to the user's message with /grant [role]. The role defaults to user",
+	"role.revoke_usage": "Usage: /revoke <user id>, or reply to the user's message with /revoke",
+	"role.invalid":      "Invalid role, available roles: admin, user, banned",
+	"role.denied":       "You are not allowed to change this user's role",
+	"role.granted":      "Set the role of user %d to %s",
+	"role.revoked":      "Revoked the role of user %d",
+	"role.failed":       "Failed to change the role, please try again later",
+
+	"limit.subscriptions": "Subscription limit reached (%d), please unsubscribe from other feeds first",
+	"limit.interval":      "The update interval cannot be less than %d minutes",
+	"limit.telegraph":     "You are not allowed to use Telegraph",
+	"limit.download":      "You are not allowed to use downloads",
+
+	"admin.stats":            "<b>Statistics</b>\nUsers: %d\nChats with subscriptions: %d\nFeeds: %d (%d failing, %d paused)\nSubscriptions: %d\nDeliveries in the last 24 hours: %d sent, %d given up\nMessages since startup: %d sent, %d retried, %d dropped",
+	"admin.query_failed":     "Query failed: %v",
+	"admin.empty":            "\nNone",
+	"admin.sources_title":    "<b>Feeds (page %d)</b>",
+	"admin.source_line":      "\n[%d] <a href=\"%s\">%s</a> %d subscribers",
+	"admin.source_error":     " ⚠️ failed %d times in a row",
+	"admin.users_title":      "<b>Users (page %d)</b>",
+	"admin.user_line":        "\n<code>%d</code> role %s, %d subscriptions",
+	"admin.announce_usage":   "Usage: /announce <text> (may span multiple lines), or reply to a message with /announce",
+	"admin.announce_started": "Sending the announcement to %d chats…",
+	"admin.announce_result":  "Announcement finished: %d sent, %d failed",
+	"admin.announce_more":    "\n…and %d more failed chats",
+
+	"invite.usage":     "Usage: /invite [max uses] [valid days], defaults to 1 use and 7 days, 0 means unlimited",
+	"invite.created":   "Invite code: <code>%s</code>\nUses: %s\nExpires at: %s\nInvite link: %s",
+	"invite.unlimited": "unlimited",
+	"invite.never":     "never",
+	"invite.failed":    "Failed to create the invite code: %v",
+	"invite.success":   "Invite code accepted, you can use the bot now.",
+	"invite.not_found": "Invite code not found",
+	"invite.expired":   "The invite code has expired",
+	"invite.used_up":   "The invite code has reached its usage limit",
+
+	"deeplink.preview":    "Subscribe to <a href=\"%s\">%s</a>?",
+	"deeplink.site":       "\nWebsite: %s",
+	"deeplink.subscribed": "Already subscribed to <a href=\"%s\">%s</a>",
+	"deeplink.not_found":  "The subscription link is invalid or the feed is unavailable",
+	"share.title":         "<b>Subscription links</b>\n",
+	"share.item":          "\n[%d] <a href=\"%s\">%s</a>\n%s\n",
+	"share.empty":         "No subscriptions to share",
+
+	"chat.migrated": "The group was upgraded to a supergroup, %d subscriptions have been migrated",
+	"chat.restored": "Resumed %d paused subscriptions",
+
+	"set.default_saved":  "Saved as the default settings for new subscriptions in this chat",
+	"chats.title":        "<b>Chats you manage</b>\n",
+	"chats.item":         "\n%s <code>%d</code> subscriptions: %d",
+	"chats.private":      "Private chat",
+	"chats.empty":        "You don't manage any chats",
+	"chats.private_only": "Use this command in a private chat with the bot",
+}
@@ -0,0 +1,64 @@
+// Package i18n bot 消息的多语言支持
+package i18n
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/indes/flowerss-bot/internal/config"
+)
+
+const (
+	// Chinese 中文
+	Chinese = "zh"
+	// English 英文
+	English = "en"
+)
+
+// catalogs 各语言的消息目录
+var catalogs = map[string]map[string]string{
+	Chinese: zh,
+	English: en,
+}
+
+// Languages 支持的语言
+func Languages() []string {
+	return []string{Chinese, English}
+}
+
+// Parse 解析 telegram 的 language_code 等语言标识，如 en-US，不支持的语言返回空字符串
+func Parse(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	if i := strings.IndexAny(code, "-_"); i >= 0 {
+		code = code[:i]
+	}
+	if _, ok := catalogs[code]; ok {
+		return code
+	}
+	return ""
+}
+
+// Default 配置的默认语言，配置有误时使用中文
+func Default() string {
+	if lang := Parse(config.Language); lang != "" {
+		return lang
+	}
+	return Chinese
+}
+
+// T 获取 key 在 lang 下的文本，args 不为空时按 fmt.Sprintf 格式化，
+// 未翻译的 key 依次使用默认语言与中文的文本
+func T(lang string, key string, args ...interface{}) string {
+	text, ok := catalogs[lang][key]
+	if !ok {
+		if text, ok = catalogs[Default()][key]; !ok {
+			if text, ok = zh[key]; !ok {
+				text = key
+			}
+		}
+	}
+	if len(args) == 0 {
+		return text
+	}
+	return fmt.Sprintf(text, args...)
+}
@@ -0,0 +1,82 @@
+package i18n
+
+import (
+	"regexp"
+	"testing"
+)
+
+var verbRegexp = regexp.MustCompile(`%[dsvq]`)
+
+func TestCatalogs(t *testing.T) {
+	for lang, catalog := range catalogs {
+		for key, text := range zh {
+			translated, ok := catalog[key]
+			if !ok {
+				t.Errorf("%s: missing key %q", lang, key)
+				continue
+			}
+			want := verbRegexp.FindAllString(text, -1)
+			got := verbRegexp.FindAllString(translated, -1)
+			if len(got) != len(want) {
+				t.Errorf("%s: key %q has verbs %v, want %v", lang, key, got, want)
+				continue
+			}
+			for i := range want {
+				if got[i] != want[i] {
+					t.Errorf("%s: key %q has verbs %v, want %v", lang, key, got, want)
+					break
+				}
+			}
+		}
+		for key := range catalog {
+			if _, ok := zh[key]; !ok {
+				t.Errorf("%s: unknown key %q", lang, key)
+			}
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		code string
+		want string
+	}{
+		{"en", English},
+		{"en-US", English},
+		{"zh-hans", Chinese},
+		{"ZH_TW", Chinese},
+		{" en ", English},
+		{"fr", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			if got := Parse(tt.code); got != tt.want {
+				t.Errorf("Parse(%q) = %q, want %q", tt.code, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestT(t *testing.T) {
+	tests := []struct {
+		name string
+		lang string
+		key  string
+		args []interface{}
+		want string
+	}{
+		{"english", English, "btn.next", nil, "Next"},
+		{"chinese", Chinese, "btn.next", nil, "下一页"},
+		{"format", English, "download.found", []interface{}{3, 2}, "Found 3 links, added 2 download tasks"},
+		{"unknown language", "fr", "btn.next", nil, "下一页"},
+		{"unknown key", English, "no.such.key", nil, "no.such.key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := T(tt.lang, tt.key, tt.args...); got != tt.want {
+				t.Errorf("T() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,306 @@
+package i18n
+
+var zh = map[string]string{
+	"language.name": "中文",
+
+	"cmd.start":           "开始使用",
+	"cmd.list":            "查看当前订阅的RSS源",
+	"cmd.share":           "生成订阅分享链接",
+	"cmd.chats":           "查看管理的会话",
+	"cmd.sub":             "[url] 订阅RSS源 (url 为可选)",
+	"cmd.unsub":           "[url] 退订RSS源 (url 为可选)",
+	"cmd.unsub_all":       "退订所有rss源",
+	"cmd.set":             "对RSS订阅进行设置",
+	"cmd.set_feed_tag":    "[sub id] [tag1] [tag2] 设置RSS订阅的标签 (最多设置三个tag，以空格分隔)",
+	"cmd.set_interval":    "[interval] [sub id] 设置RSS订阅的抓取间隔 (可同时对多个sub id进行设置，以空格分隔)",
+	"cmd.set_token":       "[token] 设置Put.io的token",
+	"cmd.set_template":    "[sub id] [mode] 设置会话或订阅的消息模版 (换行后附上模版内容)",
+	"cmd.reset_template":  "[sub id] 恢复默认消息模版",
+	"cmd.set_timezone":    "[时区] 设置消息模版中日期使用的时区",
+	"cmd.set_telegraph":   "[作者名称] [作者链接] 设置 Telegraph 页面的作者",
+	"cmd.reset_telegraph": "恢复使用全局 Telegraph 账号",
+	"cmd.language":        "[zh|en] 设置 bot 使用的语言",
+	"cmd.add_keyword":     "[keyword] 添加下载过滤关键词",
+	"cmd.remove_keyword":  "移除下载过滤关键词",
+	"cmd.download":        "回复推送的消息以开始下载",
+	"cmd.export":          "导出订阅为OPML文件",
+	"cmd.import":          "从OPML文件导入订阅",
+	"cmd.check":           "检查RSS订阅的当前状态",
+	"cmd.pause_all":       "停止抓取订阅更新",
+	"cmd.active_all":      "开启抓取订阅更新",
+	"cmd.cancel":          "取消当前操作",
+	"cmd.help":            "使用帮助",
+	"cmd.version":         "bot版本",
+
+	"help": `
+命令：
+/sub 订阅源
+/unsub  取消订阅
+/list 查看当前订阅源
+/set 设置订阅
+/check 检查当前订阅
+/set_feed_tag 设置订阅标签
+/set_interval 设置订阅刷新频率
+/set_token 设置Put.io的token
+/set_template 设置消息模版
+/reset_template 恢复默认消息模版
+/set_timezone 设置消息模版中日期使用的时区
+/set_telegraph 设置 Telegraph 页面的作者
+/reset_telegraph 恢复使用全局 Telegraph 账号
+/language 设置 bot 使用的语言
+/add_keyword 添加需下载的关键词
+/remove_keyword 移除需下载的关键词
+/download 开启特定下载任务
+/active_all 开启所有订阅
+/pause_all 暂停所有订阅
+/help 帮助
+/import 导入 OPML 文件
+/export 导出 OPML 文件
+/unsub_all 取消所有订阅
+详细使用方法请看：https://flowerss-bot.vercel.app/#/usage
+`,
+
+	"btn.prev":     "上一页",
+	"btn.next":     "下一页",
+	"btn.cancel":   "取消",
+	"btn.confirm":  "确认",
+	"btn.back":     "返回",
+	"btn.settings": "设置",
+
+	"chat.channel": "频道 ",
+	"chat.group":   "群组 ",
+
+	"processing":      "处理中...",
+	"processing_wait": "处理中，请稍候...",
+
+	"err.no_permission":         "无权进行此项操作",
+	"err.chat_not_found":        "指定的会话不存在",
+	"err.not_group_admin":       "仅群组管理员可执行此项操作",
+	"err.not_channel_admin":     "仅频道管理员可执行此项操作",
+	"err.bot_not_channel_admin": "请将 bot 添加为频道的管理员",
+	"err.render_news":           "消息模版渲染失败",
+	"err.not_subscribed":        "未订阅该RSS源",
+	"err.subscribe_not_found":   "未找到该条订阅",
+	"err.content_not_found":     "未找到该条内容",
+	"err.callback_data":         "内部错误：回调数据不正确",
+	"err.callback_signature":    "按钮已失效，请重新发送命令",
+	"err.user_not_found":        "内部错误：无法找到对应的用户",
+	"err.list_subs":             "内部错误：无法查询用户订阅列表",
+	"err.source_not_found":      "找不到该订阅源，错误代码01。",
+	"err.user_not_subscribed":   "用户未订阅该rss，错误代码02。",
+	"err.system":                "系统错误，代码04",
+	"err.invalid_sub_id":        "请输入正确的订阅ID！",
+	"err.invalid_command":       "请选择正确的指令！",
+
+	"start.welcome": "你好，欢迎使用flowerss。",
+
+	"sub.reply_url":     "请回复RSS URL",
+	"sub.channel_usage": "频道订阅请使用' /sub @ChannelID URL ' 命令",
+	"sub.invalid_url":   "请回复正确的URL",
+	"sub.failed":        "订阅失败：%s",
+	"sub.success":       "订阅 <a href=\"%s\">%s</a> 成功",
+
+	"export.failed": "导出失败",
+
+	"list.current": "当前",
+	"list.empty":   "订阅列表为空",
+	"list.title":   "订阅列表：\n",
+
+	"check.error_list": "失效订阅的列表：\n",
+	"check.all_ok":     "所有订阅正常",
+
+	"set.choose":          "请选择你要设置的源",
+	"set.tag_usage":       "请使用`/set_feed_tag %d tags`命令为该订阅设置标签，tags为需要设置的标签，以空格分隔。（最多设置三个标签） \n例如：`/set_feed_tag %d 科技 苹果`",
+	"set.success":         "修改成功",
+	"set.need_token":      "请先通过 /set_token 设置Put.io的token",
+	"set.need_token_md":   "请先通过 `/set_token` 设置Put.io的token",
+	"set.enable_dl":       "开启下载",
+	"set.disable_dl":      "关闭下载",
+	"set.enable_notice":   "开启通知",
+	"set.disable_notice":  "关闭通知",
+	"set.enable_tg":       "开启 Telegraph 转码",
+	"set.disable_tg":      "关闭 Telegraph 转码",
+	"set.pause_update":    "暂停更新",
+	"set.resume_update":   "重启更新",
+	"set.enable_filter":   "开启下载过滤",
+	"set.disable_filter":  "关闭下载过滤",
+	"set.enable_media":    "开启媒体推送",
+	"set.disable_media":   "关闭媒体推送",
+	"set.enable_full":     "开启全文抓取",
+	"set.disable_full":    "关闭全文抓取",
+	"set.enable_thread":   "推送到当前话题",
+	"set.disable_thread":  "推送到默认话题",
+	"set.thread_required": "请在论坛话题中使用 /set 选择推送的话题",
+	"set.save_default":    "设为默认设置",
+	"set.template": `
+订阅<b>设置</b>
+[id] {{ .sub.ID }}
+[标题] <a href="{{.source.Link }}">{{ .source.Title }}</a>
+[抓取更新] {{if ge .source.ErrorCount .Count }}暂停{{else if lt .source.ErrorCount .Count }}抓取中{{end}}
+[抓取频率] {{ .sub.Interval }}分钟
+[通知] {{if eq .sub.EnableNotification 0}}关闭{{else if eq .sub.EnableNotification 1}}开启{{end}}
+[下载任务] {{if eq .sub.EnableDownload 0}}关闭{{else if eq .sub.EnableDownload 1}}开启{{end}}{{if eq .sub.EnableFilter 0}}（未过滤）{{else if eq .sub.EnableFilter 1}}（已过滤）{{end}}
+[Telegraph] {{if eq .sub.EnableTelegraph 0}}关闭{{else if eq .sub.EnableTelegraph 1}}开启{{end}}
+[媒体] {{if eq .sub.EnableMedia 0}}关闭{{else if eq .sub.EnableMedia 1}}开启{{end}}
+[全文] {{if eq .sub.EnableFullText 0}}关闭{{else if eq .sub.EnableFullText 1}}开启{{end}}
+[Tag] {{if .sub.Tag}}{{ .sub.Tag }}{{else}}无{{end}}
+[模版] {{if .sub.MessageTpl}}自定义{{else}}默认{{end}}
+[话题] {{if .sub.ThreadID}}{{ .sub.ThreadID }}{{else}}默认{{end}}
+`,
+
+	"unsub.failed":        "退订失败：%s",
+	"unsub.success":       "退订 <a href=\"%s\">%s</a> 成功！",
+	"unsub.success_short": "<a href=\"%s\">%s</a> 退订成功",
+	"unsub.choose":        "请选择你要退订的源",
+
+	"unsub_all.current":  "当前用户",
+	"unsub_all.confirm":  "是否退订%s的所有订阅？",
+	"unsub_all.failed":   "退订失败",
+	"unsub_all.result":   "退订成功：%d\n退订失败：%d",
+	"cancel.btn_done":    "操作已取消。",
+	"cancel.done":        "当前操作已取消。",
+	"cancel.timeout":     "操作超时，已自动取消。",
+	"import.usage":       "请直接发送OPML文件，\n如果需要为channel导入OPML，请在发送文件的时候附上channel id，例如@telegram\n",
+	"active_all.success": "%s订阅已全部开启",
+	"pause_all.success":  "%s订阅已全部暂停",
+
+	"tag.usage":   "/set_feed_tag [sub id] [tag1] [tag2] 设置订阅标签（最多设置三个Tag，以空格分割）",
+	"tag.failed":  "订阅标签设置失败！",
+	"tag.success": "订阅标签设置成功！",
+
+	"token.usage":       "/set_token [token] 设置Put.io的token",
+	"token.invalid":     "无效的token",
+	"token.save_failed": "%s保存token失败",
+	"token.success":     "%s成功保存了 %s 的 token",
+
+	"interval.usage":   "/set_interval [interval] [sub id] 设置订阅刷新频率（可设置多个sub id，以空格分割）",
+	"interval.invalid": "请输入正确的抓取频率",
+	"interval.result":  "抓取频率设置成功%d个，失败%d个，错误%d个！",
+
+	"tpl.usage": "/set_template [sub id] [html|markdown|markdownv2|text] 换行后附上模版内容，设置会话或订阅的消息模版\n" +
+		"不指定 sub id 时设置整个会话的模版，/reset_template [sub id] 恢复默认模版",
+	"tpl.parse_failed":   "模版解析失败：%s",
+	"tpl.render_failed":  "模版渲染失败：%s",
+	"tpl.preview_failed": "模版预览失败：%s",
+	"tpl.save_failed":    "消息模版保存失败！",
+	"tpl.sub_success":    "订阅 %d 的消息模版设置成功！以上为模版预览",
+	"tpl.success":        "消息模版设置成功！以上为模版预览",
+	"tpl.reset_failed":   "消息模版重置失败！",
+	"tpl.reset_success":  "%s消息模版已恢复默认",
+
+	"tz.default": "默认",
+	"tz.usage": "当前时区：%s\n/set_timezone [时区] 设置消息模版中日期使用的时区，例如 Asia/Shanghai，" +
+		"设置为 default 时使用默认时区",
+	"tz.invalid": "请输入正确的时区，例如 Asia/Shanghai、UTC",
+	"tz.failed":  "时区设置失败！",
+	"tz.success": "%s时区设置成功！",
+
+	"tg.disabled":      "Telegraph 未启用",
+	"tg.global":        "当前使用全局 Telegraph 账号",
+	"tg.current":       "当前 Telegraph 作者：%s %s",
+	"tg.usage":         "\n/set_telegraph [作者名称] [作者链接] 设置 Telegraph 页面的作者，/reset_telegraph 恢复使用全局账号",
+	"tg.create_failed": "Telegraph 账号创建失败：%s",
+	"tg.edit_failed":   "Telegraph 账号修改失败：%s",
+	"tg.save_failed":   "Telegraph 账号保存失败！",
+	"tg.success":       "%sTelegraph 作者设置成功，之后的页面将署名为 %s",
+	"tg.delete_failed": "Telegraph 账号删除失败！",
+	"tg.reset_success": "%s已恢复使用全局 Telegraph 账号",
+	"telegraph.next":   "下一页 »",
+	"telegraph.raw":    "查看原文：",
+	"telegraph.footer": `<hr><p>本文章由 <a href="https://github.com/indes/flowerss-bot">flowerss</a> 抓取自 RSS，版权归
+{{- if .SourceLink}}<a href="{{.SourceLink}}">{{.SourceTitle}}</a>{{else}}{{.SourceTitle}}{{end}}所有。</p>
+<p>查看原文：<a href="{{.RawLink}}">{{.ContentTitle}} - {{.SourceTitle}}</a></p>`,
+
+	"language.current": "%s当前语言：%s\n/language [zh|en] 设置 bot 使用的语言，设置为 default 时跟随 Telegram 客户端的语言",
+	"language.auto":    "跟随客户端",
+	"language.invalid": "不支持的语言，可选：%s",
+	"language.success": "%s语言已设置为 %s",
+	"language.reset":   "%s语言已设置为跟随 Telegram 客户端",
+	"language.failed":  "语言设置失败！",
+
+	"kw.empty":       "关键词不能为空",
+	"kw.add_success": "添加关键词 <code>%s</code> 成功",
+	"kw.add_failed":  "添加关键词 <code>%s</code> 失败",
+	"kw.choose":      "请选择你要删除的关键词",
+
+	"news.download":     "下载到 Put.io",
+	"news.mute":         "静音 24 小时",
+	"news.unsub":        "退订",
+	"news.save":         "收藏",
+	"news.no_download":  "该消息未包含可下载的内容",
+	"news.duplicate":    "无法重复添加同一下载任务",
+	"news.downloaded":   "已添加下载",
+	"news.mute_failed":  "静音失败",
+	"news.muted":        "已静音 24 小时",
+	"news.unsubscribed": "已退订",
+	"news.save_failed":  "收藏失败，请先私聊 bot 并发送 /start",
+	"news.saved":        "已收藏到与 bot 的私聊中",
+
+	"download.reply":     "请回复本 bot 推送的消息",
+	"download.duplicate": "无法重复添加同一下载任务，请前往<a href=\"https://app.put.io/\">Put.io</a>自行新建任务",
+	"download.success":   "成功添加下载任务",
+	"download.failed":    "添加下载任务失败",
+	"download.found":     "发现%d条链接，成功添加%d个下载任务",
+
+	"opml.fetch_failed": "下载 OPML 文件失败，请检查 bot 服务器能否正常连接至 Telegram 服务器或稍后尝试导入。错误代码 02",
+	"opml.invalid":      "如果需要导入订阅，请发送正确的 OPML 文件。错误代码 01，doc mimetype: %s",
+	"opml.result":       "<b>导入成功：%d，导入失败：%d</b>",
+	"opml.success_list": "\n\n<b>以下订阅源导入成功:</b>",
+	"opml.fail_list":    "\n\n<b>以下订阅源导入失败:</b>",
+
+	"source.error": "<a href=\"%s\">%s</a> 已经累计连续%d次更新失败，暂时停止更新",
+
+	"role.grant_usage":  "用法：/grant <用户 id> [admin|user|banned]，或回复该用户的消息发送 /grant [角色]，角色默认为 user",
+	"role.revoke_usage": "用法：/revoke <用户 id>，或回复该用户的消息发送 /revoke",
+	"role.invalid":      "角色不正确，可选 admin、user、banned",
+	"role.denied":       "无权修改该用户的角色",
+	"role.granted":      "已将用户 %d 的角色设置为 %s",
+	"role.revoked":      "已撤销用户 %d 的角色",
+	"role.failed":       "修改角色失败，请稍后重试",
+
+	"limit.subscriptions": "订阅数已达上限（%d 个），请先退订其他订阅",
+	"limit.interval":      "抓取间隔不能小于 %d 分钟",
+	"limit.telegraph":     "当前用户不能使用 Telegraph",
+	"limit.download":      "当前用户不能使用下载功能",
+
+	"admin.stats":            "<b>统计</b>\n用户：%d\n有订阅的会话：%d\n订阅源：%d（更新出错 %d，已停止更新 %d）\n订阅：%d\n24 小时内推送：成功 %d，放弃 %d\n本次启动后发送消息：%d，重试 %d，丢弃 %d",
+	"admin.query_failed":     "查询失败：%v",
+	"admin.empty":            "\n无",
+	"admin.sources_title":    "<b>订阅源（第 %d 页）</b>",
+	"admin.source_line":      "\n[%d] <a href=\"%s\">%s</a> 订阅 %d",
+	"admin.source_error":     " ⚠️ 连续出错 %d 次",
+	"admin.users_title":      "<b>用户（第 %d 页）</b>",
+	"admin.user_line":        "\n<code>%d</code> 角色 %s 订阅 %d",
+	"admin.announce_usage":   "用法：/announce 公告内容（可换行），或回复一条消息发送 /announce",
+	"admin.announce_started": "正在向 %d 个会话发送公告…",
+	"admin.announce_result":  "公告发送完成：成功 %d，失败 %d",
+	"admin.announce_more":    "\n…另有 %d 个会话发送失败",
+
+	"invite.usage":     "用法：/invite [可用次数] [有效天数]，默认 1 次、7 天，0 为不限制",
+	"invite.created":   "邀请码：<code>%s</code>\n可用次数：%s\n有效期至：%s\n邀请链接：%s",
+	"invite.unlimited": "不限",
+	"invite.never":     "永久有效",
+	"invite.failed":    "创建邀请码失败：%v",
+	"invite.success":   "邀请码使用成功，现在可以使用 bot 了。",
+	"invite.not_found": "邀请码不存在",
+	"invite.expired":   "邀请码已过期",
+	"invite.used_up":   "邀请码已达到使用次数上限",
+
+	"deeplink.preview":    "订阅 <a href=\"%s\">%s</a>？",
+	"deeplink.site":       "\n网站：%s",
+	"deeplink.subscribed": "已订阅 <a href=\"%s\">%s</a>",
+	"deeplink.not_found":  "订阅链接无效或订阅源无法访问",
+	"share.title":         "<b>订阅分享链接</b>\n",
+	"share.item":          "\n[%d] <a href=\"%s\">%s</a>\n%s\n",
+	"share.empty":         "没有可以分享的订阅",
+
+	"chat.migrated": "群组已升级为超级群组，%d 个订阅已迁移",
+	"chat.restored": "已恢复 %d 个暂停的订阅",
+
+	"set.default_saved":  "已保存为该会话新订阅的默认设置",
+	"chats.title":        "<b>管理的会话</b>\n",
+	"chats.item":         "\n%s <code>%d</code> 订阅数 %d",
+	"chats.private":      "私聊",
+	"chats.empty":        "没有管理的会话",
+	"chats.private_only": "请在与 bot 的私聊中使用该命令",
+}
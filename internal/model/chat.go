@@ -0,0 +1,219 @@
+package model
+
+import (
+	"errors"
+	"strconv"
+
+	"gorm.io/gorm"
+)
+
+// ErrChatNotFound 会话不存在
+var ErrChatNotFound = errors.New("会话不存在")
+
+// Chat 会话，可以是私聊、群组或频道，ID 为 telegram 会话 id
+type Chat struct {
+	ID       int64 `gorm:"primaryKey;autoIncrement:false"`
+	Type     string
+	Title    string
+	Username string
+	// Language 会话设置的语言，为空时跟随 telegram 客户端
+	Language string
+	// Timezone 消息模版中日期使用的时区，为空时使用 bot 所在时区
+	Timezone string
+	// OwnerID 将 bot 加入会话或首次管理会话的用户
+	OwnerID int64 `gorm:"index"`
+
+	// 新订阅的默认设置
+	DefaultNotification int
+	DefaultTelegraph    int
+	DefaultMedia        int
+	DefaultFullText     int
+	// DefaultInterval 新订阅的抓取间隔，0 为使用全局设置
+	DefaultInterval int
+	EditTime
+}
+
+// newChat 新会话，默认设置与以往新订阅的设置一致
+func newChat(id int64) *Chat {
+	chat := &Chat{
+		ID:                  id,
+		DefaultNotification: 1,
+		DefaultTelegraph:    1,
+		DefaultMedia:        1,
+	}
+	if id > 0 {
+		chat.Type = "private"
+		chat.OwnerID = id
+	}
+	return chat
+}
+
+// FindOrCreateChat 获取会话，不存在时创建
+func FindOrCreateChat(id int64) (*Chat, error) {
+	chat := newChat(id)
+	err := db.Where("id = ?", id).FirstOrCreate(chat).Error
+	return chat, err
+}
+
+// GetChatByID 获取会话
+func GetChatByID(id int64) (*Chat, error) {
+	var chat Chat
+	result := db.Where("id = ?", id).Limit(1).Find(&chat)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrChatNotFound
+	}
+	return &chat, nil
+}
+
+// GetUserChats 获取与用户关联的会话：用户本人与以用户为所有者的会话
+func GetUserChats(userID int64) ([]Chat, error) {
+	var chats []Chat
+	err := db.Where("id = ? or owner_id = ?", userID, userID).Order("id desc").Find(&chats).Error
+	return chats, err
+}
+
+// GetUnresolvedChats 获取尚未保存类型的会话，由旧数据迁移生成
+func GetUnresolvedChats() ([]Chat, error) {
+	var chats []Chat
+	err := db.Where("type = ?", "").Order("id").Find(&chats).Error
+	return chats, err
+}
+
+// SaveChatInfo 保存会话的类型、标题与用户名，会话尚无所有者时以 ownerID 为所有者
+func SaveChatInfo(id int64, chatType, title, username string, ownerID int64) error {
+	chat, err := FindOrCreateChat(id)
+	if err != nil {
+		return err
+	}
+	updates := map[string]interface{}{}
+	if chat.Type != chatType {
+		updates["type"] = chatType
+	}
+	if chat.Title != title {
+		updates["title"] = title
+	}
+	if chat.Username != username {
+		updates["username"] = username
+	}
+	if chat.OwnerID == 0 && ownerID != 0 {
+		updates["owner_id"] = ownerID
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+	return db.Model(chat).Updates(updates).Error
+}
+
+// SaveChatDefaults 将订阅的设置保存为会话新订阅的默认设置
+func SaveChatDefaults(id int64, sub *Subscribe) error {
+	chat, err := FindOrCreateChat(id)
+	if err != nil {
+		return err
+	}
+	return db.Model(chat).Updates(map[string]interface{}{
+		"default_notification": sub.EnableNotification,
+		"default_telegraph":    sub.EnableTelegraph,
+		"default_media":        sub.EnableMedia,
+		"default_full_text":    sub.EnableFullText,
+		"default_interval":     sub.Interval,
+	}).Error
+}
+
+// applyDefaults 使用会话的默认设置初始化新订阅
+func (c *Chat) applyDefaults(s *Subscribe) {
+	s.EnableNotification = c.DefaultNotification
+	s.EnableTelegraph = c.DefaultTelegraph
+	s.EnableMedia = c.DefaultMedia
+	s.EnableFullText = c.DefaultFullText
+	if c.DefaultInterval > 0 {
+		s.Interval = c.DefaultInterval
+		s.WaitTime = c.DefaultInterval
+	}
+}
+
+// migrateChats 由已有的用户与订阅记录生成会话记录，
+// 群组与频道的类型与所有者在 bot 启动后向 telegram 查询补全
+func migrateChats() {
+	var count int64
+	if err := db.Model(&Chat{}).Count(&count).Error; err != nil || count > 0 {
+		return
+	}
+
+	var users []User
+	db.Find(&users)
+	var chats []*Chat
+	seen := make(map[int64]bool)
+	for _, user := range users {
+		if seen[user.TelegramID] {
+			continue
+		}
+		seen[user.TelegramID] = true
+		chats = append(chats, newChat(user.TelegramID))
+	}
+	var userIDs []int64
+	db.Model(&Subscribe{}).Distinct("user_id").Pluck("user_id", &userIDs)
+	for _, userID := range userIDs {
+		if !seen[userID] {
+			seen[userID] = true
+			chats = append(chats, newChat(userID))
+		}
+	}
+	if len(chats) > 0 {
+		db.CreateInBatches(chats, 100)
+	}
+}
+
+// chatColumns 以会话 id 关联会话的表及列
+var chatColumns = []struct {
+	model  interface{}
+	column string
+}{
+	{&Subscribe{}, "user_id"},
+	{&Keyword{}, "user_id"},
+	{&Delivery{}, "user_id"},
+	{&Publication{}, "user_id"},
+	{&TelegraphAccount{}, "user_id"},
+	{&ConversationState{}, "chat_id"},
+}
+
+// MigrateChat 群组升级为超级群组后会话 id 改变，将旧会话 id 的所有记录改为新会话 id
+func MigrateChat(from, to int64) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		// 新会话已产生的记录以旧会话为准
+		if err := tx.Where("telegram_id = ?", to).Delete(&User{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", to).Delete(&TelegraphAccount{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("chat_id = ?", to).Delete(&ConversationState{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("id = ?", to).Delete(&Chat{}).Error; err != nil {
+			return err
+		}
+
+		err := tx.Model(&User{}).Where("telegram_id = ?", from).Update("telegram_id", to).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Model(&Chat{}).Where("id = ?", from).Updates(map[string]interface{}{
+			"id":   to,
+			"type": "supergroup",
+		}).Error
+		if err != nil {
+			return err
+		}
+		for _, c := range chatColumns {
+			err := tx.Model(c.model).Where(c.column+" = ?", from).Update(c.column, to).Error
+			if err != nil {
+				return err
+			}
+		}
+		return tx.Model(&History{}).Where("target_id = ?", strconv.FormatInt(from, 10)).
+			Update("target_id", strconv.FormatInt(to, 10)).Error
+	})
+}
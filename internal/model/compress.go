@@ -0,0 +1,73 @@
+package model
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"io/ioutil"
+	"strings"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// compressedPrefix 压缩后的内容前缀
+	compressedPrefix = "gz:"
+	// minCompressSize 小于该长度的内容不压缩
+	minCompressSize = 1 << 10
+)
+
+// truncateBytes 截取前 n 个字节，不截断 UTF-8 字符，n 小于等于 0 时不截取
+func truncateBytes(s string, n int) string {
+	if n <= 0 || len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// packText 截取并按需压缩保存的内容，压缩结果以 base64 编码以兼容文本字段
+func packText(s string, maxSize int, compress bool) string {
+	s = truncateBytes(s, maxSize)
+	if !compress || len(s) < minCompressSize || strings.HasPrefix(s, compressedPrefix) {
+		return s
+	}
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write([]byte(s)); err != nil {
+		return s
+	}
+	if err := w.Close(); err != nil {
+		return s
+	}
+	packed := compressedPrefix + base64.StdEncoding.EncodeToString(buf.Bytes())
+	if len(packed) >= len(s) {
+		return s
+	}
+	return packed
+}
+
+// unpackText 还原 packText 压缩的内容，未压缩的内容原样返回
+func unpackText(s string) string {
+	if !strings.HasPrefix(s, compressedPrefix) {
+		return s
+	}
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(s, compressedPrefix))
+	if err != nil {
+		// 内容恰好以前缀开头
+		return s
+	}
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return s
+	}
+	text, err := ioutil.ReadAll(r)
+	if err != nil {
+		zap.S().Warnw("decompress content failed", "error", err)
+		return s
+	}
+	return string(text)
+}
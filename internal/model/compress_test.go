@@ -0,0 +1,57 @@
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_truncateBytes(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		n    int
+		want string
+	}{
+		{"no limit", "hello", 0, "hello"},
+		{"short", "hello", 10, "hello"},
+		{"ascii", "hello", 3, "hel"},
+		{"utf8 boundary", "你好", 4, "你"},
+		{"utf8 exact", "你好", 3, "你"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := truncateBytes(tt.s, tt.n); got != tt.want {
+				t.Errorf("truncateBytes() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_packText(t *testing.T) {
+	long := strings.Repeat("<p>hello world</p>", 100)
+	tests := []struct {
+		name       string
+		s          string
+		maxSize    int
+		compress   bool
+		compressed bool
+		want       string
+	}{
+		{"disabled", long, 0, false, false, long},
+		{"compressed", long, 0, true, true, long},
+		{"too short", "<p>hi</p>", 0, true, false, "<p>hi</p>"},
+		{"capped", long, 18, false, false, "<p>hello world</p>"},
+		{"prefix kept", "gz:not base64", 0, true, false, "gz:not base64"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			packed := packText(tt.s, tt.maxSize, tt.compress)
+			if got := strings.HasPrefix(packed, compressedPrefix) && packed != tt.s; got != tt.compressed {
+				t.Errorf("packText() compressed = %v, want %v", got, tt.compressed)
+			}
+			if got := unpackText(packed); got != tt.want {
+				t.Errorf("unpackText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
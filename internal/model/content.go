@@ -4,20 +4,34 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"net/url"
 	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/indes/flowerss-bot/internal/config"
-	"github.com/indes/flowerss-bot/internal/tgraph"
+	"github.com/indes/flowerss-bot/internal/provider/fetcher"
 	"github.com/indes/flowerss-bot/internal/util"
 
 	"github.com/SlyMarbo/rss"
 	parser "github.com/j-muller/go-torrent-parser"
+	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
+const (
+	// maxPageSize 抓取原文时读取的最大字节数
+	maxPageSize = 5 << 20
+	// fullTextWorkers 抓取新内容原文的并发数
+	fullTextWorkers = 4
+)
+
+// fullTextLocks 按内容加锁，避免多个会话同时推送时重复抓取同一原文
+var fullTextLocks sync.Map
+
 // Content fetcher content
 type Content struct {
 	SourceID     uint
@@ -26,11 +40,49 @@ type Content struct {
 	RawLink      string
 	TorrentUrl   string
 	Title        string
-	Description  string `gorm:"-"` //ignore to db
+	Description  string
 	TelegraphURL string
+	Author       string
+	PublishedAt  *time.Time
+	ModifiedAt   *time.Time
+	// Categories 条目分类，以换行分隔
+	Categories  string
+	CommentsURL string
+	// FullText 从原文页面抽取的正文
+	FullText string
+	// FullTextAt 抓取原文的时间，为空时尚未抓取
+	FullTextAt *time.Time
+	Enclosures []Enclosure `gorm:"foreignKey:ContentHashID;references:HashID"`
 	EditTime
 }
 
+// BeforeSave 截取并按配置压缩文章内容
+func (c *Content) BeforeSave(tx *gorm.DB) error {
+	c.Description = packText(c.Description, config.MaxContentSize, config.CompressContent)
+	c.FullText = packText(c.FullText, config.MaxContentSize, config.CompressContent)
+	return nil
+}
+
+// AfterSave 保存后还原文章内容
+func (c *Content) AfterSave(tx *gorm.DB) error {
+	return c.AfterFind(tx)
+}
+
+// AfterFind 解压文章内容
+func (c *Content) AfterFind(tx *gorm.DB) error {
+	c.Description = unpackText(c.Description)
+	c.FullText = unpackText(c.FullText)
+	return nil
+}
+
+// CategoryList 条目分类列表
+func (c *Content) CategoryList() []string {
+	if c.Categories == "" {
+		return nil
+	}
+	return strings.Split(c.Categories, "\n")
+}
+
 func (c *Content) GetTriggerId() string {
 	if c.TorrentUrl != "" {
 		return c.RawID
@@ -40,20 +92,134 @@ func (c *Content) GetTriggerId() string {
 	return c.HashID
 }
 
-func (c *Content) Publish(source *Source) {
-	if c.TelegraphURL != "" {
+// Body 文章内容，已抓取原文时返回原文正文
+func (c *Content) Body() string {
+	if c.FullText != "" {
+		return c.FullText
+	}
+	return c.Description
+}
+
+// HasArticle 内容超出预览长度，需要生成文章页面
+func (c *Content) HasArticle() bool {
+	body := c.Body()
+	return body != "" && len([]rune(body)) > config.PreviewText
+}
+
+// shouldPublishTelegraph 是否生成 telegraph 页面，启用阅读页面时不再生成
+func shouldPublishTelegraph() bool {
+	return config.EnableTelegraph && !config.EnableReader
+}
+
+// NeedPublish 内容超出预览长度且尚未生成 telegraph 页面
+func (c *Content) NeedPublish() bool {
+	return c.TelegraphURL == "" && c.HasArticle()
+}
+
+// fetchFullText 下载原文页面并抽取正文，失败时也记录抓取时间，避免重复抓取
+func (c *Content) fetchFullText() {
+	now := time.Now()
+	c.FullTextAt = &now
+	if c.RawLink == "" || c.TorrentUrl != "" {
 		return
 	}
-	if c.Description == "" || len([]rune(c.Description)) <= config.PreviewText {
+
+	resp, err := fetchPage(c.RawLink)
+	if err != nil {
+		zap.S().Warnw("fetch full text failed", "link", c.RawLink, "error", err)
 		return
 	}
-	urlStr, err := tgraph.PublishHtml(source.Title, c.Title, c.RawLink, c.Description)
-	if err == nil {
-		c.TelegraphURL = urlStr
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxPageSize))
+	if err != nil {
+		zap.S().Warnw("fetch full text failed", "link", c.RawLink, "error", err)
+		return
+	}
+	article, err := fetcher.ExtractArticle(data, resp.Header.Get("Content-Type"), resp.Request.URL.String())
+	if err != nil {
+		zap.S().Debugw("extract full text failed", "link", c.RawLink, "error", err)
+		return
 	}
+	c.FullText = article.Content
 }
 
-func getContentByFeedItem(source *Source, item *rss.Item) (Content, error) {
+// fetchFullTexts 并发抓取一批新内容的原文正文
+func fetchFullTexts(contents []*Content) {
+	ch := make(chan *Content)
+	var wg sync.WaitGroup
+	for i := 0; i < fullTextWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for c := range ch {
+				c.fetchFullText()
+			}
+		}()
+	}
+	for _, c := range contents {
+		ch <- c
+	}
+	close(ch)
+	wg.Wait()
+}
+
+// LoadFullText 抓取原文正文并保存，已抓取过时直接返回；
+// 原文超出预览长度时为其排队生成 telegraph 页面
+func (c *Content) LoadFullText() error {
+	if c.FullTextAt != nil {
+		return nil
+	}
+	lock, _ := fullTextLocks.LoadOrStore(c.HashID, &sync.Mutex{})
+	lock.(*sync.Mutex).Lock()
+	defer func() {
+		fullTextLocks.Delete(c.HashID)
+		lock.(*sync.Mutex).Unlock()
+	}()
+
+	// 其他会话可能已抓取并保存
+	var stored Content
+	if err := db.Select("full_text", "full_text_at").Where("hash_id = ?", c.HashID).First(&stored).Error; err == nil &&
+		stored.FullTextAt != nil {
+		c.FullText, c.FullTextAt = stored.FullText, stored.FullTextAt
+		return nil
+	}
+
+	c.fetchFullText()
+	if err := db.Model(c).Select("full_text", "full_text_at").Updates(c).Error; err != nil {
+		return err
+	}
+	if c.FullText != "" && shouldPublishTelegraph() && c.NeedPublish() {
+		return EnqueuePublications([]*Content{c})
+	}
+	return nil
+}
+
+// fetchPage 下载网页，只接受 HTML 内容
+func fetchPage(link string) (*http.Response, error) {
+	req, err := http.NewRequest("GET", link, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", config.UserAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+
+	resp, err := util.HttpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	if contentType := resp.Header.Get("Content-Type"); contentType != "" && !strings.Contains(contentType, "html") {
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("unexpected content type %s", contentType)
+	}
+	return resp, nil
+}
+
+func getContentByFeedItem(source *Source, item *rss.Item, meta *fetcher.ItemMeta) (Content, error) {
 	html := item.Content
 	if html == "" {
 		html = item.Summary
@@ -69,7 +235,24 @@ func getContentByFeedItem(source *Source, item *rss.Item) (Content, error) {
 		RawID:       item.ID,
 		HashID:      genHashID(source.Link, item.ID),
 		RawLink:     item.Link,
+		Author:      meta.Author,
+		CommentsURL: meta.Comments,
+	}
+	if item.DateValid && !item.Date.IsZero() {
+		publishedAt := item.Date
+		c.PublishedAt = &publishedAt
+	}
+	if !meta.Updated.IsZero() {
+		modifiedAt := meta.Updated
+		c.ModifiedAt = &modifiedAt
 	}
+	var categories []string
+	for _, category := range item.Categories {
+		if category = strings.TrimSpace(category); category != "" {
+			categories = append(categories, strings.ReplaceAll(category, "\n", " "))
+		}
+	}
+	c.Categories = strings.Join(categories, "\n")
 
 	if strings.HasPrefix(c.RawLink, util.PrefixInstantView) {
 		u, err := url.Parse(c.RawLink)
@@ -88,9 +271,17 @@ func getContentByFeedItem(source *Source, item *rss.Item) (Content, error) {
 
 	var torrentUrl string
 	for _, enclosure := range item.Enclosures {
-		if enclosure.Type == util.ContentTypeTorrent {
+		if enclosure == nil || enclosure.URL == "" {
+			continue
+		}
+		c.Enclosures = append(c.Enclosures, Enclosure{
+			ContentHashID: c.HashID,
+			URL:           enclosure.URL,
+			Type:          enclosure.Type,
+			Length:        enclosure.Length,
+		})
+		if torrentUrl == "" && enclosure.Type == util.ContentTypeTorrent {
 			torrentUrl = enclosure.URL
-			break
 		}
 	}
 	if torrentUrl == "" && strings.HasSuffix(c.RawLink, ".torrent") {
@@ -125,7 +316,7 @@ func getContentByFeedItem(source *Source, item *rss.Item) (Content, error) {
 }
 
 // GenContentAndCheckByFeedItem generate content by fetcher item
-func GenContentAndCheckByFeedItem(s *Source, item *rss.Item) (*Content, bool, error) {
+func GenContentAndCheckByFeedItem(s *Source, item *rss.Item, meta *fetcher.ItemMeta) (*Content, bool, error) {
 	var (
 		content   Content
 		isBroaded bool
@@ -135,7 +326,7 @@ func GenContentAndCheckByFeedItem(s *Source, item *rss.Item) (*Content, bool, er
 	err := db.Where("hash_id=?", hashID).First(&content).Error
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		isBroaded = false
-		content, _ = getContentByFeedItem(s, item)
+		content, _ = getContentByFeedItem(s, item, meta)
 	} else {
 		isBroaded = true
 	}
@@ -143,6 +334,15 @@ func GenContentAndCheckByFeedItem(s *Source, item *rss.Item) (*Content, bool, er
 	return &content, isBroaded, nil
 }
 
+// GetContentByHashID get content by hash id
+func GetContentByHashID(hashID string) (*Content, error) {
+	var content Content
+	if err := db.Preload("Enclosures").Where("hash_id=?", hashID).First(&content).Error; err != nil {
+		return nil, err
+	}
+	return &content, nil
+}
+
 func GetContentByRawLink(rawLink string) (content *Content) {
 	condition := &Content{RawLink: rawLink}
 	db.Where(condition).First(&content)
@@ -0,0 +1,68 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ConversationState 会话所处的多步操作状态
+type ConversationState struct {
+	ID       uint  `gorm:"primary_key;AUTO_INCREMENT"`
+	ChatID   int64 `gorm:"uniqueIndex"`
+	Status   int
+	Payload  string
+	ExpireAt time.Time `gorm:"index"`
+	EditTime
+}
+
+// GetConversationState 获取会话的状态，不存在时返回 nil
+func GetConversationState(chatID int64) (*ConversationState, error) {
+	var state ConversationState
+	// 每条文本消息都会查询，使用 Find 避免记录不存在时打印日志
+	result := db.Where("chat_id = ?", chatID).Limit(1).Find(&state)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+	return &state, nil
+}
+
+// SaveConversationState 保存会话的状态，会话已有状态时覆盖
+func SaveConversationState(state *ConversationState) error {
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "chat_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"status", "payload", "expire_at", "updated_at"}),
+	}).Create(state).Error
+}
+
+// DeleteConversationState 清除会话的状态
+func DeleteConversationState(chatID int64) error {
+	return db.Where("chat_id = ?", chatID).Delete(&ConversationState{}).Error
+}
+
+// PopExpiredConversationStates 删除并返回在 now 之前过期的状态，未设置过期时间的状态不会过期
+func PopExpiredConversationStates(now time.Time) ([]*ConversationState, error) {
+	var states []*ConversationState
+	err := db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("expire_at > ? and expire_at < ?", time.Time{}, now).Find(&states).Error
+		if err != nil {
+			return err
+		}
+		if len(states) == 0 {
+			return nil
+		}
+		ids := make([]uint, 0, len(states))
+		for _, state := range states {
+			ids = append(ids, state.ID)
+		}
+		return tx.Where("id in ?", ids).Delete(&ConversationState{}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return states, nil
+}
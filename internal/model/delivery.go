@@ -0,0 +1,133 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DeliveryStatus 投递状态
+type DeliveryStatus int
+
+const (
+	// DeliveryPending 等待发送
+	DeliveryPending DeliveryStatus = iota
+	// DeliverySent 已确认发送
+	DeliverySent
+	// DeliveryDead 多次重试失败或无法恢复的错误，不再重试
+	DeliveryDead
+)
+
+const (
+	maxDeliveryAttempts = 8
+	deliveryBaseBackoff = 30 * time.Second
+	deliveryMaxBackoff  = 6 * time.Hour
+	maxDeliveryError    = 255
+)
+
+// Delivery 待推送给订阅者的一条内容
+type Delivery struct {
+	ID            uint           `gorm:"primary_key;AUTO_INCREMENT"`
+	UserID        int64          `gorm:"index"`
+	SubscribeID   uint           `gorm:"index"`
+	SourceID      uint           `gorm:"index"`
+	ContentHashID string         `gorm:"index"`
+	Status        DeliveryStatus `gorm:"index"`
+	Attempts      int
+	NextAttemptAt time.Time `gorm:"index"`
+	LastError     string
+	// MessageID 已发送的文本消息 id，用于补充 telegraph 链接
+	MessageID int
+	EditTime
+}
+
+// enqueueDeliveries 在事务 tx 中为每个订阅者创建待投递记录
+func enqueueDeliveries(tx *gorm.DB, subs []*Subscribe, contents []*Content) error {
+	var deliveries []Delivery
+	now := time.Now()
+	for _, content := range contents {
+		for _, sub := range subs {
+			deliveries = append(deliveries, Delivery{
+				UserID:        sub.UserID,
+				SubscribeID:   sub.ID,
+				SourceID:      sub.SourceID,
+				ContentHashID: content.HashID,
+				Status:        DeliveryPending,
+				NextAttemptAt: now,
+			})
+		}
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return tx.CreateInBatches(&deliveries, 100).Error
+}
+
+// GetDueDeliveries 获取已到发送时间的待投递记录，按入队顺序排列，跳过 excludeUserIDs 中的会话
+func GetDueDeliveries(limit int, excludeUserIDs []int64) ([]*Delivery, error) {
+	var deliveries []*Delivery
+	query := db.Where("status = ? and next_attempt_at <= ?", DeliveryPending, time.Now())
+	if len(excludeUserIDs) > 0 {
+		query = query.Where("user_id not in ?", excludeUserIDs)
+	}
+	err := query.Order("id").Limit(limit).Find(&deliveries).Error
+	return deliveries, err
+}
+
+// GetDueDeliveriesByUserID 获取会话已到发送时间的待投递记录，按入队顺序排列
+func GetDueDeliveriesByUserID(userID int64, limit int) ([]*Delivery, error) {
+	var deliveries []*Delivery
+	err := db.Where("user_id = ? and status = ? and next_attempt_at <= ?", userID, DeliveryPending, time.Now()).
+		Order("id").Limit(limit).Find(&deliveries).Error
+	return deliveries, err
+}
+
+// PruneDeliveries 删除 before 之前已结束的投递记录
+func PruneDeliveries(before time.Time) error {
+	return db.Where("status <> ? and updated_at < ?", DeliveryPending, before).Delete(&Delivery{}).Error
+}
+
+// GetSentDeliveriesByContent 获取内容已发送且记录了消息 id 的投递记录
+func GetSentDeliveriesByContent(hashID string) ([]*Delivery, error) {
+	var deliveries []*Delivery
+	err := db.Where("content_hash_id = ? and status = ? and message_id <> 0", hashID, DeliverySent).
+		Order("id").Find(&deliveries).Error
+	return deliveries, err
+}
+
+// MarkSent 标记为已发送，messageID 为 0 时表示消息不可编辑
+func (d *Delivery) MarkSent(messageID int) error {
+	d.Status = DeliverySent
+	d.MessageID = messageID
+	d.LastError = ""
+	return db.Save(d).Error
+}
+
+// MarkFailed 记录一次失败，按指数退避安排重试，超过重试次数或 permanent 时标记为死信
+func (d *Delivery) MarkFailed(err error, permanent bool) error {
+	d.Attempts++
+	if err != nil {
+		d.LastError = err.Error()
+		if len(d.LastError) > maxDeliveryError {
+			d.LastError = d.LastError[:maxDeliveryError]
+		}
+	}
+	if permanent || d.Attempts >= maxDeliveryAttempts {
+		d.Status = DeliveryDead
+	} else {
+		d.NextAttemptAt = time.Now().Add(deliveryBackoff(d.Attempts))
+	}
+	return db.Save(d).Error
+}
+
+// deliveryBackoff 第 attempts 次失败后的等待时长
+func deliveryBackoff(attempts int) time.Duration {
+	backoff := deliveryBaseBackoff
+	for i := 1; i < attempts; i++ {
+		backoff *= 2
+		if backoff >= deliveryMaxBackoff {
+			return deliveryMaxBackoff
+		}
+	}
+	return backoff
+}
@@ -0,0 +1,26 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_deliveryBackoff(t *testing.T) {
+	tests := []struct {
+		name     string
+		attempts int
+		want     time.Duration
+	}{
+		{"first", 1, deliveryBaseBackoff},
+		{"second", 2, 2 * deliveryBaseBackoff},
+		{"third", 3, 4 * deliveryBaseBackoff},
+		{"capped", 20, deliveryMaxBackoff},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := deliveryBackoff(tt.attempts); got != tt.want {
+				t.Errorf("deliveryBackoff() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
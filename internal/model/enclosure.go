@@ -0,0 +1,27 @@
+package model
+
+import "strings"
+
+// Enclosure feed item enclosure
+type Enclosure struct {
+	ID            uint   `gorm:"primary_key;AUTO_INCREMENT"`
+	ContentHashID string `gorm:"index"`
+	URL           string
+	Type          string
+	Length        uint
+}
+
+// IsImage check if the enclosure is an image
+func (e *Enclosure) IsImage() bool {
+	return strings.HasPrefix(e.Type, "image/")
+}
+
+// IsAudio check if the enclosure is an audio
+func (e *Enclosure) IsAudio() bool {
+	return strings.HasPrefix(e.Type, "audio/")
+}
+
+// IsVideo check if the enclosure is a video
+func (e *Enclosure) IsVideo() bool {
+	return strings.HasPrefix(e.Type, "video/")
+}
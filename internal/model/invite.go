@@ -0,0 +1,90 @@
+package model
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// InvitePrefix 邀请码前缀，用于与其他 /start 参数区分
+const InvitePrefix = "inv_"
+
+var (
+	// ErrInviteNotFound 邀请码不存在
+	ErrInviteNotFound = errors.New("invite code not found")
+	// ErrInviteExpired 邀请码已过期
+	ErrInviteExpired = errors.New("invite code expired")
+	// ErrInviteUsedUp 邀请码使用次数已用完
+	ErrInviteUsedUp = errors.New("invite code used up")
+)
+
+// InviteCode 邀请码，使用后用户被授予 user 角色
+type InviteCode struct {
+	ID   uint   `gorm:"primary_key;AUTO_INCREMENT"`
+	Code string `gorm:"uniqueIndex"`
+	// MaxUses 最大使用次数，0 为不限制
+	MaxUses int
+	Uses    int
+	// ExpireAt 过期时间，为空时不过期
+	ExpireAt  *time.Time
+	CreatedBy int64
+	EditTime
+}
+
+// CreateInviteCode 创建邀请码，maxUses 为 0 时不限次数，ttl 为 0 时不过期
+func CreateInviteCode(createdBy int64, maxUses int, ttl time.Duration) (*InviteCode, error) {
+	buf := make([]byte, 6)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	invite := &InviteCode{
+		Code:      InvitePrefix + hex.EncodeToString(buf),
+		MaxUses:   maxUses,
+		CreatedBy: createdBy,
+	}
+	if ttl > 0 {
+		expireAt := time.Now().Add(ttl)
+		invite.ExpireAt = &expireAt
+	}
+	if err := db.Create(invite).Error; err != nil {
+		return nil, err
+	}
+	return invite, nil
+}
+
+// RedeemInviteCode 使用邀请码，为用户授予 user 角色
+func RedeemInviteCode(code string, userID int64) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var invite InviteCode
+		err := tx.Where("code = ?", code).First(&invite).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInviteNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if invite.ExpireAt != nil && time.Now().After(*invite.ExpireAt) {
+			return ErrInviteExpired
+		}
+
+		// 条件更新保证并发使用时不超过最大次数
+		result := tx.Model(&InviteCode{}).
+			Where("id = ? and (max_uses = 0 or uses < max_uses)", invite.ID).
+			Update("uses", gorm.Expr("uses + 1"))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrInviteUsedUp
+		}
+
+		var user User
+		if err := tx.Where(User{TelegramID: userID}).FirstOrCreate(&user).Error; err != nil {
+			return err
+		}
+		return tx.Model(&user).Update("role", RoleUser).Error
+	})
+}
@@ -36,8 +36,16 @@ func updateTable() {
 	createOrUpdateTable(&Source{})
 	createOrUpdateTable(&Option{})
 	createOrUpdateTable(&Content{})
+	createOrUpdateTable(&Enclosure{})
 	createOrUpdateTable(&History{})
 	createOrUpdateTable(&Keyword{})
+	createOrUpdateTable(&Delivery{})
+	createOrUpdateTable(&Publication{})
+	createOrUpdateTable(&TelegraphAccount{})
+	createOrUpdateTable(&ConversationState{})
+	createOrUpdateTable(&InviteCode{})
+	createOrUpdateTable(&Chat{})
+	migrateChats()
 }
 
 // connectDB connect to db
@@ -0,0 +1,155 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PublicationStatus telegraph 发布状态
+type PublicationStatus int
+
+const (
+	// PublicationPending 等待发布
+	PublicationPending PublicationStatus = iota
+	// PublicationDone 已发布
+	PublicationDone
+	// PublicationDead 多次重试失败或内容无法发布，不再重试
+	PublicationDead
+)
+
+const maxPublicationAttempts = 8
+
+// Publication 待发布到 telegraph 的一条内容
+//
+// UserID 为 0 时以全局账号发布，页面地址保存在 Content 中；
+// 否则以该会话的 telegraph 账号发布，页面地址保存在 TelegraphURL 中
+type Publication struct {
+	ID            uint              `gorm:"primary_key;AUTO_INCREMENT"`
+	ContentHashID string            `gorm:"uniqueIndex:idx_publication_content_user;size:191"`
+	UserID        int64             `gorm:"uniqueIndex:idx_publication_content_user"`
+	SourceID      uint              `gorm:"index"`
+	Status        PublicationStatus `gorm:"index"`
+	Attempts      int
+	NextAttemptAt time.Time `gorm:"index"`
+	LastError     string
+	TelegraphURL  string
+	// Pages 多页内容发布中断前已创建的页面地址，从最后一页起以换行分隔，重试时继续发布
+	Pages string
+	EditTime
+}
+
+// EnqueuePublications 为内容创建待发布记录，拥有自有账号的会话另行发布，已存在的记录会被忽略
+func EnqueuePublications(contents []*Content) error {
+	var publications []Publication
+	now := time.Now()
+	accountUsers := make(map[uint][]int64)
+	for _, content := range contents {
+		userIDs, ok := accountUsers[content.SourceID]
+		if !ok {
+			var err error
+			if userIDs, err = getTelegraphAccountUserIDs(content.SourceID); err != nil {
+				return err
+			}
+			accountUsers[content.SourceID] = userIDs
+		}
+		for _, userID := range append([]int64{0}, userIDs...) {
+			publications = append(publications, Publication{
+				ContentHashID: content.HashID,
+				UserID:        userID,
+				SourceID:      content.SourceID,
+				Status:        PublicationPending,
+				NextAttemptAt: now,
+			})
+		}
+	}
+	if len(publications) == 0 {
+		return nil
+	}
+	return db.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(&publications, 100).Error
+}
+
+// GetDuePublications 获取已到发布时间的记录，按入队顺序排列
+func GetDuePublications(limit int) ([]*Publication, error) {
+	var publications []*Publication
+	err := db.Where("status = ? and next_attempt_at <= ?", PublicationPending, time.Now()).
+		Order("id").Limit(limit).Find(&publications).Error
+	return publications, err
+}
+
+// PrunePublications 删除 before 之前已结束的发布记录，保留会话自有账号已发布的页面地址
+func PrunePublications(before time.Time) error {
+	return db.Where("updated_at < ? and (status = ? or (status = ? and user_id = 0))",
+		before, PublicationDead, PublicationDone).Delete(&Publication{}).Error
+}
+
+// GetChatTelegraphURL 获取以会话自有账号发布的页面地址，尚未发布时返回空
+func GetChatTelegraphURL(hashID string, userID int64) string {
+	var p Publication
+	err := db.Where("content_hash_id = ? and user_id = ? and status = ?", hashID, userID, PublicationDone).
+		First(&p).Error
+	if err != nil {
+		return ""
+	}
+	return p.TelegraphURL
+}
+
+// CreatedPages 获取已创建的页面地址
+func (p *Publication) CreatedPages() []string {
+	if p.Pages == "" {
+		return nil
+	}
+	return strings.Split(p.Pages, "\n")
+}
+
+// SavePages 保存已创建的页面地址
+func (p *Publication) SavePages(pages []string) error {
+	p.Pages = strings.Join(pages, "\n")
+	return db.Model(p).Update("pages", p.Pages).Error
+}
+
+// MarkDone 标记为已发布并保存页面地址
+func (p *Publication) MarkDone(telegraphURL string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if p.UserID == 0 {
+			err := tx.Model(&Content{}).Where("hash_id = ?", p.ContentHashID).
+				Update("telegraph_url", telegraphURL).Error
+			if err != nil {
+				return err
+			}
+		}
+		p.Status = PublicationDone
+		p.LastError = ""
+		p.Pages = ""
+		p.TelegraphURL = telegraphURL
+		return tx.Save(p).Error
+	})
+}
+
+// MarkFailed 记录一次失败，retryAfter 大于 0 时为限流，按指定时间重试且不计入重试次数，
+// 否则按指数退避安排重试，超过重试次数或 permanent 时标记为失败
+func (p *Publication) MarkFailed(err error, retryAfter time.Duration, permanent bool) error {
+	if err != nil {
+		p.LastError = err.Error()
+		if len(p.LastError) > maxDeliveryError {
+			p.LastError = p.LastError[:maxDeliveryError]
+		}
+	}
+	switch {
+	case permanent:
+		p.Status = PublicationDead
+	case retryAfter > 0:
+		p.NextAttemptAt = time.Now().Add(retryAfter)
+	default:
+		p.Attempts++
+		if p.Attempts >= maxPublicationAttempts {
+			p.Status = PublicationDead
+		} else {
+			// 重试间隔与投递队列一致
+			p.NextAttemptAt = time.Now().Add(deliveryBackoff(p.Attempts))
+		}
+	}
+	return db.Save(p).Error
+}
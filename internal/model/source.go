@@ -11,6 +11,7 @@ import (
 	"unicode"
 
 	"github.com/indes/flowerss-bot/internal/config"
+	"github.com/indes/flowerss-bot/internal/provider/fetcher"
 	"github.com/indes/flowerss-bot/internal/util"
 
 	"github.com/SlyMarbo/rss"
@@ -22,19 +23,24 @@ type Source struct {
 	ID         uint   `gorm:"primary_key;AUTO_INCREMENT"`
 	Link       string `gorm:"uniqueIndex"`
 	Title      string
+	SiteLink   string
 	ErrorCount uint
 	Content    []Content
 	EditTime
 }
 
 func (s *Source) BeforeDelete(tx *gorm.DB) error {
+	contents := tx.Model(&Content{}).Select("hash_id").Where("source_id = ?", s.ID)
+	if err := tx.Where("content_hash_id IN (?)", contents).Delete(&Enclosure{}).Error; err != nil {
+		return err
+	}
 	return tx.Where("source_id = ?", s.ID).Delete(Content{}).Error
 }
 
-func (s *Source) appendContents(items []*rss.Item) error {
+func (s *Source) appendContents(items []*rss.Item, meta *fetcher.FeedMeta) error {
 	var contents []Content
 	for _, item := range items {
-		c, _ := getContentByFeedItem(s, item)
+		c, _ := getContentByFeedItem(s, item, meta.Item(item.ID, item.Link))
 		if c.TorrentUrl != "" {
 			return nil
 		}
@@ -87,6 +93,40 @@ func fetchFunc(url string) (resp *http.Response, err error) {
 	return
 }
 
+// fetchFeed 抓取并解析 feed，同时解析 rss 库未提供的条目信息
+func fetchFeed(url string) (*rss.Feed, *fetcher.FeedMeta, error) {
+	resp, err := fetchFunc(url)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func(Body io.ReadCloser) {
+		_ = Body.Close()
+	}(resp.Body)
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	feed, err := rss.Parse(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	if feed.Link == "" {
+		feed.Link = url
+	}
+	feed.UpdateURL = url
+	feed.FetchFunc = fetchFunc
+
+	meta, err := fetcher.ParseFeedMeta(data)
+	if err != nil {
+		zap.S().Debugw("parse feed meta failed", "url", url, "error", err)
+	}
+	if meta.Link == "" {
+		meta.Link = feed.Link
+	}
+	return feed, meta, nil
+}
+
 func FindOrNewSourceByUrl(url string) (*Source, error) {
 	var source Source
 
@@ -98,13 +138,14 @@ func FindOrNewSourceByUrl(url string) (*Source, error) {
 	}
 
 	// parsing task
-	feed, err := rss.FetchByFunc(fetchFunc, url)
+	feed, meta, err := fetchFeed(url)
 	if err != nil {
 		return nil, fmt.Errorf("Feed 抓取错误 %v", err)
 	}
 
 	source.Title = feed.Title
 	source.Link = url
+	source.SiteLink = meta.Link
 	// 避免task更新
 	source.ErrorCount = config.ErrorThreshold + 1
 
@@ -120,11 +161,31 @@ func FindOrNewSourceByUrl(url string) (*Source, error) {
 	}
 
 	go func() {
-		_ = source.appendContents(items)
+		_ = source.appendContents(items, meta)
 	}()
 	return &source, nil
 }
 
+// PreviewSourceByUrl 获取订阅源用于预览，源不存在时抓取 feed 但不保存
+func PreviewSourceByUrl(url string) (*Source, error) {
+	var source Source
+	err := db.Where("link = ?", url).First(&source).Error
+	if err == nil {
+		return &source, nil
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	feed, meta, err := fetchFeed(url)
+	if err != nil {
+		return nil, fmt.Errorf("Feed 抓取错误 %v", err)
+	}
+	source.Title = feed.Title
+	source.Link = url
+	source.SiteLink = meta.Link
+	return &source, nil
+}
+
 func GetSources() (sources []*Source) {
 	db.Find(&sources)
 	return sources
@@ -171,38 +232,63 @@ func (s *Source) GetNewContents() ([]*Content, error) {
 	)
 
 	var newContents []*Content
-	feed, err := rss.FetchByFunc(fetchFunc, s.Link)
+	feed, meta, err := fetchFeed(s.Link)
 	if err != nil {
 		zap.S().Errorw("unable to fetch update", "error", err, "source", s)
 		s.AddErrorCount()
 		return nil, err
 	}
 
+	s.SiteLink = meta.Link
 	s.EraseErrorCount(feed)
 
 	items := feed.Items
 	sort.SliceStable(items, func(i, j int) bool {
 		return items[i].Date.Before(items[j].Date)
 	})
+	seen := make(map[string]bool)
 	for _, item := range items {
-		c, isBroad, _ := GenContentAndCheckByFeedItem(s, item)
-		if !isBroad {
+		c, isBroad, _ := GenContentAndCheckByFeedItem(s, item, meta.Item(item.ID, item.Link))
+		if !isBroad && !seen[c.HashID] {
+			// 同一 feed 中重复的条目只保存一次
+			seen[c.HashID] = true
 			newContents = append(newContents, c)
 		}
 	}
 
 	var firstContent Content
-	shouldPublish := config.EnableTelegraph
+	isFirstFetch := false
 	if err := db.Where("source_id=?", s.ID).First(&firstContent).Error; errors.Is(err, gorm.ErrRecordNotFound) {
-		if len(newContents) > 1 {
-			shouldPublish = false
+		isFirstFetch = len(newContents) > 1
+	}
+	shouldPublish := shouldPublishTelegraph() && !isFirstFetch
+	subs := GetSubscriberBySource(s)
+	if !isFirstFetch && hasFullTextSubscribe(subs) {
+		// 原文在保存前抓取一次，推送时与判断是否生成 telegraph 页面时直接使用
+		fetchFullTexts(newContents)
+	}
+	// 内容与投递记录在同一事务中保存，入队失败时内容不会被视为已推送，下次抓取时重试
+	err = db.Transaction(func(tx *gorm.DB) error {
+		for _, content := range newContents {
+			if err := tx.Create(content).Error; err != nil {
+				return err
+			}
 		}
+		return enqueueDeliveries(tx, subs, newContents)
+	})
+	if err != nil {
+		zap.S().Errorw("save new contents failed", "source id", s.ID, "error", err)
+		return nil, err
 	}
+
+	var publishContents []*Content
 	for _, content := range newContents {
-		if shouldPublish {
-			content.Publish(s)
+		if shouldPublish && content.NeedPublish() {
+			publishContents = append(publishContents, content)
 		}
-		db.Create(content)
+	}
+	if err := EnqueuePublications(publishContents); err != nil {
+		zap.S().Errorw("enqueue telegraph publications failed", "source id", s.ID, "error", err)
 	}
 
 	return newContents, nil
@@ -0,0 +1,124 @@
+package model
+
+import (
+	"time"
+
+	"github.com/indes/flowerss-bot/internal/config"
+
+	"gorm.io/gorm"
+)
+
+// Stats 实例的统计数据
+type Stats struct {
+	// Users 私聊用户数
+	Users int64
+	// Chats 有订阅的会话数
+	Chats         int64
+	Sources       int64
+	Subscriptions int64
+	// ErrorSources 最近一次更新出错的源数
+	ErrorSources int64
+	// PausedSources 连续出错次数达到阈值而停止更新的源数
+	PausedSources int64
+	// Sent 统计时间内已发送的推送数
+	Sent int64
+	// Dead 统计时间内放弃发送的推送数
+	Dead int64
+}
+
+// SourceStat 源及其订阅数
+type SourceStat struct {
+	ID          uint
+	Link        string
+	Title       string
+	ErrorCount  uint
+	Subscribers int64
+}
+
+// UserStat 用户及其订阅数
+type UserStat struct {
+	TelegramID    int64
+	Role          Role
+	Subscriptions int64
+}
+
+// GetStats 统计实例数据，推送数为 since 之后的记录
+func GetStats(since time.Time) (*Stats, error) {
+	var stats Stats
+	counts := []struct {
+		count *int64
+		tx    *gorm.DB
+	}{
+		{&stats.Users, db.Model(&User{}).Where("telegram_id > 0")},
+		{&stats.Chats, db.Model(&Subscribe{}).Distinct("user_id")},
+		{&stats.Sources, db.Model(&Source{})},
+		{&stats.Subscriptions, db.Model(&Subscribe{})},
+		{&stats.ErrorSources, db.Model(&Source{}).Where("error_count > 0")},
+		{&stats.PausedSources, db.Model(&Source{}).Where("error_count >= ?", config.ErrorThreshold)},
+		{&stats.Sent, db.Model(&Delivery{}).Where("status = ? and updated_at >= ?", DeliverySent, since)},
+		{&stats.Dead, db.Model(&Delivery{}).Where("status = ? and updated_at >= ?", DeliveryDead, since)},
+	}
+	for _, c := range counts {
+		if err := c.tx.Count(c.count).Error; err != nil {
+			return nil, err
+		}
+	}
+	return &stats, nil
+}
+
+// GetSourceStatsByPage 按订阅数从多到少分页获取源
+func GetSourceStatsByPage(page, limit int) (stats []SourceStat, hasPrev, hasNext bool, err error) {
+	var count int64
+	if err = db.Model(&Source{}).Count(&count).Error; err != nil {
+		return
+	}
+	err = db.Model(&Source{}).
+		Select("sources.id, sources.link, sources.title, sources.error_count, count(subscribes.id) as subscribers").
+		Joins("left join subscribes on subscribes.source_id = sources.id").
+		Group("sources.id, sources.link, sources.title, sources.error_count").
+		Order("subscribers desc, sources.id").
+		Offset(getPageOffset(page, limit)).Limit(limit).
+		Scan(&stats).Error
+	hasPrev = page > 1
+	hasNext = int64(page*limit) < count
+	return
+}
+
+// GetUserStatsByPage 按订阅数从多到少分页获取用户
+func GetUserStatsByPage(page, limit int) (stats []UserStat, hasPrev, hasNext bool, err error) {
+	var count int64
+	if err = db.Model(&User{}).Count(&count).Error; err != nil {
+		return
+	}
+	err = db.Model(&User{}).
+		Select("users.telegram_id, users.role, count(subscribes.id) as subscriptions").
+		Joins("left join subscribes on subscribes.user_id = users.telegram_id").
+		Group("users.telegram_id, users.role").
+		Order("subscriptions desc, users.telegram_id").
+		Offset(getPageOffset(page, limit)).Limit(limit).
+		Scan(&stats).Error
+	hasPrev = page > 1
+	hasNext = int64(page*limit) < count
+	return
+}
+
+// GetAllChatIDs 所有使用过 bot 或有订阅的会话
+func GetAllChatIDs() ([]int64, error) {
+	var userIDs, subIDs []int64
+	if err := db.Model(&User{}).Pluck("telegram_id", &userIDs).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Model(&Subscribe{}).Distinct().Pluck("user_id", &subIDs).Error; err != nil {
+		return nil, err
+	}
+	seen := make(map[int64]bool, len(userIDs)+len(subIDs))
+	var chatIDs []int64
+	for _, id := range append(userIDs, subIDs...) {
+		if id == 0 || seen[id] {
+			continue
+		}
+		seen[id] = true
+		chatIDs = append(chatIDs, id)
+	}
+	return chatIDs, nil
+}
@@ -3,12 +3,20 @@ package model
 import (
 	"errors"
 	"strings"
+	"time"
 
 	"github.com/indes/flowerss-bot/internal/config"
 
 	"gorm.io/gorm"
 )
 
+var (
+	// ErrNotSubscribed 会话未订阅该源
+	ErrNotSubscribed = errors.New("未订阅该RSS源")
+	// ErrSubscribeNotFound 订阅不存在或不属于该会话
+	ErrSubscribeNotFound = errors.New("未找到该条订阅")
+)
+
 type Subscribe struct {
 	ID                 uint  `gorm:"primary_key;AUTO_INCREMENT"`
 	UserID             int64 `gorm:"index"`
@@ -17,10 +25,17 @@ type Subscribe struct {
 	EnableTelegraph    int
 	EnableDownload     int
 	EnableFilter       int
+	EnableMedia        int
+	EnableFullText     int
 	Tag                string
 	Webhook            string // Deprecated: 不再使用
 	Interval           int
 	WaitTime           int
+	MuteUntil          *time.Time
+	MessageTpl         string
+	MessageMode        string
+	ThreadID           int  // 推送到的论坛话题，0 为默认话题
+	Paused             bool `gorm:"default:false"` // bot 被移出会话时暂停推送
 	EditTime
 }
 
@@ -50,10 +65,13 @@ func RegistFeed(userID int64, feedUrl string) (source *Source, err error) {
 
 	subscribe.UserID = userID
 	subscribe.SourceID = source.ID
-	subscribe.EnableNotification = 1
-	subscribe.EnableTelegraph = 1
 	subscribe.Interval = config.UpdateInterval
 	subscribe.WaitTime = config.UpdateInterval
+	chat, err := FindOrCreateChat(userID)
+	if err != nil {
+		return
+	}
+	chat.applyDefaults(&subscribe)
 
 	err = db.Create(&subscribe).Error
 	return
@@ -63,11 +81,21 @@ func GetSubscribeByUserIDAndSourceID(userID int64, sourceID uint) (*Subscribe, e
 	var sub Subscribe
 	db.Where("user_id=? and source_id=?", userID, sourceID).First(&sub)
 	if sub.UserID != int64(userID) {
-		return nil, errors.New("未订阅该RSS源")
+		return nil, ErrNotSubscribed
 	}
 	return &sub, nil
 }
 
+// hasFullTextSubscribe 是否有订阅开启了抓取原文
+func hasFullTextSubscribe(subs []*Subscribe) bool {
+	for _, sub := range subs {
+		if sub.EnableFullText == 1 {
+			return true
+		}
+	}
+	return false
+}
+
 func GetSubscriberBySource(s *Source) []*Subscribe {
 	if s == nil {
 		return []*Subscribe{}
@@ -75,7 +103,7 @@ func GetSubscriberBySource(s *Source) []*Subscribe {
 
 	var subs []*Subscribe
 
-	db.Where("source_id=?", s.ID).Find(&subs)
+	db.Where("source_id=? and paused=?", s.ID, false).Find(&subs)
 	return subs
 }
 
@@ -87,7 +115,7 @@ func UnsubByUserIDAndSource(userID int64, source *Source) error {
 	var sub Subscribe
 	db.Where("user_id=? and source_id=?", userID, source.ID).First(&sub)
 	if sub.UserID != userID {
-		return errors.New("未订阅该RSS源")
+		return ErrNotSubscribed
 	}
 	return db.Delete(&sub).Error
 }
@@ -97,7 +125,7 @@ func UnsubByUserIDAndSubID(userID int64, subID uint) error {
 	db.Where("id=?", subID).First(&sub)
 
 	if sub.UserID != userID {
-		return errors.New("未找到该条订阅")
+		return ErrSubscribeNotFound
 	}
 	return db.Delete(&sub).Error
 }
@@ -122,6 +150,12 @@ func UnsubAllByUserID(userID int64) (success int, fail int, err error) {
 	return
 }
 
+// SetSubsPausedByUserID 暂停或恢复会话的所有订阅，返回变更的订阅数
+func SetSubsPausedByUserID(userID int64, paused bool) (int64, error) {
+	result := db.Model(&Subscribe{}).Where("user_id = ? and paused = ?", userID, !paused).Update("paused", paused)
+	return result.RowsAffected, result.Error
+}
+
 func GetSubsByUserID(userID int64) ([]Subscribe, error) {
 	var subs []Subscribe
 
@@ -130,6 +164,13 @@ func GetSubsByUserID(userID int64) ([]Subscribe, error) {
 	return subs, nil
 }
 
+// CountSubsByUserID 会话的订阅数
+func CountSubsByUserID(userID int64) (int64, error) {
+	var count int64
+	err := db.Model(&Subscribe{}).Where("user_id = ?", userID).Count(&count).Error
+	return count, err
+}
+
 func GetSubsByUserIdByPage(userId int64, page, limit int) (subs []Subscribe, hasPrev, hasNext bool, err error) {
 	condition := &Subscribe{UserID: userId}
 
@@ -194,6 +235,41 @@ func (s *Subscribe) ToggleFilter() error {
 	return nil
 }
 
+func (s *Subscribe) ToggleMedia() error {
+	if s.EnableMedia != 1 {
+		s.EnableMedia = 1
+	} else {
+		s.EnableMedia = 0
+	}
+	return nil
+}
+
+// ToggleFullText 切换是否抓取原文正文
+func (s *Subscribe) ToggleFullText() error {
+	if s.EnableFullText != 1 {
+		s.EnableFullText = 1
+	} else {
+		s.EnableFullText = 0
+	}
+	return nil
+}
+
+// ToggleThread 切换推送到 threadID 话题或默认话题
+func (s *Subscribe) ToggleThread(threadID int) error {
+	if s.ThreadID != 0 {
+		s.ThreadID = 0
+	} else {
+		s.ThreadID = threadID
+	}
+	return nil
+}
+
+// SetThread 设置推送到的论坛话题，threadID 为 0 时推送到默认话题
+func (s *Subscribe) SetThread(threadID int) error {
+	s.ThreadID = threadID
+	return db.Model(s).Update("thread_id", threadID).Error
+}
+
 func (s *Source) ToggleEnabled() error {
 	if s.ErrorCount >= config.ErrorThreshold {
 		s.ErrorCount = 0
@@ -226,6 +302,28 @@ func (s *Subscribe) SetInterval(interval int) error {
 	return nil
 }
 
+// SetMessageTpl 设置订阅的消息模版，tpl 为空时使用会话的模版
+func (s *Subscribe) SetMessageTpl(tpl string, mode string) error {
+	s.MessageTpl = tpl
+	s.MessageMode = mode
+	return db.Model(s).Updates(map[string]interface{}{
+		"message_tpl":  tpl,
+		"message_mode": mode,
+	}).Error
+}
+
+// Mute 在 d 时间内不再推送该订阅的更新
+func (s *Subscribe) Mute(d time.Duration) error {
+	until := time.Now().Add(d)
+	s.MuteUntil = &until
+	return db.Model(s).Update("mute_until", s.MuteUntil).Error
+}
+
+// IsMuted 订阅是否处于静音状态
+func (s *Subscribe) IsMuted() bool {
+	return s.MuteUntil != nil && time.Now().Before(*s.MuteUntil)
+}
+
 func (s *Subscribe) Unsub() error {
 	if s.ID == 0 {
 		return errors.New("can't delete 0 subscribe")
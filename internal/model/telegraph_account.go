@@ -0,0 +1,40 @@
+package model
+
+// TelegraphAccount 会话自有的 telegraph 账号，该会话订阅的内容以此账号发布
+type TelegraphAccount struct {
+	ID          uint  `gorm:"primary_key;AUTO_INCREMENT"`
+	UserID      int64 `gorm:"uniqueIndex"`
+	AccessToken string
+	AuthorName  string
+	AuthorURL   string
+	EditTime
+}
+
+// GetTelegraphAccountByUserID 获取会话的 telegraph 账号
+func GetTelegraphAccountByUserID(userID int64) (*TelegraphAccount, error) {
+	var account TelegraphAccount
+	if err := db.Where("user_id = ?", userID).First(&account).Error; err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// Save 保存 telegraph 账号
+func (a *TelegraphAccount) Save() error {
+	return db.Save(a).Error
+}
+
+// DeleteTelegraphAccountByUserID 删除会话的 telegraph 账号，之后的内容以全局账号发布
+func DeleteTelegraphAccountByUserID(userID int64) error {
+	return db.Where("user_id = ?", userID).Delete(&TelegraphAccount{}).Error
+}
+
+// getTelegraphAccountUserIDs 获取订阅了 source 且开启 telegraph 的会话中拥有自有账号的会话
+func getTelegraphAccountUserIDs(sourceID uint) ([]int64, error) {
+	var userIDs []int64
+	err := db.Model(&Subscribe{}).
+		Joins("join telegraph_accounts on telegraph_accounts.user_id = subscribes.user_id").
+		Where("subscribes.source_id = ? and subscribes.enable_telegraph = 1", sourceID).
+		Pluck("subscribes.user_id", &userIDs).Error
+	return userIDs, err
+}
@@ -2,15 +2,35 @@ package model
 
 import "errors"
 
+// Role 用户角色
+type Role string
+
+const (
+	// RoleGuest 未被授予角色的用户
+	RoleGuest Role = ""
+	// RoleOwner bot 所有者，由配置指定，不保存在数据库中
+	RoleOwner Role = "owner"
+	// RoleAdmin 管理员，可以为其他用户授予或撤销 user、banned 角色
+	RoleAdmin Role = "admin"
+	// RoleUser 普通用户
+	RoleUser Role = "user"
+	// RoleBanned 被禁止使用 bot 的用户
+	RoleBanned Role = "banned"
+)
+
 // User subscriber
 //
 // TelegramID 用作外键
 type User struct {
-	ID         int64    `gorm:"primary_key"`
-	TelegramID int64    `gorm:"uniqueIndex"`
-	Source     []Source `gorm:"many2many:subscribes;"`
-	State      int      `gorm:"DEFAULT:0;"`
-	Token      string
+	ID          int64    `gorm:"primary_key"`
+	TelegramID  int64    `gorm:"uniqueIndex"`
+	Source      []Source `gorm:"many2many:subscribes;"`
+	State       int      `gorm:"DEFAULT:0;"`
+	Token       string
+	MessageTpl  string
+	MessageMode string
+	// Role 用户角色，为空时未被授予角色
+	Role Role
 	EditTime
 }
 
@@ -28,6 +48,59 @@ func SaveTokenByUserId(userId int64, token string) error {
 	return db.Save(user).Error
 }
 
+// SaveMessageTplByUserId 保存会话的消息模版，tpl 为空时恢复默认模版
+func SaveMessageTplByUserId(userId int64, tpl string, mode string) error {
+	user, _ := FindOrCreateUserByTelegramID(userId)
+	user.MessageTpl = tpl
+	user.MessageMode = mode
+	return db.Save(user).Error
+}
+
+// SaveTimezoneByUserId 保存会话的时区，tz 为空时使用 bot 所在时区
+func SaveTimezoneByUserId(userId int64, tz string) error {
+	chat, err := FindOrCreateChat(userId)
+	if err != nil {
+		return err
+	}
+	return db.Model(chat).Update("timezone", tz).Error
+}
+
+// SaveLanguageByUserId 保存会话的语言，lang 为空时跟随 telegram 客户端
+func SaveLanguageByUserId(userId int64, lang string) error {
+	chat, err := FindOrCreateChat(userId)
+	if err != nil {
+		return err
+	}
+	return db.Model(chat).Update("language", lang).Error
+}
+
+// GetLanguageByUserId 会话设置的语言，未设置时返回空字符串
+func GetLanguageByUserId(userId int64) string {
+	var languages []string
+	db.Model(&Chat{}).Where("id = ?", userId).Limit(1).Pluck("language", &languages)
+	if len(languages) == 0 {
+		return ""
+	}
+	return languages[0]
+}
+
+// SaveRoleByUserId 保存用户的角色，role 为空时撤销已授予的角色
+func SaveRoleByUserId(userId int64, role Role) error {
+	user, _ := FindOrCreateUserByTelegramID(userId)
+	user.Role = role
+	return db.Save(user).Error
+}
+
+// GetRoleByUserId 用户被授予的角色，未授予时返回 RoleGuest
+func GetRoleByUserId(userId int64) Role {
+	var roles []string
+	db.Model(&User{}).Where("telegram_id = ?", userId).Limit(1).Pluck("role", &roles)
+	if len(roles) == 0 {
+		return RoleGuest
+	}
+	return Role(roles[0])
+}
+
 // GetSubSourceMap get user subscribe and fetcher source
 func (user *User) GetSubSourceMap() (map[Subscribe]Source, error) {
 	m := make(map[Subscribe]Source)
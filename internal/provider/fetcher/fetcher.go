@@ -1 +1,211 @@
 package fetcher
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html/charset"
+)
+
+// ItemMeta rss 库未解析的条目信息
+type ItemMeta struct {
+	Author   string
+	Updated  time.Time
+	Comments string
+}
+
+// FeedMeta rss 库未解析的 feed 信息
+type FeedMeta struct {
+	// Link 站点地址
+	Link  string
+	items map[string]*ItemMeta
+}
+
+// Item 按条目 id 或链接获取条目信息，不存在时返回空信息
+func (m *FeedMeta) Item(id, link string) *ItemMeta {
+	if m != nil {
+		if item, ok := m.items[id]; ok && id != "" {
+			return item
+		}
+		if item, ok := m.items[link]; ok && link != "" {
+			return item
+		}
+	}
+	return &ItemMeta{}
+}
+
+var timeLayouts = []string{
+	time.RFC3339,
+	time.RFC3339Nano,
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC822Z,
+	time.RFC822,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+func parseTime(s string) time.Time {
+	s = strings.TrimSpace(s)
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// parseAuthor 解析 rss author 字段，"mail@example.com (Name)" 格式只保留名称
+func parseAuthor(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.Index(s, "("); i > 0 && strings.HasSuffix(s, ")") {
+		return strings.TrimSpace(s[i+1 : len(s)-1])
+	}
+	return s
+}
+
+func charsetReader(label string, input io.Reader) (io.Reader, error) {
+	switch strings.ToLower(label) {
+	case "utf-8", "utf8", "":
+		return input, nil
+	}
+	if encoding, _ := charset.Lookup(label); encoding != nil {
+		return encoding.NewDecoder().Reader(input), nil
+	}
+	return nil, errors.New("unexpected charset: " + label)
+}
+
+func attr(e xml.StartElement, name string) string {
+	for _, a := range e.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// isAlternateLink atom 中 rel 为空或 alternate 的链接指向原文
+func isAlternateLink(e xml.StartElement) bool {
+	rel := attr(e, "rel")
+	return rel == "" || rel == "alternate"
+}
+
+// ParseFeedMeta 解析 RSS 1.0 / RSS 2.0 / Atom 中作者、更新时间、评论地址与站点地址
+func ParseFeedMeta(data []byte) (*FeedMeta, error) {
+	meta := &FeedMeta{items: make(map[string]*ItemMeta)}
+
+	d := xml.NewDecoder(bytes.NewReader(data))
+	d.Strict = false
+	d.CharsetReader = charsetReader
+
+	var (
+		path      []string
+		itemDepth = -1
+		item      *ItemMeta
+		id, link  string
+		text      strings.Builder
+	)
+	for {
+		token, err := d.Token()
+		if err == io.EOF {
+			return meta, nil
+		}
+		if err != nil {
+			return meta, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			name := t.Name.Local
+			path = append(path, name)
+			text.Reset()
+			depth := len(path) - 1
+			switch {
+			case item == nil && (name == "item" || name == "entry"):
+				itemDepth = depth
+				item = &ItemMeta{}
+				id, link = "", ""
+			case item == nil && name == "link" && depth <= 2 && meta.Link == "":
+				if href := attr(t, "href"); href != "" && isAlternateLink(t) {
+					meta.Link = href
+				}
+			case item != nil && depth == itemDepth+1 && name == "link":
+				if href := attr(t, "href"); href != "" {
+					switch attr(t, "rel") {
+					case "", "alternate":
+						link = href
+					case "replies":
+						if item.Comments == "" {
+							item.Comments = href
+						}
+					}
+				}
+			}
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			if len(path) == 0 {
+				continue
+			}
+			name := path[len(path)-1]
+			depth := len(path) - 1
+			path = path[:len(path)-1]
+			value := strings.TrimSpace(text.String())
+			text.Reset()
+
+			if item == nil {
+				// rss 的 channel/link 为站点地址，需排除 atom:link 等带 href 的元素
+				if name == "link" && depth == 2 && meta.Link == "" && value != "" {
+					meta.Link = value
+				}
+				continue
+			}
+			if depth == itemDepth {
+				if id != "" {
+					meta.items[id] = item
+				}
+				if link != "" {
+					if _, ok := meta.items[link]; !ok {
+						meta.items[link] = item
+					}
+				}
+				item = nil
+				itemDepth = -1
+				continue
+			}
+			if value == "" {
+				continue
+			}
+			switch {
+			case depth == itemDepth+1:
+				switch name {
+				case "guid", "id":
+					id = value
+				case "link":
+					if link == "" {
+						link = value
+					}
+				case "author", "creator":
+					if item.Author == "" {
+						item.Author = parseAuthor(value)
+					}
+				case "updated", "modified":
+					item.Updated = parseTime(value)
+				case "comments":
+					item.Comments = value
+				}
+			case depth == itemDepth+2 && name == "name" && path[len(path)-1] == "author":
+				if item.Author == "" {
+					item.Author = value
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package fetcher
+
+import (
+	"testing"
+	"time"
+)
+
+const rss2Feed = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:atom="http://www.w3.org/2005/Atom">
+<channel>
+	<title>Example</title>
+	<atom:link href="https://example.com/feed.xml" rel="self" type="application/rss+xml"/>
+	<link>https://example.com/</link>
+	<item>
+		<title>First</title>
+		<link>https://example.com/first</link>
+		<guid>first-id</guid>
+		<author>mail@example.com (Alice)</author>
+		<comments>https://example.com/first#comments</comments>
+	</item>
+	<item>
+		<title>Second</title>
+		<link>https://example.com/second</link>
+		<dc:creator><![CDATA[Bob]]></dc:creator>
+	</item>
+</channel>
+</rss>`
+
+const atomFeed = `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+	<title>Example</title>
+	<link href="https://example.com/atom.xml" rel="self"/>
+	<link href="https://example.com/"/>
+	<entry>
+		<id>tag:example.com,2021:1</id>
+		<title>Entry</title>
+		<link href="https://example.com/entry" rel="alternate"/>
+		<link href="https://example.com/entry/comments" rel="replies" type="text/html"/>
+		<updated>2021-12-01T08:00:00Z</updated>
+		<author><name>Carol</name></author>
+		<source><author><name>Someone Else</name></author></source>
+	</entry>
+</feed>`
+
+// gbkFeed 作者为 GBK 编码的 "张三"
+const gbkFeed = "<?xml version=\"1.0\" encoding=\"GBK\"?>\n" +
+	"<rss version=\"2.0\"><channel><link>https://example.com/</link>" +
+	"<item><guid>gbk-id</guid><author>\xd5\xc5\xc8\xfd</author></item>" +
+	"</channel></rss>"
+
+func TestParseFeedMeta(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		wantLink string
+		id       string
+		link     string
+		want     ItemMeta
+	}{
+		{"rss author", rss2Feed, "https://example.com/", "first-id", "",
+			ItemMeta{Author: "Alice", Comments: "https://example.com/first#comments"}},
+		{"rss creator by link", rss2Feed, "https://example.com/", "https://example.com/second", "https://example.com/second",
+			ItemMeta{Author: "Bob"}},
+		{"atom", atomFeed, "https://example.com/", "tag:example.com,2021:1", "",
+			ItemMeta{
+				Author:   "Carol",
+				Updated:  time.Date(2021, 12, 1, 8, 0, 0, 0, time.UTC),
+				Comments: "https://example.com/entry/comments",
+			}},
+		{"gbk", gbkFeed, "https://example.com/", "gbk-id", "", ItemMeta{Author: "张三"}},
+		{"missing item", atomFeed, "https://example.com/", "unknown", "", ItemMeta{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta, err := ParseFeedMeta([]byte(tt.data))
+			if err != nil {
+				t.Fatalf("ParseFeedMeta() error = %v", err)
+			}
+			if meta.Link != tt.wantLink {
+				t.Errorf("ParseFeedMeta().Link = %q, want %q", meta.Link, tt.wantLink)
+			}
+			got := meta.Item(tt.id, tt.link)
+			if got.Author != tt.want.Author || got.Comments != tt.want.Comments || !got.Updated.Equal(tt.want.Updated) {
+				t.Errorf("Item() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,456 @@
+package fetcher
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+const (
+	// minParagraphLength 参与评分的段落最小长度
+	minParagraphLength = 25
+	// minArticleLength 正文的最小长度，不足时视为抽取失败
+	minArticleLength = 140
+)
+
+// ErrArticleNotFound 页面中找不到正文
+var ErrArticleNotFound = errors.New("article not found")
+
+var (
+	unlikelyRegexp = regexp.MustCompile(`(?i)banner|breadcrumb|combx|comment|community|cookie|disqus|extra|foot|` +
+		`header|legends|menu|modal|nav|related|remark|replies|rss|share|shoutbox|sidebar|skyscraper|social|` +
+		`sponsor|ad-break|agegate|pagination|pager|popup|subscribe|tags`)
+	maybeRegexp    = regexp.MustCompile(`(?i)and|article|body|column|content|main|shadow`)
+	positiveRegexp = regexp.MustCompile(`(?i)article|body|content|entry|hentry|h-entry|main|page|post|text|blog|story`)
+	negativeRegexp = regexp.MustCompile(`(?i)hidden|^hid$|\shid$|\shid\s|^hid\s|banner|combx|comment|com-|contact|` +
+		`foot|footer|footnote|masthead|media|meta|outbrain|promo|related|scroll|share|shoutbox|sidebar|` +
+		`skyscraper|sponsor|shopping|tags|tool|widget`)
+	metaCharsetRegexp = regexp.MustCompile(`(?i)<meta[^>]+charset\s*=\s*["']?([\w-]+)`)
+)
+
+// removedTags 抽取前移除的标签
+var removedTags = map[atom.Atom]bool{
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Noscript: true,
+	atom.Nav:      true,
+	atom.Footer:   true,
+	atom.Aside:    true,
+	atom.Form:     true,
+	atom.Button:   true,
+	atom.Input:    true,
+	atom.Select:   true,
+	atom.Textarea: true,
+	atom.Svg:      true,
+	atom.Link:     true,
+	atom.Meta:     true,
+}
+
+// blockTags 判断 div 是否只包含行内内容
+var blockTags = map[atom.Atom]bool{
+	atom.Blockquote: true,
+	atom.Dl:         true,
+	atom.Div:        true,
+	atom.Img:        true,
+	atom.Ol:         true,
+	atom.P:          true,
+	atom.Pre:        true,
+	atom.Table:      true,
+	atom.Ul:         true,
+	atom.Section:    true,
+	atom.Article:    true,
+	atom.Figure:     true,
+	atom.H1:         true,
+	atom.H2:         true,
+	atom.H3:         true,
+	atom.H4:         true,
+}
+
+// Article 网页中抽取的正文
+type Article struct {
+	Title string
+	// Content 正文 HTML，链接已转换为绝对地址
+	Content string
+}
+
+// ExtractArticle 以 readability 算法抽取网页正文，contentType 用于识别编码
+func ExtractArticle(data []byte, contentType string, pageURL string) (*Article, error) {
+	doc, err := html.Parse(decodeHTML(data, contentType))
+	if err != nil {
+		return nil, err
+	}
+	base, _ := url.Parse(pageURL)
+
+	article := &Article{Title: pageTitle(doc)}
+	removeUnlikely(doc)
+
+	scores := scoreCandidates(doc)
+	top := topCandidate(doc, scores)
+	if top == nil {
+		return nil, ErrArticleNotFound
+	}
+
+	var buf bytes.Buffer
+	length := 0
+	for _, n := range articleNodes(top, scores) {
+		cleanConditionally(n)
+		resolveLinks(n, base)
+		length += utf8.RuneCountInString(strings.TrimSpace(innerText(n)))
+		if err := html.Render(&buf, n); err != nil {
+			return nil, err
+		}
+	}
+	if length < minArticleLength {
+		return nil, ErrArticleNotFound
+	}
+	article.Content = buf.String()
+	return article, nil
+}
+
+// decodeHTML 按 Content-Type 或 meta 标签声明的编码转换为 UTF-8
+func decodeHTML(data []byte, contentType string) io.Reader {
+	var charset string
+	if _, params, err := mime.ParseMediaType(contentType); err == nil {
+		charset = params["charset"]
+	}
+	if charset == "" {
+		head := data
+		if len(head) > 1024 {
+			head = head[:1024]
+		}
+		if m := metaCharsetRegexp.FindSubmatch(head); m != nil {
+			charset = string(m[1])
+		}
+	}
+	if r, err := charsetReader(charset, bytes.NewReader(data)); err == nil {
+		return r
+	}
+	return bytes.NewReader(data)
+}
+
+// pageTitle 页面标题，优先使用 og:title
+func pageTitle(doc *html.Node) string {
+	var title, ogTitle string
+	walk(doc, func(n *html.Node) bool {
+		switch n.DataAtom {
+		case atom.Title:
+			if title == "" {
+				title = strings.TrimSpace(innerText(n))
+			}
+		case atom.Meta:
+			if getAttr(n, "property") == "og:title" && ogTitle == "" {
+				ogTitle = strings.TrimSpace(getAttr(n, "content"))
+			}
+		}
+		return true
+	})
+	if ogTitle != "" {
+		return ogTitle
+	}
+	return title
+}
+
+// removeUnlikely 移除脚本、导航等不可能是正文的节点
+func removeUnlikely(doc *html.Node) {
+	var removed []*html.Node
+	walk(doc, func(n *html.Node) bool {
+		if n.Type == html.CommentNode {
+			removed = append(removed, n)
+			return false
+		}
+		if n.Type != html.ElementNode {
+			return true
+		}
+		if removedTags[n.DataAtom] || isHidden(n) {
+			removed = append(removed, n)
+			return false
+		}
+		switch n.DataAtom {
+		case atom.Html, atom.Body, atom.Article, atom.Main, atom.A:
+			return true
+		}
+		match := getAttr(n, "class") + " " + getAttr(n, "id")
+		if unlikelyRegexp.MatchString(match) && !maybeRegexp.MatchString(match) {
+			removed = append(removed, n)
+			return false
+		}
+		return true
+	})
+	for _, n := range removed {
+		n.Parent.RemoveChild(n)
+	}
+}
+
+func isHidden(n *html.Node) bool {
+	if _, ok := attrValue(n, "hidden"); ok {
+		return true
+	}
+	if getAttr(n, "aria-hidden") == "true" {
+		return true
+	}
+	style := strings.ReplaceAll(strings.ToLower(getAttr(n, "style")), " ", "")
+	return strings.Contains(style, "display:none") || strings.Contains(style, "visibility:hidden")
+}
+
+// scoreCandidates 为段落评分并累加到父节点与祖父节点，得分按链接密度折算
+func scoreCandidates(doc *html.Node) map[*html.Node]float64 {
+	scores := make(map[*html.Node]float64)
+	addScore := func(n *html.Node, score float64) {
+		if n == nil || n.Type != html.ElementNode {
+			return
+		}
+		if _, ok := scores[n]; !ok {
+			scores[n] = initialScore(n)
+		}
+		scores[n] += score
+	}
+
+	walk(doc, func(n *html.Node) bool {
+		if !isParagraph(n) {
+			return true
+		}
+		text := strings.TrimSpace(innerText(n))
+		length := utf8.RuneCountInString(text)
+		if length < minParagraphLength {
+			return true
+		}
+		score := 1.0
+		score += float64(strings.Count(text, ",") + strings.Count(text, "，") + strings.Count(text, "。"))
+		if bonus := float64(length / 100); bonus < 3 {
+			score += bonus
+		} else {
+			score += 3
+		}
+		addScore(n.Parent, score)
+		if n.Parent != nil {
+			addScore(n.Parent.Parent, score/2)
+		}
+		return true
+	})
+
+	for n, score := range scores {
+		scores[n] = score * (1 - linkDensity(n))
+	}
+	return scores
+}
+
+// topCandidate 得分最高的节点，得分相同时取文档中靠前的节点
+func topCandidate(doc *html.Node, scores map[*html.Node]float64) *html.Node {
+	var top *html.Node
+	walk(doc, func(n *html.Node) bool {
+		if score, ok := scores[n]; ok && (top == nil || score > scores[top]) {
+			top = n
+		}
+		return true
+	})
+	return top
+}
+
+func siblingThreshold(topScore float64) float64 {
+	if threshold := topScore * 0.2; threshold > 10 {
+		return threshold
+	}
+	return 10
+}
+
+// initialScore 按标签与 class、id 初始化候选节点得分
+func initialScore(n *html.Node) float64 {
+	score := classWeight(n)
+	switch n.DataAtom {
+	case atom.Div, atom.Article, atom.Main, atom.Section:
+		score += 5
+	case atom.Pre, atom.Td, atom.Blockquote:
+		score += 3
+	case atom.Address, atom.Ol, atom.Ul, atom.Dl, atom.Dd, atom.Dt, atom.Li, atom.Form:
+		score -= 3
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6, atom.Th:
+		score -= 5
+	}
+	return score
+}
+
+func classWeight(n *html.Node) float64 {
+	var weight float64
+	for _, value := range []string{getAttr(n, "class"), getAttr(n, "id")} {
+		if value == "" {
+			continue
+		}
+		if negativeRegexp.MatchString(value) {
+			weight -= 25
+		}
+		if positiveRegexp.MatchString(value) {
+			weight += 25
+		}
+	}
+	return weight
+}
+
+// isParagraph 段落以及只包含行内内容的 div
+func isParagraph(n *html.Node) bool {
+	if n.Type != html.ElementNode {
+		return false
+	}
+	switch n.DataAtom {
+	case atom.P, atom.Pre, atom.Td:
+		return true
+	case atom.Div, atom.Section:
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == html.ElementNode && blockTags[c.DataAtom] {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// articleNodes 正文节点，包括得分最高的节点以及内容相关的兄弟节点
+func articleNodes(top *html.Node, scores map[*html.Node]float64) []*html.Node {
+	if top.Parent == nil || top.DataAtom == atom.Body {
+		return []*html.Node{top}
+	}
+	threshold := siblingThreshold(scores[top])
+	var nodes []*html.Node
+	for n := top.Parent.FirstChild; n != nil; n = n.NextSibling {
+		if n.Type != html.ElementNode {
+			continue
+		}
+		if score, ok := scores[n]; n == top || (ok && score >= threshold) {
+			nodes = append(nodes, n)
+			continue
+		}
+		if n.DataAtom != atom.P {
+			continue
+		}
+		text := strings.TrimSpace(innerText(n))
+		length := utf8.RuneCountInString(text)
+		density := linkDensity(n)
+		if (length > 80 && density < 0.25) ||
+			(length > 0 && density == 0 && (strings.Contains(text, ". ") || strings.Contains(text, "。"))) {
+			nodes = append(nodes, n)
+		}
+	}
+	return nodes
+}
+
+// cleanConditionally 移除正文中链接过多或权重为负的列表、表格与区块
+func cleanConditionally(root *html.Node) {
+	var removed []*html.Node
+	walk(root, func(n *html.Node) bool {
+		if n == root || n.Type != html.ElementNode {
+			return true
+		}
+		switch n.DataAtom {
+		case atom.Div, atom.Section, atom.Ul, atom.Ol, atom.Table, atom.Header:
+		default:
+			return true
+		}
+		length := utf8.RuneCountInString(strings.TrimSpace(innerText(n)))
+		density := linkDensity(n)
+		if classWeight(n) < 0 || (density > 0.5 && length < 200) || (n.DataAtom == atom.Header && length < 200) {
+			if countTags(n, atom.Img) == 0 || classWeight(n) < 0 {
+				removed = append(removed, n)
+				return false
+			}
+		}
+		return true
+	})
+	for _, n := range removed {
+		n.Parent.RemoveChild(n)
+	}
+}
+
+// resolveLinks 将链接与图片地址转换为绝对地址
+func resolveLinks(root *html.Node, base *url.URL) {
+	if base == nil {
+		return
+	}
+	walk(root, func(n *html.Node) bool {
+		if n.Type != html.ElementNode {
+			return true
+		}
+		for i, a := range n.Attr {
+			if a.Key != "href" && a.Key != "src" {
+				continue
+			}
+			if u, err := url.Parse(strings.TrimSpace(a.Val)); err == nil && !u.IsAbs() {
+				n.Attr[i].Val = base.ResolveReference(u).String()
+			}
+		}
+		return true
+	})
+}
+
+// linkDensity 链接文字占全部文字的比例
+func linkDensity(n *html.Node) float64 {
+	length := utf8.RuneCountInString(innerText(n))
+	if length == 0 {
+		return 0
+	}
+	linkLength := 0
+	walk(n, func(c *html.Node) bool {
+		if c.DataAtom == atom.A {
+			linkLength += utf8.RuneCountInString(innerText(c))
+			return false
+		}
+		return true
+	})
+	return float64(linkLength) / float64(length)
+}
+
+func countTags(n *html.Node, tag atom.Atom) int {
+	count := 0
+	walk(n, func(c *html.Node) bool {
+		if c.DataAtom == tag {
+			count++
+		}
+		return true
+	})
+	return count
+}
+
+// innerText 节点的文字内容，连续空白合并为一个空格
+func innerText(n *html.Node) string {
+	var b strings.Builder
+	walk(n, func(c *html.Node) bool {
+		if c.Type == html.TextNode {
+			b.WriteString(c.Data)
+		}
+		return true
+	})
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// walk 深度优先遍历，fn 返回 false 时跳过子节点
+func walk(n *html.Node, fn func(*html.Node) bool) {
+	if !fn(n) {
+		return
+	}
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		walk(c, fn)
+		c = next
+	}
+}
+
+func getAttr(n *html.Node, key string) string {
+	v, _ := attrValue(n, key)
+	return v
+}
+
+func attrValue(n *html.Node, key string) (string, bool) {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
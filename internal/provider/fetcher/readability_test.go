@@ -0,0 +1,81 @@
+package fetcher
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExtractArticle(t *testing.T) {
+	tests := []struct {
+		name        string
+		file        string
+		contentType string
+		pageURL     string
+		wantTitle   string
+		contains    []string
+		excludes    []string
+		wantErr     error
+	}{
+		{
+			name:        "blog",
+			file:        "blog.html",
+			contentType: "text/html; charset=utf-8",
+			pageURL:     "https://blog.example.com/posts/interfaces",
+			wantTitle:   "Understanding Go Interfaces",
+			contains: []string{
+				"Interfaces in Go are satisfied implicitly",
+				"small interfaces make fakes trivial",
+				`src="https://blog.example.com/images/interfaces.png"`,
+				`href="https://go.dev/doc/effective_go"`,
+			},
+			excludes: []string{"Popular posts", "Archive", "Great article", "Copyright", "Tweet", "analytics"},
+		},
+		{
+			name:      "gbk meta charset",
+			file:      "gbk.html",
+			pageURL:   "https://news.example.com/1.html",
+			wantTitle: "新闻标题 - 示例新闻网",
+			contains:  []string{"这项新技术在过去一年中取得了显著进展", "人才短缺"},
+			excludes:  []string{"首页", "相关阅读"},
+		},
+		{
+			name:    "no article",
+			file:    "short.html",
+			wantErr: ErrArticleNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := ioutil.ReadFile(filepath.Join("testdata", tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := ExtractArticle(data, tt.contentType, tt.pageURL)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ExtractArticle() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ExtractArticle() error = %v", err)
+			}
+			if got.Title != tt.wantTitle {
+				t.Errorf("ExtractArticle() title = %q, want %q", got.Title, tt.wantTitle)
+			}
+			for _, s := range tt.contains {
+				if !strings.Contains(got.Content, s) {
+					t.Errorf("ExtractArticle() content missing %q\n%s", s, got.Content)
+				}
+			}
+			for _, s := range tt.excludes {
+				if strings.Contains(got.Content, s) {
+					t.Errorf("ExtractArticle() content contains %q\n%s", s, got.Content)
+				}
+			}
+		})
+	}
+}
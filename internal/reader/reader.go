@@ -0,0 +1,195 @@
+// Package reader 内置阅读页面，以净化后的 HTML 展示已保存的文章，可代替 telegraph 页面
+package reader
+
+import (
+	"bytes"
+	"html"
+	"html/template"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/indes/flowerss-bot/internal/config"
+	"github.com/indes/flowerss-bot/internal/i18n"
+	"github.com/indes/flowerss-bot/internal/model"
+	"github.com/indes/flowerss-bot/internal/tgraph"
+
+	"github.com/indes/telegraph-go"
+	"go.uber.org/zap"
+)
+
+const articlePath = "/a/"
+
+var (
+	hashIDRegexp = regexp.MustCompile(`^[0-9a-f]{1,64}$`)
+
+	// voidTags 没有结束标签的元素
+	voidTags = map[string]bool{"br": true, "hr": true, "img": true}
+
+	pageTpl = template.Must(template.New("article").Parse(`<!DOCTYPE html>
+<html lang="{{.Lang}}">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="referrer" content="no-referrer">
+<title>{{.Title}}</title>
+<style>
+body{max-width:720px;margin:0 auto;padding:16px;font:17px/1.7 -apple-system,"Segoe UI",Roboto,"PingFang SC","Microsoft YaHei",sans-serif;color:#222;word-wrap:break-word}
+h1{font-size:26px;line-height:1.3}
+.meta{color:#888;font-size:14px}
+img,video{max-width:100%;height:auto}
+pre{overflow:auto;background:#f5f5f5;padding:8px}
+blockquote{margin:0;padding-left:16px;border-left:3px solid #ddd;color:#555}
+a{color:#2a6fdb}
+footer{margin-top:32px;padding-top:8px;border-top:1px solid #eee;color:#888;font-size:14px}
+</style>
+</head>
+<body>
+<article>
+<h1>{{.Title}}</h1>
+<p class="meta">{{if .SourceLink}}<a href="{{.SourceLink}}">{{.SourceTitle}}</a>{{else}}{{.SourceTitle}}{{end}}
+{{- if .Author}} · {{.Author}}{{end}}{{if .PublishedAt}} · {{.PublishedAt}}{{end}}</p>
+{{.Body}}
+</article>
+<footer>
+<p>{{.RawLinkText}}<a href="{{.RawLink}}">{{.RawLink}}</a></p>
+</footer>
+</body>
+</html>
+`))
+)
+
+// page 阅读页面模版数据
+type page struct {
+	Title       string
+	SourceTitle string
+	SourceLink  string
+	Author      string
+	PublishedAt string
+	RawLink     string
+	RawLinkText string
+	Lang        string
+	Body        template.HTML
+}
+
+// Enabled 是否启用阅读页面
+func Enabled() bool {
+	return config.EnableReader
+}
+
+// URL 文章的阅读页面地址
+func URL(hashID string) string {
+	return config.ReaderBaseURL + articlePath + hashID
+}
+
+// NewHandler 阅读页面的 HTTP 处理器
+func NewHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(articlePath, articleHandler)
+	return mux
+}
+
+// articleHandler 展示文章，只展示仍有订阅的源的内容
+func articleHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	hashID := strings.TrimPrefix(r.URL.Path, articlePath)
+	if !hashIDRegexp.MatchString(hashID) {
+		http.NotFound(w, r)
+		return
+	}
+	content, err := model.GetContentByHashID(hashID)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	source, err := model.GetSourceById(content.SourceID)
+	if err != nil || len(model.GetSubscriberBySource(source)) == 0 {
+		http.NotFound(w, r)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := pageTpl.Execute(&buf, newPage(source, content)); err != nil {
+		zap.S().Errorw("render reader page failed", "content", hashID, "error", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	header := w.Header()
+	header.Set("Content-Type", "text/html; charset=utf-8")
+	header.Set("Content-Security-Policy",
+		"default-src 'none'; img-src http: https: data:; media-src http: https:; style-src 'unsafe-inline'")
+	header.Set("X-Content-Type-Options", "nosniff")
+	header.Set("Referrer-Policy", "no-referrer")
+	header.Set("Cache-Control", "public, max-age=3600")
+	_, _ = w.Write(buf.Bytes())
+}
+
+func newPage(source *model.Source, content *model.Content) *page {
+	p := &page{
+		Title:       content.Title,
+		SourceTitle: source.Title,
+		SourceLink:  source.SiteLink,
+		Author:      content.Author,
+		RawLink:     content.RawLink,
+		Lang:        i18n.Default(),
+	}
+	p.RawLinkText = i18n.T(p.Lang, "telegraph.raw")
+	if content.PublishedAt != nil {
+		p.PublishedAt = content.PublishedAt.In(time.Local).Format("2006-01-02 15:04")
+	}
+
+	var body strings.Builder
+	renderNodes(&body, tgraph.Sanitize(content.Body(), content.RawLink))
+	p.Body = template.HTML(body.String())
+	return p
+}
+
+// renderNodes 将净化后的节点输出为 HTML，telegraph 视频嵌入转换为链接
+func renderNodes(b *strings.Builder, nodes []telegraph.Node) {
+	for _, n := range nodes {
+		switch v := n.(type) {
+		case string:
+			b.WriteString(html.EscapeString(v))
+		case telegraph.NodeElement:
+			if v.Tag == "iframe" {
+				renderEmbed(b, v.Attrs["src"])
+				continue
+			}
+			b.WriteString("<" + v.Tag)
+			for _, key := range []string{"href", "src"} {
+				if value, ok := v.Attrs[key]; ok {
+					b.WriteString(" " + key + `="` + html.EscapeString(value) + `"`)
+				}
+			}
+			if v.Tag == "video" {
+				b.WriteString(" controls")
+			}
+			b.WriteString(">")
+			if voidTags[v.Tag] {
+				continue
+			}
+			renderNodes(b, v.Children)
+			b.WriteString("</" + v.Tag + ">")
+		}
+	}
+}
+
+// renderEmbed telegraph 嵌入地址 /embed/youtube?url=... 转换为视频链接
+func renderEmbed(b *strings.Builder, src string) {
+	u, err := url.Parse(src)
+	if err != nil {
+		return
+	}
+	link := u.Query().Get("url")
+	if !strings.HasPrefix(link, "https://") {
+		return
+	}
+	link = html.EscapeString(link)
+	b.WriteString(`<a href="` + link + `">` + link + `</a>`)
+}
@@ -0,0 +1,83 @@
+package reader
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/indes/flowerss-bot/internal/model"
+	"github.com/indes/flowerss-bot/internal/tgraph"
+)
+
+func Test_renderNodes(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{
+			"paragraph and link",
+			`<p>a &lt; b <a href="/x">link</a></p>`,
+			`<p>a &lt; b <a href="https://example.com/x">link</a></p>`,
+		},
+		{
+			"image",
+			`<img src="https://example.com/a.png" onerror="alert(1)">`,
+			`<img src="https://example.com/a.png">`,
+		},
+		{
+			"script removed",
+			`<p>text</p><script>alert(1)</script>`,
+			`<p>text</p>`,
+		},
+		{
+			"youtube embed",
+			`<iframe src="https://www.youtube.com/embed/abc"></iframe>`,
+			`<figure><a href="https://www.youtube.com/watch?v=abc">https://www.youtube.com/watch?v=abc</a></figure>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			renderNodes(&b, tgraph.Sanitize(tt.src, "https://example.com/post"))
+			if got := b.String(); got != tt.want {
+				t.Errorf("renderNodes() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_newPage(t *testing.T) {
+	source := &model.Source{Title: "<Blog>", SiteLink: "https://example.com"}
+	content := &model.Content{
+		Title:       "Hello <b>",
+		RawLink:     "https://example.com/1",
+		Description: "<p>body</p>",
+	}
+	var b strings.Builder
+	if err := pageTpl.Execute(&b, newPage(source, content)); err != nil {
+		t.Fatal(err)
+	}
+	got := b.String()
+	for _, want := range []string{
+		"<title>Hello &lt;b&gt;</title>",
+		`<a href="https://example.com">&lt;Blog&gt;</a>`,
+		"<p>body</p>",
+		`<a href="https://example.com/1">`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("page missing %s\n%s", want, got)
+		}
+	}
+}
+
+func Test_articleHandler_invalidPath(t *testing.T) {
+	for _, path := range []string{"/a/", "/a/../../etc/passwd", "/a/XYZ"} {
+		rec := httptest.NewRecorder()
+		NewHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusNotFound && rec.Code != http.StatusMovedPermanently {
+			t.Errorf("%s status = %d, want 404", path, rec.Code)
+		}
+	}
+}
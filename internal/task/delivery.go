@@ -0,0 +1,205 @@
+package task
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/indes/flowerss-bot/internal/bot"
+	"github.com/indes/flowerss-bot/internal/config"
+	"github.com/indes/flowerss-bot/internal/model"
+
+	"go.uber.org/atomic"
+	"go.uber.org/zap"
+)
+
+const (
+	deliveryBatchSize    = 200
+	deliveryWorkers      = 8
+	deliveryIdleInterval = 5 * time.Second
+	deliveryRetention    = 7 * 24 * time.Hour
+)
+
+var deliveryTask = NewDeliveryTask()
+
+func init() {
+	registerTask(deliveryTask)
+}
+
+// NewDeliveryTask new DeliveryTask
+func NewDeliveryTask() *DeliveryTask {
+	return &DeliveryTask{
+		wake: make(chan struct{}, 1),
+		busy: make(map[int64]bool),
+	}
+}
+
+// DeliveryTask 消息投递任务，从投递队列中取出消息发送给订阅者
+//
+// 每个会话由独立的 worker 按入队顺序投递，同时投递的会话数不超过 deliveryWorkers，
+// 单个会话发送缓慢或被限流时不影响其他会话
+type DeliveryTask struct {
+	isStop atomic.Bool
+	wake   chan struct{}
+
+	mu sync.Mutex
+	// busy 正在投递的会话
+	busy map[int64]bool
+}
+
+// Name 任务名称
+func (t *DeliveryTask) Name() string {
+	return "DeliveryTask"
+}
+
+// Notify 通知任务有新的待投递消息
+func (t *DeliveryTask) Notify() {
+	select {
+	case t.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Stop stop task
+func (t *DeliveryTask) Stop() {
+	t.isStop.Store(true)
+	t.Notify()
+}
+
+// Start run task
+func (t *DeliveryTask) Start() {
+	if config.RunMode == config.TestMode {
+		return
+	}
+
+	t.isStop.Store(false)
+
+	go func() {
+		lastPrune := time.Time{}
+		for {
+			if t.isStop.Load() {
+				zap.S().Info("DeliveryTask stopped")
+				return
+			}
+
+			if time.Since(lastPrune) > time.Hour {
+				if err := model.PruneDeliveries(time.Now().Add(-deliveryRetention)); err != nil {
+					zap.S().Warnw("prune deliveries failed", "error", err)
+				}
+				lastPrune = time.Now()
+			}
+
+			var deliveries []*model.Delivery
+			if busy, full := t.busyChats(); !full {
+				var err error
+				deliveries, err = model.GetDueDeliveries(deliveryBatchSize, busy)
+				if err != nil {
+					zap.S().Errorw("get due deliveries failed", "error", err)
+				}
+				t.dispatch(deliveries)
+			}
+			if _, full := t.busyChats(); full || len(deliveries) < deliveryBatchSize {
+				// worker 退出时会唤醒任务
+				select {
+				case <-t.wake:
+				case <-time.After(deliveryIdleInterval):
+				}
+			}
+		}
+	}()
+}
+
+// busyChats 正在投递的会话，以及是否已达到同时投递的会话数上限
+func (t *DeliveryTask) busyChats() ([]int64, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	chatIDs := make([]int64, 0, len(t.busy))
+	for chatID := range t.busy {
+		chatIDs = append(chatIDs, chatID)
+	}
+	return chatIDs, len(t.busy) >= deliveryWorkers
+}
+
+// dispatch 按会话分组，为空闲的会话启动 worker，超出上限的会话留待下次获取
+func (t *DeliveryTask) dispatch(deliveries []*model.Delivery) {
+	var chatIDs []int64
+	chats := make(map[int64][]*model.Delivery)
+	for _, d := range deliveries {
+		if _, ok := chats[d.UserID]; !ok {
+			chatIDs = append(chatIDs, d.UserID)
+		}
+		chats[d.UserID] = append(chats[d.UserID], d)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, chatID := range chatIDs {
+		if len(t.busy) >= deliveryWorkers {
+			return
+		}
+		if t.busy[chatID] {
+			continue
+		}
+		t.busy[chatID] = true
+		go t.work(chatID, chats[chatID])
+	}
+}
+
+// work 按入队顺序投递会话的消息，队列投递完后继续获取该会话的待投递消息，没有时退出
+func (t *DeliveryTask) work(chatID int64, list []*model.Delivery) {
+	defer func() {
+		t.mu.Lock()
+		delete(t.busy, chatID)
+		t.mu.Unlock()
+		t.Notify()
+	}()
+
+	for len(list) > 0 {
+		for _, d := range list {
+			if t.isStop.Load() {
+				return
+			}
+			deliverOne(d)
+		}
+		var err error
+		if list, err = model.GetDueDeliveriesByUserID(chatID, deliveryBatchSize); err != nil {
+			zap.S().Errorw("get due deliveries failed", "user id", chatID, "error", err)
+			return
+		}
+	}
+}
+
+// deliverOne 投递一条消息并记录结果
+func deliverOne(d *model.Delivery) {
+	sub, err := model.GetSubscribeByID(int(d.SubscribeID))
+	if err != nil {
+		_ = d.MarkFailed(errors.New("subscription not found"), true)
+		return
+	}
+	source, err := model.GetSourceById(d.SourceID)
+	if err != nil {
+		_ = d.MarkFailed(err, true)
+		return
+	}
+	content, err := model.GetContentByHashID(d.ContentHashID)
+	if err != nil {
+		_ = d.MarkFailed(errors.New("content not found"), true)
+		return
+	}
+
+	messageID, err := bot.SendNews(source, sub, content)
+	if err != nil {
+		permanent := bot.IsPermanentSendError(err)
+		zap.S().Warnw("deliver news failed",
+			"delivery id", d.ID,
+			"user id", d.UserID,
+			"attempts", d.Attempts+1,
+			"permanent", permanent,
+			"error", err.Error(),
+		)
+		_ = d.MarkFailed(err, permanent)
+		return
+	}
+	_ = d.MarkSent(messageID)
+}
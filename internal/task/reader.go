@@ -0,0 +1,57 @@
+package task
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/indes/flowerss-bot/internal/config"
+	"github.com/indes/flowerss-bot/internal/reader"
+
+	"go.uber.org/zap"
+)
+
+func init() {
+	registerTask(&ReaderTask{})
+}
+
+// ReaderTask 内置阅读页面的 HTTP 服务
+type ReaderTask struct {
+	server *http.Server
+}
+
+// Name 任务名称
+func (t *ReaderTask) Name() string {
+	return "ReaderTask"
+}
+
+// Start run task
+func (t *ReaderTask) Start() {
+	if config.RunMode == config.TestMode || !reader.Enabled() {
+		return
+	}
+
+	t.server = &http.Server{
+		Addr:         config.ReaderListen,
+		Handler:      reader.NewHandler(),
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 30 * time.Second,
+	}
+	go func(server *http.Server) {
+		zap.S().Infow("reader server started", "listen", server.Addr, "base url", config.ReaderBaseURL)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			zap.S().Errorw("reader server stopped", "error", err)
+		}
+	}(t.server)
+}
+
+// Stop stop task
+func (t *ReaderTask) Stop() {
+	if t.server == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_ = t.server.Shutdown(ctx)
+	zap.S().Info("ReaderTask stopped")
+}
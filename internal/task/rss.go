@@ -138,7 +138,15 @@ type telegramBotRssUpdateObserver struct {
 func (o *telegramBotRssUpdateObserver) update(
 	source *model.Source, newContents []*model.Content, subscribes []*model.Subscribe) {
 	zap.S().Debugf("%v receiving [%d]%v update", o.id(), source.ID, source.Title)
-	bot.BroadcastNews(source, subscribes, newContents)
+	// 投递记录已随新内容一起保存
+	zap.S().Infow("enqueue news",
+		"fetcher id", source.ID,
+		"fetcher title", source.Title,
+		"subscriber count", len(subscribes),
+		"new contents", len(newContents),
+	)
+	deliveryTask.Notify()
+	telegraphTask.Notify()
 }
 
 func (o *telegramBotRssUpdateObserver) errorUpdate(source *model.Source) {
@@ -0,0 +1,52 @@
+package task
+
+import (
+	"time"
+
+	"github.com/indes/flowerss-bot/internal/bot"
+	"github.com/indes/flowerss-bot/internal/config"
+
+	"go.uber.org/atomic"
+	"go.uber.org/zap"
+)
+
+const stateCheckInterval = time.Minute
+
+func init() {
+	registerTask(&StateTask{})
+}
+
+// StateTask 定期取消已超时的多步操作
+type StateTask struct {
+	isStop atomic.Bool
+}
+
+// Name 任务名称
+func (t *StateTask) Name() string {
+	return "StateTask"
+}
+
+// Start run task
+func (t *StateTask) Start() {
+	if config.RunMode == config.TestMode || config.ConversationTimeout <= 0 {
+		return
+	}
+
+	t.isStop.Store(false)
+
+	go func() {
+		for {
+			if t.isStop.Load() {
+				zap.S().Info("StateTask stopped")
+				return
+			}
+			bot.ExpireStates()
+			time.Sleep(stateCheckInterval)
+		}
+	}()
+}
+
+// Stop stop task
+func (t *StateTask) Stop() {
+	t.isStop.Store(true)
+}
@@ -0,0 +1,208 @@
+package task
+
+import (
+	"errors"
+	"time"
+
+	"github.com/indes/flowerss-bot/internal/bot"
+	"github.com/indes/flowerss-bot/internal/config"
+	"github.com/indes/flowerss-bot/internal/i18n"
+	"github.com/indes/flowerss-bot/internal/model"
+	"github.com/indes/flowerss-bot/internal/tgraph"
+
+	"go.uber.org/atomic"
+	"go.uber.org/zap"
+)
+
+const (
+	telegraphBatchSize    = 20
+	telegraphIdleInterval = 10 * time.Second
+	// telegraphUnavailableRetry 所有 token 均暂停使用时的重试间隔
+	telegraphUnavailableRetry = time.Minute
+)
+
+var telegraphTask = NewTelegraphTask()
+
+func init() {
+	registerTask(telegraphTask)
+}
+
+// NewTelegraphTask new TelegraphTask
+func NewTelegraphTask() *TelegraphTask {
+	return &TelegraphTask{
+		wake: make(chan struct{}, 1),
+	}
+}
+
+// TelegraphTask telegraph 发布任务，从发布队列中取出内容生成页面，并为已发送的消息补充链接
+type TelegraphTask struct {
+	isStop atomic.Bool
+	wake   chan struct{}
+}
+
+// Name 任务名称
+func (t *TelegraphTask) Name() string {
+	return "TelegraphTask"
+}
+
+// Notify 通知任务有新的待发布内容
+func (t *TelegraphTask) Notify() {
+	select {
+	case t.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Stop stop task
+func (t *TelegraphTask) Stop() {
+	t.isStop.Store(true)
+	t.Notify()
+}
+
+// Start run task
+func (t *TelegraphTask) Start() {
+	if config.RunMode == config.TestMode || !tgraph.Enabled() {
+		return
+	}
+
+	t.isStop.Store(false)
+
+	go func() {
+		lastPrune := time.Time{}
+		for {
+			if t.isStop.Load() {
+				zap.S().Info("TelegraphTask stopped")
+				return
+			}
+
+			if time.Since(lastPrune) > time.Hour {
+				if err := model.PrunePublications(time.Now().Add(-deliveryRetention)); err != nil {
+					zap.S().Warnw("prune telegraph publications failed", "error", err)
+				}
+				lastPrune = time.Now()
+			}
+
+			publications, err := model.GetDuePublications(telegraphBatchSize)
+			if err != nil {
+				zap.S().Errorw("get due telegraph publications failed", "error", err)
+			}
+			for _, p := range publications {
+				if t.isStop.Load() {
+					break
+				}
+				if !publishOne(p) {
+					break
+				}
+			}
+			if len(publications) < telegraphBatchSize {
+				select {
+				case <-t.wake:
+				case <-time.After(telegraphIdleInterval):
+				}
+			}
+		}
+	}()
+}
+
+// publishOne 生成一篇内容的 telegraph 页面，返回 false 时表示暂无可用 token
+func publishOne(p *model.Publication) bool {
+	content, err := model.GetContentByHashID(p.ContentHashID)
+	if err != nil {
+		_ = p.MarkFailed(errors.New("content not found"), 0, true)
+		return true
+	}
+	source, err := model.GetSourceById(content.SourceID)
+	if err != nil {
+		_ = p.MarkFailed(err, 0, true)
+		return true
+	}
+
+	var (
+		account *tgraph.Account
+		lang    string
+	)
+	if p.UserID == 0 {
+		if content.TelegraphURL != "" {
+			_ = p.MarkDone(content.TelegraphURL)
+			return true
+		}
+	} else {
+		a, err := model.GetTelegraphAccountByUserID(p.UserID)
+		if err != nil {
+			// 账号已删除，该会话改用全局账号发布的页面
+			_ = p.MarkFailed(errors.New("telegraph account not found"), 0, true)
+			return true
+		}
+		account = &tgraph.Account{AccessToken: a.AccessToken, AuthorName: a.AuthorName, AuthorURL: a.AuthorURL}
+		// 会话自有的页面使用会话设置的语言
+		lang = i18n.Parse(model.GetLanguageByUserId(p.UserID))
+	}
+
+	url, err := tgraph.PublishHtml(&tgraph.Article{
+		SourceTitle:  source.Title,
+		SourceLink:   source.SiteLink,
+		ContentTitle: content.Title,
+		RawLink:      content.RawLink,
+		Content:      content.Body(),
+		Lang:         lang,
+		Created:      p.CreatedPages(),
+		OnCreated: func(created []string) {
+			if err := p.SavePages(created); err != nil {
+				zap.S().Warnw("save telegraph pages failed", "content", content.HashID, "error", err)
+			}
+		},
+	}, account)
+	if err != nil {
+		var floodErr *tgraph.FloodWaitError
+		switch {
+		case errors.As(err, &floodErr):
+			_ = p.MarkFailed(err, floodErr.Wait, false)
+		case errors.Is(err, tgraph.ErrNoAvailableClient):
+			_ = p.MarkFailed(err, telegraphUnavailableRetry, false)
+			return false
+		default:
+			_ = p.MarkFailed(err, 0, tgraph.IsContentError(err))
+		}
+		zap.S().Warnw("publish telegraph page failed",
+			"content", content.HashID,
+			"user id", p.UserID,
+			"attempts", p.Attempts,
+			"error", err.Error(),
+		)
+		return true
+	}
+	if err := p.MarkDone(url); err != nil {
+		zap.S().Errorw("save telegraph page failed", "content", content.HashID, "error", err)
+		return true
+	}
+	if p.UserID == 0 {
+		content.TelegraphURL = url
+	}
+	updateSentNews(p, source, content)
+	return true
+}
+
+// updateSentNews 为已发送的消息补充 telegraph 链接，自有账号的会话只使用以其账号发布的页面
+func updateSentNews(p *model.Publication, source *model.Source, content *model.Content) {
+	deliveries, err := model.GetSentDeliveriesByContent(content.HashID)
+	if err != nil {
+		zap.S().Warnw("get sent deliveries failed", "content", content.HashID, "error", err)
+		return
+	}
+	for _, d := range deliveries {
+		if p.UserID != 0 && d.UserID != p.UserID {
+			continue
+		}
+		sub, err := model.GetSubscribeByID(int(d.SubscribeID))
+		if err != nil {
+			continue
+		}
+		if err := bot.UpdateNewsTelegraph(source, sub, content, d.MessageID); err != nil {
+			zap.S().Warnw("update news telegraph link failed",
+				"delivery id", d.ID,
+				"user id", d.UserID,
+				"error", err.Error(),
+			)
+		}
+	}
+}
@@ -0,0 +1,426 @@
+// Package tghtml 将 feed 中的 HTML 转换为 telegram 支持的 HTML 子集
+package tghtml
+
+import (
+	"html"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode"
+
+	nethtml "golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+const ellipsis = "…"
+
+// inlineTags telegram 支持的行内标签，value 为转换后的标签名
+var inlineTags = map[atom.Atom]string{
+	atom.B:      "b",
+	atom.Strong: "b",
+	atom.I:      "i",
+	atom.Em:     "i",
+	atom.U:      "u",
+	atom.Ins:    "u",
+	atom.S:      "s",
+	atom.Strike: "s",
+	atom.Del:    "s",
+	atom.Code:   "code",
+}
+
+// droppedTags 连同内容一起丢弃的标签
+var droppedTags = map[atom.Atom]bool{
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Noscript: true,
+	atom.Head:     true,
+	atom.Title:    true,
+	atom.Img:      true,
+	atom.Picture:  true,
+	atom.Video:    true,
+	atom.Audio:    true,
+	atom.Iframe:   true,
+	atom.Object:   true,
+	atom.Embed:    true,
+	atom.Svg:      true,
+	atom.Math:     true,
+	atom.Form:     true,
+	atom.Button:   true,
+	atom.Select:   true,
+	atom.Textarea: true,
+	atom.Template: true,
+}
+
+// blockTags 前后需要换行的块级标签，value 为换行数
+var blockTags = map[atom.Atom]int{
+	atom.P:          2,
+	atom.H1:         2,
+	atom.H2:         2,
+	atom.H3:         2,
+	atom.H4:         2,
+	atom.H5:         2,
+	atom.H6:         2,
+	atom.Ul:         2,
+	atom.Ol:         2,
+	atom.Table:      2,
+	atom.Figure:     2,
+	atom.Hr:         2,
+	atom.Div:        1,
+	atom.Section:    1,
+	atom.Article:    1,
+	atom.Header:     1,
+	atom.Footer:     1,
+	atom.Tr:         1,
+	atom.Dl:         1,
+	atom.Dt:         1,
+	atom.Dd:         1,
+	atom.Figcaption: 1,
+}
+
+// Convert 将 HTML 转换为 telegram HTML，相对链接基于 base 解析，
+// 可见字符超过 limit 时在字符边界截断并补全标签，limit 小于等于 0 时不截断
+func Convert(src, base string, limit int) string {
+	return convert(src, base, 0, limit, true)
+}
+
+// Text 将 HTML 转换为纯文本，截断规则同 Convert
+func Text(src string, limit int) string {
+	return convert(src, "", 0, limit, false)
+}
+
+// ConvertRest 转换 HTML 并跳过前 skip 个可见字符，以省略号开头，用于输出 Convert 截断后剩余的部分，
+// limit 为包含跳过部分在内的可见字符数
+func ConvertRest(src, base string, skip, limit int) string {
+	return convert(src, base, skip, limit, true)
+}
+
+// TextRest 同 ConvertRest，转换为纯文本
+func TextRest(src string, skip, limit int) string {
+	return convert(src, "", skip, limit, false)
+}
+
+func convert(src, base string, skip, limit int, markup bool) string {
+	nodes, err := nethtml.ParseFragment(strings.NewReader(src), &nethtml.Node{
+		Type:     nethtml.ElementNode,
+		Data:     "body",
+		DataAtom: atom.Body,
+	})
+	if err != nil {
+		return ""
+	}
+
+	w := &writer{limit: limit, skip: skip, markup: markup}
+	if u, err := url.Parse(base); err == nil && u.IsAbs() {
+		w.base = u
+	}
+	for _, n := range nodes {
+		w.walk(n)
+	}
+	w.closeAll()
+	return strings.TrimSpace(w.buf.String())
+}
+
+// writer 输出转换结果，记录可见字符数与未闭合的标签
+type writer struct {
+	buf    strings.Builder
+	markup bool
+	base   *url.URL
+	limit  int
+	skip   int
+	lead   string
+
+	count   int
+	full    bool
+	newline int
+	space   bool
+	started bool
+	pre     int
+	link    bool
+	open    []*openTag
+}
+
+type openTag struct {
+	name    string
+	href    string
+	written bool
+}
+
+// walk 深度优先遍历节点
+func (w *writer) walk(n *nethtml.Node) {
+	if w.full {
+		return
+	}
+	switch n.Type {
+	case nethtml.TextNode:
+		w.text(n.Data)
+		return
+	case nethtml.ElementNode:
+	case nethtml.DocumentNode:
+		w.children(n)
+		return
+	default:
+		return
+	}
+
+	if droppedTags[n.DataAtom] {
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.Br:
+		w.breakLine(1)
+		return
+	case atom.Pre:
+		w.breakLine(2)
+		w.pre++
+		w.tag("pre", "", func() { w.children(n) })
+		w.pre--
+		w.breakLine(2)
+		return
+	case atom.Blockquote:
+		w.breakLine(2)
+		w.tag("blockquote", "", func() { w.children(n) })
+		w.breakLine(2)
+		return
+	case atom.A:
+		href := w.resolve(attr(n, "href"))
+		if href == "" || w.link {
+			w.children(n)
+			return
+		}
+		w.link = true
+		w.tag("a", href, func() { w.children(n) })
+		w.link = false
+		return
+	case atom.Li:
+		w.breakLine(1)
+		w.text(listMarker(n))
+		w.children(n)
+		w.breakLine(1)
+		return
+	case atom.Td, atom.Th:
+		w.children(n)
+		w.space = true
+		return
+	}
+
+	if name, ok := inlineTags[n.DataAtom]; ok {
+		if name == "code" && w.pre > 0 {
+			w.children(n)
+			return
+		}
+		w.tag(name, "", func() { w.children(n) })
+		return
+	}
+
+	lines := blockTags[n.DataAtom]
+	w.breakLine(lines)
+	w.children(n)
+	w.breakLine(lines)
+}
+
+func (w *writer) children(n *nethtml.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		w.walk(c)
+	}
+}
+
+// tag 输出标签及其内容，标签在内容输出前才写入，内容为空时不输出标签
+func (w *writer) tag(name, href string, content func()) {
+	if !w.markup || w.full {
+		content()
+		return
+	}
+	w.open = append(w.open, &openTag{name: name, href: href})
+	depth := len(w.open)
+
+	content()
+
+	if len(w.open) < depth {
+		// 已在截断时闭合
+		return
+	}
+	t := w.open[depth-1]
+	w.open = w.open[:depth-1]
+	if t.written {
+		w.buf.WriteString("</" + t.name + ">")
+	}
+}
+
+// openTags 写入尚未写入的标签
+func (w *writer) openTags() {
+	for _, t := range w.open {
+		if t.written {
+			continue
+		}
+		if t.href != "" {
+			w.buf.WriteString(`<a href="` + html.EscapeString(t.href) + `">`)
+		} else {
+			w.buf.WriteString("<" + t.name + ">")
+		}
+		t.written = true
+	}
+}
+
+// closeAll 闭合所有已写入的标签
+func (w *writer) closeAll() {
+	for i := len(w.open) - 1; i >= 0; i-- {
+		if w.open[i].written {
+			w.buf.WriteString("</" + w.open[i].name + ">")
+		}
+	}
+	w.open = nil
+}
+
+// breakLine 在下一段文字前插入换行
+func (w *writer) breakLine(n int) {
+	if n > w.newline {
+		w.newline = n
+	}
+}
+
+// pendingSpace 取出挂起的换行或空格
+func (w *writer) pendingSpace() string {
+	var space string
+	if w.started {
+		if w.newline > 0 {
+			space = strings.Repeat("\n", w.newline)
+		} else if w.space {
+			space = " "
+		}
+	}
+	w.newline = 0
+	w.space = false
+	return space
+}
+
+func (w *writer) text(s string) {
+	if w.pre == 0 {
+		var b strings.Builder
+		for _, r := range s {
+			if unicode.IsSpace(r) {
+				if b.Len() == 0 {
+					w.space = true
+				} else {
+					w.emit(b.String())
+					b.Reset()
+					w.space = true
+				}
+				continue
+			}
+			b.WriteRune(r)
+		}
+		if b.Len() > 0 {
+			w.emit(b.String())
+		}
+		return
+	}
+	w.emit(s)
+}
+
+// emit 按可见字符计数输出一段文字，超出 limit 时截断并闭合标签
+func (w *writer) emit(s string) {
+	if s == "" || w.full {
+		return
+	}
+	space := w.pendingSpace()
+	if w.skip > 0 {
+		// 跳过的文字同样计数，换行与空格在剩余部分开头时丢弃
+		w.started = true
+		skipped := []rune(space + s)
+		if len(skipped) <= w.skip {
+			w.skip -= len(skipped)
+			w.count += len(skipped)
+			if w.skip == 0 {
+				w.lead = ellipsis
+			}
+			return
+		}
+		w.count += w.skip
+		s = strings.TrimLeftFunc(string(skipped[w.skip:]), unicode.IsSpace)
+		w.count += len(skipped) - w.skip - len([]rune(s))
+		w.skip = 0
+		w.lead = ellipsis
+		if s == "" {
+			return
+		}
+	}
+	if w.lead != "" {
+		space, w.lead = w.lead, ""
+	}
+	runes := []rune(s)
+	n := len([]rune(space)) + len(runes)
+	if w.limit > 0 && w.count+n > w.limit {
+		if keep := w.limit - w.count - 1 - len([]rune(space)); keep > 0 {
+			w.buf.WriteString(space)
+			w.openTags()
+			w.writeEscaped(strings.TrimRightFunc(string(runes[:keep]), unicode.IsSpace))
+		}
+		w.buf.WriteString(ellipsis)
+		w.count = w.limit
+		w.full = true
+		w.closeAll()
+		return
+	}
+	w.buf.WriteString(space)
+	w.openTags()
+	w.started = true
+	w.count += n
+	w.writeEscaped(s)
+}
+
+func (w *writer) writeEscaped(s string) {
+	if w.markup {
+		s = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+	}
+	w.buf.WriteString(s)
+}
+
+// resolve 解析链接地址，仅保留 http、https、mailto 与 tg 协议
+func (w *writer) resolve(href string) string {
+	href = strings.TrimSpace(href)
+	if href == "" {
+		return ""
+	}
+	u, err := url.Parse(href)
+	if err != nil {
+		return ""
+	}
+	if !u.IsAbs() {
+		if w.base == nil {
+			return ""
+		}
+		u = w.base.ResolveReference(u)
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https", "mailto", "tg":
+		return u.String()
+	}
+	return ""
+}
+
+func attr(n *nethtml.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// listMarker 列表项前缀，有序列表使用序号
+func listMarker(n *nethtml.Node) string {
+	if n.Parent == nil || n.Parent.DataAtom != atom.Ol {
+		return "• "
+	}
+	index := 1
+	if start, err := strconv.Atoi(attr(n.Parent, "start")); err == nil {
+		index = start
+	}
+	for c := n.Parent.FirstChild; c != nil && c != n; c = c.NextSibling {
+		if c.Type == nethtml.ElementNode && c.DataAtom == atom.Li {
+			index++
+		}
+	}
+	return strconv.Itoa(index) + ". "
+}
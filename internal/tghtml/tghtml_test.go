@@ -0,0 +1,87 @@
+package tghtml
+
+import (
+	"testing"
+)
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		name  string
+		src   string
+		base  string
+		limit int
+		want  string
+	}{
+		{"plain text", "hello  world", "", 0, "hello world"},
+		{"inline tags", "<strong>bold</strong> and <em>italic</em> <del>x</del>", "", 0, "<b>bold</b> and <i>italic</i> <s>x</s>"},
+		{"escape text", "<p>a &lt; b &amp;&amp; c &gt; d</p>", "", 0, "a &lt; b &amp;&amp; c &gt; d"},
+		{"paragraphs", "<p>first</p><p>second</p>", "", 0, "first\n\nsecond"},
+		{"line breaks", "a<br>b<br/><br>c", "", 0, "a\nb\nc"},
+		{"unordered list", "<ul><li>one</li><li>two</li></ul>", "", 0, "• one\n• two"},
+		{"ordered list", `<ol start="3"><li>one</li><li>two</li></ol>`, "", 0, "3. one\n4. two"},
+		{"drop scripts and images", `<script>alert(1)</script><p>text<img src="a.png"></p><style>p{}</style>`, "", 0, "text"},
+		{"link", `<a href="/post?a=1&b=2">post</a>`, "https://example.com/blog/", 0, `<a href="https://example.com/post?a=1&amp;b=2">post</a>`},
+		{"relative link without base", `<a href="/post">post</a>`, "", 0, "post"},
+		{"javascript link", `<a href="javascript:alert(1)">x</a>`, "", 0, "x"},
+		{"empty link", `<a href="https://example.com/"><img src="a.png"></a>text`, "", 0, "text"},
+		{"nested link", `<a href="https://a.com/">a <a href="https://b.com/">b</a></a>`, "", 0, `<a href="https://a.com/">a</a> <a href="https://b.com/">b</a>`},
+		{"pre", "<pre><code>func main() {\n\treturn\n}</code></pre>", "", 0, "<pre>func main() {\n\treturn\n}</pre>"},
+		{"blockquote", "<p>a</p><blockquote>quote</blockquote>b", "", 0, "a\n\n<blockquote>quote</blockquote>\n\nb"},
+		{"unknown tags", "<span>a</span><font>b</font>", "", 0, "ab"},
+		{"truncate", "hello world", "", 8, "hello w…"},
+		{"truncate runes", "这是一段很长的中文", "", 5, "这是一段…"},
+		{"truncate inside tag", "<b>hello world</b> tail", "", 6, "<b>hello…</b>"},
+		{"truncate nested", "<b>bold <i>italic text</i></b>", "", 10, "<b>bold <i>ital…</i></b>"},
+		{"truncate entity", "a &amp; b &amp; c", "", 4, "a &amp;…"},
+		{"truncate before tag", "hello <b>world</b>", "", 6, "hello…"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Convert(tt.src, tt.base, tt.limit); got != tt.want {
+				t.Errorf("Convert() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestText(t *testing.T) {
+	tests := []struct {
+		name  string
+		src   string
+		limit int
+		want  string
+	}{
+		{"strip tags", "<p><b>a</b> &amp; <a href=\"https://a.com\">b</a></p><p>c</p>", 0, "a & b\n\nc"},
+		{"truncate", "<p>hello world</p>", 6, "hello…"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Text(tt.src, tt.limit); got != tt.want {
+				t.Errorf("Text() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertRest(t *testing.T) {
+	tests := []struct {
+		name  string
+		src   string
+		skip  int
+		limit int
+		want  string
+	}{
+		{"rest", "hello world", 7, 0, "…orld"},
+		{"rest at word boundary", "<b>hello world</b> tail", 5, 0, "…<b>world</b> tail"},
+		{"rest inside tag", "<b>bold <i>italic text</i></b>", 9, 0, "…<b><i>ic text</i></b>"},
+		{"rest limit", "hello world foo", 7, 12, "…orld…"},
+		{"skip all", "hello", 5, 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ConvertRest(tt.src, "", tt.skip, tt.limit); got != tt.want {
+				t.Errorf("ConvertRest() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
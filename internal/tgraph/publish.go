@@ -1,38 +1,198 @@
 package tgraph
 
 import (
+	"bytes"
 	"fmt"
-	"html"
-	"math/rand"
-	"time"
+	"html/template"
 
+	"github.com/indes/flowerss-bot/internal/config"
+	"github.com/indes/flowerss-bot/internal/i18n"
+
+	"github.com/indes/telegraph-go"
 	"go.uber.org/zap"
 )
 
-func PublishHtml(sourceTitle string, title string, rawLink string, htmlContent string) (string, error) {
-	//html = fmt.Sprintf(
-	//	"<p>本文章由 <a href=\"https://github.com/indes/flowerss-bot\">flowerss</a> 抓取自RSS，版权归<a href=\"\">源站点</a>所有。</p><hr>",
-	//) + html + fmt.Sprintf(
-	//	"<hr><p>本文章由 <a href=\"https://github.com/indes/flowerss-bot\">flowerss</a> 抓取自RSS，版权归<a href=\"\">源站点</a>所有。</p><p>查看原文：<a href=\"%s\">%s - %s</p>",
-	//	rawLink,
-	//	title,
-	//	sourceTitle,
-	//)
-
-	htmlContent = html.UnescapeString(htmlContent) + fmt.Sprintf(
-		"<hr><p>本文章由 <a href=\"https://github.com/indes/flowerss-bot\">flowerss</a> 抓取自RSS，版权归<a href=\"\">源站点</a>所有。</p><p>查看原文：<a href=\"%s\">%s - %s</p>",
-		rawLink,
-		title,
-		sourceTitle,
-	)
-	rand.Seed(time.Now().Unix()) // initialize global pseudo random generator
-	client := clientPool[rand.Intn(len(clientPool))]
+const (
+	// maxTitleLength telegraph 页面标题的最大长度
+	maxTitleLength = 256
+	// maxShortNameLength telegraph 账号名称的最大长度
+	maxShortNameLength = 32
+)
+
+// footerTpl 自定义的页脚模版，为空时使用页面语言的默认页脚
+var footerTpl = parseFooter(config.TelegraphFooter)
+
+// Article 待发布的文章
+type Article struct {
+	SourceTitle string
+	// SourceLink 源站点地址
+	SourceLink   string
+	ContentTitle string
+	RawLink      string
+	// Content 文章 HTML 内容
+	Content string
+	// Lang 页脚与翻页链接使用的语言，为空时使用默认语言
+	Lang string
+	// Created 上次发布中断前已创建的页面地址，从最后一页起依次排列，重试时不再重复创建
+	Created []string
+	// OnCreated 每创建一个页面后以已创建的全部页面地址调用，用于保存发布进度
+	OnCreated func(created []string)
+}
+
+// Account 会话自有的 telegraph 账号，页面署名为会话
+type Account struct {
+	AccessToken string
+	AuthorName  string
+	AuthorURL   string
+}
+
+// parseFooter 解析自定义页脚模版，未设置或模版有误时返回 nil 以使用默认页脚
+func parseFooter(text string) *template.Template {
+	if text == "" {
+		return nil
+	}
+	tpl, err := template.New("footer").Parse(text)
+	if err != nil {
+		zap.S().Errorw("parse telegraph footer failed, use default footer", "error", err)
+		return nil
+	}
+	return tpl
+}
+
+func (a *Article) lang() string {
+	if a.Lang == "" {
+		return i18n.Default()
+	}
+	return a.Lang
+}
+
+// footerHTML 渲染页脚模版
+func (a *Article) footerHTML() (string, error) {
+	tpl := footerTpl
+	if tpl == nil {
+		var err error
+		if tpl, err = template.New("footer").Parse(i18n.T(a.lang(), "telegraph.footer")); err != nil {
+			return "", err
+		}
+	}
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, a); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// footer 页脚节点
+func (a *Article) footer() []telegraph.Node {
+	footer, err := a.footerHTML()
+	if err != nil {
+		zap.S().Warnw("render telegraph footer failed", "error", err)
+		return nil
+	}
+	return Sanitize(footer, a.RawLink)
+}
+
+// PublishHtml 创建 telegraph 页面，内容过长时拆分为多个依次链接的页面并返回第一页地址，
+// account 为空时使用全局账号，限流时返回 *FloodWaitError
+func PublishHtml(article *Article, account *Account) (string, error) {
+	footer := article.footer()
+	pages := splitPages(Sanitize(article.Content, article.RawLink), maxPageSize)
+	if len(pages) == 0 {
+		pages = [][]telegraph.Node{nil}
+	}
+	// 已创建的页面数不少于总页数时说明内容已变化，重新创建全部页面
+	created := article.Created
+	if len(created) >= len(pages) {
+		created = nil
+	}
 
-	if page, err := client.CreatePageWithHTML(title+" - "+sourceTitle, sourceTitle, rawLink, htmlContent, true); err == nil {
-		zap.S().Infof("Created telegraph page url: %s", page.URL)
-		return page.URL, err
+	var (
+		client     *telegraph.Client
+		pc         *poolClient
+		authorName = article.SourceTitle
+		authorURL  = article.RawLink
+	)
+	if account != nil {
+		client = &telegraph.Client{AccessToken: account.AccessToken, Socks5Proxy: socks5Proxy}
+		authorName, authorURL = account.AuthorName, account.AuthorURL
 	} else {
-		zap.S().Warnf("Create telegraph page failed, error: %s", err)
-		return "", nil
+		var err error
+		if pc, err = pool.get(); err != nil {
+			return "", err
+		}
+		client = pc.client
+	}
+
+	// 从最后一页开始创建，以便在每页末尾链接到下一页，跳过上次已创建的页面
+	var next string
+	if len(created) > 0 {
+		next = created[len(created)-1]
+	}
+	for i := len(pages) - 1 - len(created); i >= 0; i-- {
+		content := pages[i]
+		title := article.ContentTitle + " - " + article.SourceTitle
+		if len(pages) > 1 {
+			title = fmt.Sprintf("%s (%d/%d)", title, i+1, len(pages))
+		}
+		if next != "" {
+			content = append(content, element("p", nil, element("a", map[string]string{"href": next}, i18n.T(article.lang(), "telegraph.next"))))
+		}
+		content = append(content, footer...)
+
+		page, err := client.CreatePage(pageTitle(title), authorName, authorURL, content, false)
+		if pc != nil {
+			pool.report(pc, err)
+		}
+		if err != nil {
+			zap.S().Warnf("Create telegraph page failed, error: %s", err)
+			if floodErr, ok := parseFloodWait(err); ok {
+				return "", floodErr
+			}
+			return "", err
+		}
+		next = page.URL
+		created = append(created, next)
+		if article.OnCreated != nil && i > 0 {
+			article.OnCreated(created)
+		}
+	}
+	zap.S().Infof("Created telegraph page url: %s, pages: %d", next, len(pages))
+	return next, nil
+}
+
+// CreateAccount 创建 telegraph 账号，返回 access token
+func CreateAccount(shortName, authorName, authorURL string) (string, error) {
+	client, err := telegraph.Create(accountShortName(shortName), authorName, authorURL, socks5Proxy)
+	if err != nil {
+		return "", err
+	}
+	return client.AccessToken, nil
+}
+
+// EditAccount 修改 telegraph 账号信息
+func EditAccount(accessToken, shortName, authorName, authorURL string) error {
+	client := &telegraph.Client{AccessToken: accessToken, Socks5Proxy: socks5Proxy}
+	_, err := client.EditAccountInfo(accountShortName(shortName), authorName, authorURL)
+	return err
+}
+
+// accountShortName 截取账号名称，为空时使用项目名称
+func accountShortName(name string) string {
+	runes := []rune(name)
+	if len(runes) == 0 {
+		return config.ProjectName
+	}
+	if len(runes) > maxShortNameLength {
+		return string(runes[:maxShortNameLength])
+	}
+	return name
+}
+
+// pageTitle 截取页面标题
+func pageTitle(title string) string {
+	runes := []rune(title)
+	if len(runes) > maxTitleLength {
+		return string(runes[:maxTitleLength-1]) + "…"
 	}
+	return title
 }
@@ -0,0 +1,358 @@
+package tgraph
+
+import (
+	"encoding/json"
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/indes/telegraph-go"
+	nethtml "golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// maxPageSize 单个页面内容的大小上限，telegraph 限制为 64KB，预留页脚与翻页链接的空间
+const maxPageSize = 60 << 10
+
+// allowedTags telegraph 支持的标签，value 为转换后的标签名
+var allowedTags = map[atom.Atom]string{
+	atom.A:          "a",
+	atom.Aside:      "aside",
+	atom.B:          "b",
+	atom.Strong:     "strong",
+	atom.Blockquote: "blockquote",
+	atom.Q:          "blockquote",
+	atom.Br:         "br",
+	atom.Code:       "code",
+	atom.Kbd:        "code",
+	atom.Samp:       "code",
+	atom.Em:         "em",
+	atom.I:          "i",
+	atom.Cite:       "i",
+	atom.Figure:     "figure",
+	atom.Figcaption: "figcaption",
+	atom.H1:         "h3",
+	atom.H2:         "h3",
+	atom.H3:         "h3",
+	atom.H4:         "h4",
+	atom.H5:         "h4",
+	atom.H6:         "h4",
+	atom.Hr:         "hr",
+	atom.Img:        "img",
+	atom.Li:         "li",
+	atom.Ol:         "ol",
+	atom.Ul:         "ul",
+	atom.P:          "p",
+	atom.Pre:        "pre",
+	atom.S:          "s",
+	atom.Strike:     "s",
+	atom.Del:        "s",
+	atom.U:          "u",
+	atom.Ins:        "u",
+	atom.Video:      "video",
+	atom.Iframe:     "iframe",
+}
+
+// paragraphTags 转换为段落的块级标签
+var paragraphTags = map[atom.Atom]bool{
+	atom.Tr: true,
+	atom.Dt: true,
+	atom.Dd: true,
+}
+
+// droppedTags 连同内容一起丢弃的标签
+var droppedTags = map[atom.Atom]bool{
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Noscript: true,
+	atom.Head:     true,
+	atom.Title:    true,
+	atom.Object:   true,
+	atom.Embed:    true,
+	atom.Svg:      true,
+	atom.Math:     true,
+	atom.Form:     true,
+	atom.Button:   true,
+	atom.Select:   true,
+	atom.Textarea: true,
+	atom.Template: true,
+	atom.Source:   true,
+	atom.Track:    true,
+}
+
+var (
+	youtubeRegexp = regexp.MustCompile(`^(?:https?:)?//(?:www\.)?(?:youtube\.com|youtube-nocookie\.com)/embed/([\w-]+)`)
+	vimeoRegexp   = regexp.MustCompile(`^(?:https?:)?//player\.vimeo\.com/video/(\d+)`)
+)
+
+// Sanitize 将 feed 中的 HTML 转换为 telegraph 节点，相对地址基于 base 解析
+func Sanitize(src string, base string) []telegraph.Node {
+	// 部分 feed 的内容被转义了两次
+	if !strings.Contains(src, "<") && strings.Contains(src, "&lt;") {
+		src = html.UnescapeString(src)
+	}
+	nodes, err := nethtml.ParseFragment(strings.NewReader(src), &nethtml.Node{
+		Type:     nethtml.ElementNode,
+		Data:     "body",
+		DataAtom: atom.Body,
+	})
+	if err != nil {
+		return nil
+	}
+
+	s := &sanitizer{}
+	if u, err := url.Parse(base); err == nil && u.IsAbs() {
+		s.base = u
+	}
+	var result []telegraph.Node
+	for _, n := range nodes {
+		result = append(result, s.node(n)...)
+	}
+	return trimSpaceNodes(result)
+}
+
+type sanitizer struct {
+	base *url.URL
+}
+
+// node 转换单个节点，不支持的标签保留其内容
+func (s *sanitizer) node(n *nethtml.Node) []telegraph.Node {
+	switch n.Type {
+	case nethtml.TextNode:
+		if n.Data == "" {
+			return nil
+		}
+		return []telegraph.Node{n.Data}
+	case nethtml.ElementNode:
+	default:
+		return nil
+	}
+	if droppedTags[n.DataAtom] {
+		return nil
+	}
+
+	switch n.DataAtom {
+	case atom.Img:
+		src := s.resolve(imageSource(n))
+		if src == "" {
+			return nil
+		}
+		return []telegraph.Node{element("img", map[string]string{"src": src})}
+	case atom.Video:
+		src := s.resolve(mediaSource(n))
+		if src == "" {
+			return nil
+		}
+		return []telegraph.Node{element("video", map[string]string{"src": src})}
+	case atom.Audio:
+		src := s.resolve(mediaSource(n))
+		if src == "" {
+			return nil
+		}
+		return []telegraph.Node{element("p", nil, element("a", map[string]string{"href": src}, src))}
+	case atom.Iframe:
+		return s.iframe(n)
+	case atom.A:
+		children := s.children(n)
+		href := s.resolve(attr(n, "href"))
+		if href == "" || len(children) == 0 {
+			return children
+		}
+		return []telegraph.Node{element("a", map[string]string{"href": href}, children...)}
+	}
+
+	if paragraphTags[n.DataAtom] {
+		children := trimSpaceNodes(s.children(n))
+		if len(children) == 0 {
+			return nil
+		}
+		return []telegraph.Node{element("p", nil, children...)}
+	}
+
+	tag, ok := allowedTags[n.DataAtom]
+	if !ok {
+		return s.children(n)
+	}
+	children := s.children(n)
+	switch tag {
+	case "br", "hr":
+		return []telegraph.Node{element(tag, nil)}
+	case "p", "li", "h3", "h4", "figcaption", "blockquote", "aside":
+		children = trimSpaceNodes(children)
+	}
+	if len(children) == 0 {
+		return nil
+	}
+	return []telegraph.Node{element(tag, nil, children...)}
+}
+
+func (s *sanitizer) children(n *nethtml.Node) []telegraph.Node {
+	var nodes []telegraph.Node
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		nodes = append(nodes, s.node(c)...)
+	}
+	return nodes
+}
+
+// iframe YouTube 与 Vimeo 转换为 telegraph 嵌入，其他 iframe 转换为链接
+func (s *sanitizer) iframe(n *nethtml.Node) []telegraph.Node {
+	src := attr(n, "src")
+	var embed string
+	if m := youtubeRegexp.FindStringSubmatch(src); m != nil {
+		embed = "/embed/youtube?url=" + url.QueryEscape("https://www.youtube.com/watch?v="+m[1])
+	} else if m := vimeoRegexp.FindStringSubmatch(src); m != nil {
+		embed = "/embed/vimeo?url=" + url.QueryEscape("https://vimeo.com/"+m[1])
+	}
+	if embed != "" {
+		return []telegraph.Node{element("figure", nil, element("iframe", map[string]string{"src": embed}))}
+	}
+	if src = s.resolve(src); src == "" {
+		return nil
+	}
+	return []telegraph.Node{element("p", nil, element("a", map[string]string{"href": src}, src))}
+}
+
+// resolve 解析地址，仅保留 http 与 https 协议
+func (s *sanitizer) resolve(ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return ""
+	}
+	u, err := url.Parse(ref)
+	if err != nil {
+		return ""
+	}
+	if !u.IsAbs() {
+		if s.base == nil {
+			return ""
+		}
+		u = s.base.ResolveReference(u)
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+		return u.String()
+	}
+	return ""
+}
+
+func element(tag string, attrs map[string]string, children ...telegraph.Node) telegraph.Node {
+	return telegraph.NodeElement{Tag: tag, Attrs: attrs, Children: children}
+}
+
+func attr(n *nethtml.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// imageSource 图片地址，兼容懒加载的 data-src
+func imageSource(n *nethtml.Node) string {
+	for _, key := range []string{"data-src", "data-original", "src"} {
+		if src := attr(n, key); src != "" && !strings.HasPrefix(src, "data:") {
+			return src
+		}
+	}
+	return ""
+}
+
+// mediaSource 音视频地址，未设置 src 时使用第一个 source
+func mediaSource(n *nethtml.Node) string {
+	if src := attr(n, "src"); src != "" {
+		return src
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == nethtml.ElementNode && c.DataAtom == atom.Source {
+			if src := attr(c, "src"); src != "" {
+				return src
+			}
+		}
+	}
+	return ""
+}
+
+// trimSpaceNodes 去除首尾的空白文本节点
+func trimSpaceNodes(nodes []telegraph.Node) []telegraph.Node {
+	for len(nodes) > 0 {
+		if text, ok := nodes[0].(string); ok && strings.TrimSpace(text) == "" {
+			nodes = nodes[1:]
+			continue
+		}
+		break
+	}
+	for len(nodes) > 0 {
+		if text, ok := nodes[len(nodes)-1].(string); ok && strings.TrimSpace(text) == "" {
+			nodes = nodes[:len(nodes)-1]
+			continue
+		}
+		break
+	}
+	return nodes
+}
+
+// nodeSize 节点序列化后的大小
+func nodeSize(n telegraph.Node) int {
+	data, _ := json.Marshal(n)
+	return len(data)
+}
+
+// splitPages 按大小将节点拆分为多个页面
+func splitPages(nodes []telegraph.Node, limit int) [][]telegraph.Node {
+	var pages [][]telegraph.Node
+	var page []telegraph.Node
+	size := 0
+	for _, n := range nodes {
+		for _, part := range splitNode(n, limit) {
+			partSize := nodeSize(part) + 1
+			if size+partSize > limit && len(page) > 0 {
+				pages = append(pages, page)
+				page, size = nil, 0
+			}
+			page = append(page, part)
+			size += partSize
+		}
+	}
+	if len(page) > 0 {
+		pages = append(pages, page)
+	}
+	return pages
+}
+
+// splitNode 将超出大小的节点拆分为多个同类节点
+func splitNode(n telegraph.Node, limit int) []telegraph.Node {
+	if nodeSize(n) <= limit {
+		return []telegraph.Node{n}
+	}
+	switch v := n.(type) {
+	case string:
+		var parts []telegraph.Node
+		runes := []rune(v)
+		// json 转义后单个字符最多占 6 字节
+		step := limit / 6
+		if step < 1 {
+			step = 1
+		}
+		for len(runes) > 0 {
+			if len(runes) < step {
+				step = len(runes)
+			}
+			parts = append(parts, string(runes[:step]))
+			runes = runes[step:]
+		}
+		return parts
+	case telegraph.NodeElement:
+		var children []telegraph.Node
+		for _, child := range v.Children {
+			children = append(children, splitNode(child, limit)...)
+		}
+		var parts []telegraph.Node
+		for _, page := range splitPages(children, limit-nodeSize(telegraph.NodeElement{Tag: v.Tag, Attrs: v.Attrs})) {
+			parts = append(parts, telegraph.NodeElement{Tag: v.Tag, Attrs: v.Attrs, Children: page})
+		}
+		return parts
+	}
+	return nil
+}
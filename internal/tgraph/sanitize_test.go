@@ -0,0 +1,124 @@
+package tgraph
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/indes/telegraph-go"
+)
+
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		base string
+		want string
+	}{
+		{
+			"unsupported tags unwrapped",
+			`<div class="post"><span>hello</span> <font>world</font></div>`,
+			"",
+			`["hello"," ","world"]`,
+		},
+		{
+			"headings mapped",
+			`<h1>title</h1><h5>sub</h5>`,
+			"",
+			`[{"tag":"h3","children":["title"]},{"tag":"h4","children":["sub"]}]`,
+		},
+		{
+			"script dropped",
+			`<p>text<script>alert(1)</script></p>`,
+			"",
+			`[{"tag":"p","children":["text"]}]`,
+		},
+		{
+			"relative urls resolved",
+			`<a href="/post/2">next</a><img src="img/a.png">`,
+			"https://example.com/post/1",
+			`[{"tag":"a","attrs":{"href":"https://example.com/post/2"},"children":["next"]},{"tag":"img","attrs":{"src":"https://example.com/post/img/a.png"}}]`,
+		},
+		{
+			"javascript link unwrapped",
+			`<a href="javascript:void(0)">click</a>`,
+			"https://example.com/",
+			`["click"]`,
+		},
+		{
+			"lazy image",
+			`<img src="data:image/gif;base64,R0lGOD" data-src="https://example.com/a.jpg">`,
+			"",
+			`[{"tag":"img","attrs":{"src":"https://example.com/a.jpg"}}]`,
+		},
+		{
+			"youtube embed",
+			`<iframe src="https://www.youtube.com/embed/dQw4w9WgXcQ?rel=0"></iframe>`,
+			"",
+			`[{"tag":"figure","children":[{"tag":"iframe","attrs":{"src":"/embed/youtube?url=https%3A%2F%2Fwww.youtube.com%2Fwatch%3Fv%3DdQw4w9WgXcQ"}}]}]`,
+		},
+		{
+			"vimeo embed",
+			`<iframe src="//player.vimeo.com/video/76979871"></iframe>`,
+			"",
+			`[{"tag":"figure","children":[{"tag":"iframe","attrs":{"src":"/embed/vimeo?url=https%3A%2F%2Fvimeo.com%2F76979871"}}]}]`,
+		},
+		{
+			"other iframe as link",
+			`<iframe src="https://example.com/widget"></iframe>`,
+			"",
+			`[{"tag":"p","children":[{"tag":"a","attrs":{"href":"https://example.com/widget"},"children":["https://example.com/widget"]}]}]`,
+		},
+		{
+			"video source",
+			`<video controls><source src="/v.mp4" type="video/mp4"></video>`,
+			"https://example.com/",
+			`[{"tag":"video","attrs":{"src":"https://example.com/v.mp4"}}]`,
+		},
+		{
+			"double escaped",
+			`&lt;p&gt;hi&lt;/p&gt;`,
+			"",
+			`[{"tag":"p","children":["hi"]}]`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(Sanitize(tt.src, tt.base))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("Sanitize() = %s, want %s", data, tt.want)
+			}
+		})
+	}
+}
+
+func Test_splitPages(t *testing.T) {
+	paragraph := element("p", nil, strings.Repeat("a", 100))
+	tests := []struct {
+		name      string
+		nodes     []telegraph.Node
+		limit     int
+		wantPages int
+	}{
+		{"empty", nil, 1000, 0},
+		{"fits", []telegraph.Node{paragraph, paragraph}, 1000, 1},
+		{"split top level", []telegraph.Node{paragraph, paragraph, paragraph}, 300, 2},
+		{"split large node", []telegraph.Node{element("p", nil, strings.Repeat("b", 1000))}, 300, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pages := splitPages(tt.nodes, tt.limit)
+			if len(pages) != tt.wantPages {
+				t.Fatalf("splitPages() pages = %d, want %d", len(pages), tt.wantPages)
+			}
+			for _, page := range pages {
+				if size := nodeSize(page); size > tt.limit {
+					t.Errorf("page size = %d, limit %d", size, tt.limit)
+				}
+			}
+		})
+	}
+}
@@ -1,6 +1,13 @@
 package tgraph
 
 import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
 	"github.com/indes/flowerss-bot/internal/config"
 
 	"github.com/indes/telegraph-go"
@@ -10,6 +17,11 @@ import (
 const (
 	//verbose = false
 	htmlContent = `<h1>hello</h1>`
+
+	// clientBaseBackoff 客户端出错后暂停使用的初始时长
+	clientBaseBackoff = time.Minute
+	// clientMaxBackoff 客户端出错后暂停使用的最长时长
+	clientMaxBackoff = 30 * time.Minute
 )
 
 var (
@@ -19,9 +31,114 @@ var (
 	authorName  = "flowerss"
 	verbose     = false
 	//client     *telegraph.Client
-	clientPool []*telegraph.Client
+	pool = &clientPool{}
+
+	// ErrNoAvailableClient 所有 telegraph token 均暂停使用
+	ErrNoAvailableClient = errors.New("no available telegraph client")
 )
 
+// FloodWaitError telegraph 限流，需等待 Wait 后重试
+type FloodWaitError struct {
+	Wait time.Duration
+}
+
+func (e *FloodWaitError) Error() string {
+	return fmt.Sprintf("telegraph flood wait %s", e.Wait)
+}
+
+// parseFloodWait 解析 FLOOD_WAIT_X 错误
+func parseFloodWait(err error) (*FloodWaitError, bool) {
+	msg := err.Error()
+	if !strings.HasPrefix(msg, "FLOOD_WAIT_") {
+		return nil, false
+	}
+	seconds, convErr := strconv.Atoi(strings.TrimPrefix(msg, "FLOOD_WAIT_"))
+	if convErr != nil {
+		return nil, false
+	}
+	return &FloodWaitError{Wait: time.Duration(seconds) * time.Second}, true
+}
+
+// IsContentError 页面内容导致的错误，更换 token 重试无法恢复
+func IsContentError(err error) bool {
+	msg := err.Error()
+	return strings.HasPrefix(msg, "CONTENT_") || strings.HasPrefix(msg, "TITLE_") ||
+		strings.HasPrefix(msg, "AUTHOR_")
+}
+
+// poolClient 带健康状态的 telegraph 客户端
+type poolClient struct {
+	client        *telegraph.Client
+	failures      int
+	disabledUntil time.Time
+}
+
+// clientPool 按 token 记录健康状态，轮询使用可用的客户端
+type clientPool struct {
+	mu      sync.Mutex
+	clients []*poolClient
+	next    int
+}
+
+func (p *clientPool) add(client *telegraph.Client) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.clients = append(p.clients, &poolClient{client: client})
+}
+
+func (p *clientPool) size() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.clients)
+}
+
+// get 轮询获取一个可用的客户端
+func (p *clientPool) get() (*poolClient, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := time.Now()
+	for i := 0; i < len(p.clients); i++ {
+		idx := (p.next + i) % len(p.clients)
+		if c := p.clients[idx]; !now.Before(c.disabledUntil) {
+			p.next = idx + 1
+			return c, nil
+		}
+	}
+	return nil, ErrNoAvailableClient
+}
+
+// report 记录客户端的调用结果，限流或出错时暂停使用
+func (p *clientPool) report(c *poolClient, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err == nil {
+		c.failures = 0
+		c.disabledUntil = time.Time{}
+		return
+	}
+	if floodErr, ok := parseFloodWait(err); ok {
+		c.disabledUntil = time.Now().Add(floodErr.Wait)
+		return
+	}
+	if IsContentError(err) {
+		return
+	}
+	c.failures++
+	backoff := clientBaseBackoff
+	for i := 1; i < c.failures && backoff < clientMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > clientMaxBackoff {
+		backoff = clientMaxBackoff
+	}
+	c.disabledUntil = time.Now().Add(backoff)
+}
+
+// Enabled 是否有可用的 telegraph 账号
+func Enabled() bool {
+	return config.EnableTelegraph && pool.size() > 0
+}
+
 func init() {
 	if config.EnableTelegraph {
 		zap.S().Infow("telegraph enabled",
@@ -39,11 +156,11 @@ func init() {
 					"token", t,
 				)
 			} else {
-				clientPool = append(clientPool, client)
+				pool.add(client)
 			}
 		}
 
-		if len(clientPool) == 0 {
+		if pool.size() == 0 {
 			if config.TelegraphAccountName == "" {
 				config.EnableTelegraph = false
 				zap.S().Error("telegraph token error, telegraph disabled")
@@ -59,9 +176,10 @@ func init() {
 				if err != nil {
 					config.EnableTelegraph = false
 					zap.S().Errorw("create telegraph account fail, telegraph disabled", "error", err)
+					return
 				}
 
-				clientPool = append(clientPool, client)
+				pool.add(client)
 				zap.S().Infow("create telegraph account success",
 					"telegraph token", client.AccessToken)
 
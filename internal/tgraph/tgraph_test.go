@@ -0,0 +1,110 @@
+package tgraph
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/indes/telegraph-go"
+)
+
+func Test_parseFloodWait(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		want   time.Duration
+		wantOk bool
+	}{
+		{"flood wait", errors.New("FLOOD_WAIT_7"), 7 * time.Second, true},
+		{"other error", errors.New("CONTENT_TOO_BIG"), 0, false},
+		{"malformed", errors.New("FLOOD_WAIT_X"), 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseFloodWait(tt.err)
+			if ok != tt.wantOk {
+				t.Fatalf("parseFloodWait() ok = %v, want %v", ok, tt.wantOk)
+			}
+			if ok && got.Wait != tt.want {
+				t.Errorf("parseFloodWait() = %v, want %v", got.Wait, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientPool(t *testing.T) {
+	p := &clientPool{}
+	p.add(&telegraph.Client{AccessToken: "a"})
+	p.add(&telegraph.Client{AccessToken: "b"})
+
+	first, _ := p.get()
+	second, _ := p.get()
+	if first == second {
+		t.Fatalf("get() should round-robin between clients")
+	}
+
+	p.report(first, errors.New("FLOOD_WAIT_60"))
+	for i := 0; i < 3; i++ {
+		if c, _ := p.get(); c != second {
+			t.Fatalf("get() returned a flood-limited client")
+		}
+	}
+
+	p.report(second, errors.New("CONTENT_TOO_BIG"))
+	if c, _ := p.get(); c != second {
+		t.Fatalf("content errors should not disable the client")
+	}
+
+	p.report(second, errors.New("connection refused"))
+	if _, err := p.get(); err != ErrNoAvailableClient {
+		t.Fatalf("get() error = %v, want ErrNoAvailableClient", err)
+	}
+
+	p.report(first, nil)
+	if c, _ := p.get(); c != first {
+		t.Fatalf("get() should return the recovered client")
+	}
+}
+
+func TestArticle_footerHTML(t *testing.T) {
+	tests := []struct {
+		name    string
+		article Article
+		want    []string
+	}{
+		{
+			"with site link",
+			Article{SourceTitle: "Blog", SourceLink: "https://example.com", ContentTitle: "Post", RawLink: "https://example.com/1"},
+			[]string{`版权归<a href="https://example.com">Blog</a>所有`, `<a href="https://example.com/1">Post - Blog</a>`},
+		},
+		{
+			"without site link",
+			Article{SourceTitle: "Blog", ContentTitle: "Post", RawLink: "https://example.com/1"},
+			[]string{`版权归Blog所有`},
+		},
+		{
+			"escaped",
+			Article{SourceTitle: "<b>Blog</b>", ContentTitle: "Post", RawLink: "javascript:alert(1)"},
+			[]string{`&lt;b&gt;Blog&lt;/b&gt;`, `href="#ZgotmplZ"`},
+		},
+		{
+			"english",
+			Article{SourceTitle: "Blog", ContentTitle: "Post", RawLink: "https://example.com/1", Lang: "en"},
+			[]string{`all rights reserved by Blog.`, `Original: <a href="https://example.com/1">Post - Blog</a>`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.article.footerHTML()
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("footerHTML() = %s, want contains %s", got, want)
+				}
+			}
+		})
+	}
+}